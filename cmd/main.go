@@ -20,6 +20,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"strings"
 
@@ -33,7 +34,10 @@ import (
 	mountManager "github.com/IBM/ibm-csi-common/pkg/mountmanager"
 	"github.com/IBM/ibm-csi-common/pkg/utils"
 	csiConfig "github.com/IBM/ibm-vpc-file-csi-driver/config"
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/driverconfig"
 	driver "github.com/IBM/ibm-vpc-file-csi-driver/pkg/ibmcsidriver"
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/tracing"
 	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
 	nodeInfoManager "github.com/IBM/ibmcloud-volume-file-vpc/pkg/metadata"
 	"github.com/IBM/ibmcloud-volume-file-vpc/pkg/watcher"
@@ -52,10 +56,37 @@ func init() {
 
 var (
 	endpoint             = flag.String("endpoint", "unix:/tmp/csi.sock", "CSI endpoint")
+	configFile           = flag.String("config", "", "YAML driver config file, e.g. a mounted ConfigMap. Its settings override the environment variables; vpcID, vpcSubnetIDs, vpcZoneSubnetIDs, clusterSecurityGroupID, snapshotEnabled and clusterEnv are reloaded when the file changes.")
+	mode                 = flag.String("mode", "", "Driver mode: 'controller' for the csi-controller deployment, 'node' for the csi-node daemonset or 'all'. When empty it is derived from the deprecated IS_NODE_SERVER environment variable.")
 	metricsAddress       = flag.String("metrics-address", "0.0.0.0:9080", "Metrics address")
 	vendorVersion        string
 	extraVolumeLabelsStr = flag.String("extra-labels", "", "Extra labels to tag all volumes created by driver. It is a comma separated list of key value pairs like '<key1>:<value1>,<key2>:<value2>'.")
 	logger               *zap.Logger
+
+	circuitBreakerFailureThreshold = flag.Int("circuit-breaker-failure-threshold", driver.DefaultCircuitBreakerFailureThreshold, "Consecutive VPC backend failures after which controller requests fail fast with Unavailable. 0 disables the circuit breaker.")
+	circuitBreakerOpenTimeout      = flag.Duration("circuit-breaker-open-timeout", driver.DefaultCircuitBreakerOpenTimeout, "Time the VPC circuit breaker stays open before a probe request is allowed through.")
+	sessionCacheTTL                = flag.Duration("session-cache-ttl", driver.DefaultSessionCacheTTL, "Maximum age of the cached VPC provider session before it is re-authenticated. 0 opens a new session for every request.")
+	remountStaleMounts             = flag.Bool("remount-stale-mounts", false, "Periodically remount stale NFS mounts of this driver on the node in place, e.g. after the share target IP changed or stunnel was restarted.")
+	remountInterval                = flag.Duration("remount-interval", driver.DefaultRemountInterval, "Time between two scans for stale NFS mounts when --remount-stale-mounts is set.")
+	remountRetryInterval           = flag.Duration("remount-retry-interval", driver.DefaultRemountRetryInterval, "Minimum time between two remounts of the same volume when --remount-stale-mounts is set.")
+	kubeletDir                     = flag.String("kubelet-dir", driver.DefaultKubeletDir, "Kubelet root directory as mounted into the node server, the pod and staging paths of the volumes are below it.")
+	volumeStatsCacheTTL            = flag.Duration("volume-stats-cache-ttl", driver.DefaultVolumeStatsCacheTTL, "Time the volume stats of a share are reused by NodeGetVolumeStats for all its pod volumes, which are only probed for their health meanwhile. 0 runs a statfs for every NodeGetVolumeStats call.")
+	volumeStatsTimeout             = flag.Duration("volume-stats-timeout", driver.DefaultVolumeStatsTimeout, "Time a volume statfs may take before NodeGetVolumeStats reports the volume condition abnormal with the last known values.")
+	subDirectoryVolumes            = flag.Bool("subdirectory-volumes", false, "Provision the volumes of storage classes with parentVolumeHandle as directories of that share. The controller mounts the parent share to create and delete the directories, so its container must be allowed to mount NFS.")
+	mountHealthTimeout             = flag.Duration("mount-health-timeout", driver.DefaultMountHealthTimeout, "Time an NFS mount may take to answer a statfs before the stale mount reconciler considers it stale and NodeGetVolumeStats reports it unresponsive.")
+
+	otlpEndpoint     = flag.String("otlp-endpoint", "", "OTLP gRPC collector address (host:port) to export OpenTelemetry traces to. Tracing is disabled when empty.")
+	otlpInsecure     = flag.Bool("otlp-insecure", false, "Connect to the OTLP collector without TLS.")
+	traceSampleRatio = flag.Float64("trace-sample-ratio", 1.0, "Fraction of CSI requests traced when tracing is enabled, between 0 and 1.")
+
+	shutdownDrainTimeout = flag.Duration("shutdown-drain-timeout", driver.DefaultShutdownDrainTimeout, "Time in-flight CSI requests may run after SIGTERM before the GRPC server is stopped forcefully. Keep it below the pod terminationGracePeriodSeconds.")
+
+	tlsCertFile          = flag.String("tls-cert-file", "", "PEM server certificate for a tcp endpoint. TLS is disabled when empty; unix sockets never use TLS. Rotated files are reloaded on the next handshake.")
+	tlsKeyFile           = flag.String("tls-key-file", "", "PEM private key of --tls-cert-file.")
+	tlsClientCAFile      = flag.String("tls-client-ca-file", "", "PEM CA bundle used to verify client certificates on a tcp endpoint.")
+	tlsRequireClientCert = flag.Bool("tls-require-client-cert", false, "Reject tcp clients without a certificate signed by --tls-client-ca-file (mTLS).")
+
+	auditLog = flag.String("audit-log", "", "Audit sink for create, delete and expand of volumes and snapshots: 'stdout' or the path of a JSON lines file. Auditing is disabled when empty.")
 )
 
 func main() {
@@ -87,6 +118,36 @@ func handle(logger *zap.Logger) {
 	}
 	logger.Info("IBM CSI driver version", zap.Reflect("DriverVersion", vendorVersion))
 	logger.Info("Controller Mutex Lock enabled", zap.Bool("LockEnabled", *utils.LockEnabled))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:       *otlpEndpoint,
+		Insecure:       *otlpInsecure,
+		SampleRatio:    *traceSampleRatio,
+		ServiceName:    csiConfig.CSIDriverGithubName,
+		ServiceVersion: vendorVersion,
+	}, logger)
+	if err != nil {
+		logger.Fatal("Failed to set up OpenTelemetry tracing", zap.Error(err))
+	}
+
+	driverMode, err := driver.ParseDriverMode(*mode)
+	if err != nil {
+		logger.Fatal("Invalid driver mode", zap.Error(err))
+	}
+	if *mode == "" {
+		logger.Warn("--mode is not set, deriving the driver mode from IS_NODE_SERVER is deprecated", zap.String("mode", string(driverMode)))
+	}
+	configStore, err := driverconfig.NewStore(*configFile, logger)
+	if err != nil {
+		logger.Fatal("Invalid driver configuration", zap.String("config", *configFile), zap.Error(err))
+	}
+	stopConfigWatch := make(chan struct{})
+	go configStore.WatchFile(driverconfig.DefaultReloadInterval, stopConfigWatch)
+	nodeName := configStore.Get().NodeName
+	if driverMode.RunsNode() && nodeName == "" {
+		logger.Fatal("KUBE_NODE_NAME must be set in node mode", zap.String("mode", string(driverMode)))
+	}
+
 	// Setup Cloud Provider
 	k8sClient, err := k8sUtils.Getk8sClientSet()
 	if err != nil {
@@ -99,12 +160,60 @@ func handle(logger *zap.Logger) {
 
 	// Setup CSI Driver
 	ibmCSIDriver := driver.GetIBMCSIDriver()
+	ibmCSIDriver.AddShutdownHook(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracing.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("Failed to flush pending spans", zap.Error(err))
+		}
+	})
+	ibmCSIDriver.SetMode(driverMode)
+	ibmCSIDriver.SetConfigStore(configStore)
+	ibmCSIDriver.AddShutdownHook(func() { close(stopConfigWatch) })
+	ibmCSIDriver.SetCircuitBreakerConfig(driver.CircuitBreakerConfig{
+		FailureThreshold: *circuitBreakerFailureThreshold,
+		OpenTimeout:      *circuitBreakerOpenTimeout,
+	})
+	ibmCSIDriver.SetSessionCacheTTL(*sessionCacheTTL)
+	ibmCSIDriver.SetMountHealthTimeout(*mountHealthTimeout)
+	ibmCSIDriver.SetSubDirectoryVolumes(*subDirectoryVolumes)
+	ibmCSIDriver.SetVolumeStatsConfig(driver.VolumeStatsConfig{
+		CacheTTL: *volumeStatsCacheTTL,
+		Timeout:  *volumeStatsTimeout,
+	})
+	if driverMode.RunsController() {
+		// sessions of the accounts in provisioner secrets are opened through the provider of the cluster credentials
+		if accountProvider, err := ibmcloudProvider.Registry.Get(ibmcloudProvider.ProviderName); err != nil {
+			logger.Warn("Cross-account provisioning is disabled, the VPC provider is not registered", zap.String("provider", ibmcloudProvider.ProviderName), zap.Error(err))
+		} else {
+			ibmCSIDriver.SetAccountSessionOpener(driver.NewAccountSessionOpener(accountProvider))
+		}
+	}
+	ibmCSIDriver.SetShutdownDrainTimeout(*shutdownDrainTimeout)
+	tlsConfig := driver.TLSConfig{
+		CertFile:          *tlsCertFile,
+		KeyFile:           *tlsKeyFile,
+		ClientCAFile:      *tlsClientCAFile,
+		RequireClientCert: *tlsRequireClientCert,
+	}
+	if err := tlsConfig.Validate(); err != nil {
+		logger.Fatal("Invalid TLS configuration", zap.Error(err))
+	}
+	ibmCSIDriver.SetTLSConfig(tlsConfig)
+	// only the controller watches PVs, the node server gets the PV named in the kubelet path of the request
+	eventRecorder := driver.NewVolumeEventRecorder(k8sClient.Clientset, csiConfig.CSIDriverName, csiConfig.CSIDriverGithubName, nodeName, driverMode.RunsController(), logger)
+	eventRecorder.Start()
+	ibmCSIDriver.AddShutdownHook(eventRecorder.Stop)
+	ibmCSIDriver.SetEventRecorder(eventRecorder)
+	auditLogger, err := driver.NewAuditLogger(*auditLog)
+	if err != nil {
+		logger.Fatal("Failed to open audit log", zap.String("auditLog", *auditLog), zap.Error(err))
+	}
+	ibmCSIDriver.SetAuditLogger(auditLogger)
 
 	// Get new instance for the Mount Manager
 	mounter := mountManager.NewNodeMounter()
 
-	nodeName := os.Getenv("KUBE_NODE_NAME")
-
 	nodeInfo := nodeInfoManager.NodeInfoManager{
 		NodeName: nodeName,
 	}
@@ -117,27 +226,46 @@ func handle(logger *zap.Logger) {
 	}
 
 	logger.Info("Successfully initialized driver...")
-	serveMetrics()
+	serveMetrics(ibmCSIDriver.HealthChecker())
 
-	// Start PV watcher if its controller POD
-	if strings.Contains(os.Getenv("POD_NAME"), "csi-controller") && strings.Contains(os.Getenv("IKS_ENABLED"), "True") {
-		pvwatcher := watcher.New(logger, csiConfig.CSIDriverName, csiConfig.CSIProviderVolumeType, ibmcloudProvider)
-		go pvwatcher.Start()
+	// The watchers only serve the controller: PV tagging on IKS, subnet list and provider session refresh
+	if driverMode.RunsController() {
+		if strings.Contains(os.Getenv("IKS_ENABLED"), "True") {
+			pvwatcher := watcher.New(logger, csiConfig.CSIDriverName, csiConfig.CSIProviderVolumeType, ibmcloudProvider)
+			go pvwatcher.Start()
+		}
+		configWatcher := driver.WatchClusterConfigMap(k8sClient.Clientset.CoreV1().RESTClient(), configStore, logger)
+		ibmCSIDriver.AddShutdownHook(configWatcher.Stop)
+		// apply the cluster provider data changed while the driver was down before serving requests
+		if !configWatcher.WaitForSync(driver.ConfigmapSyncTimeout) {
+			logger.Warn("Cluster provider data was not synced, continuing with the current settings", zap.Duration("timeout", driver.ConfigmapSyncTimeout))
+		}
+		secretWatcher := driver.WatchStorageSecretStore(k8sClient.Clientset.CoreV1().RESTClient(), k8sClient.Namespace, ibmCSIDriver, logger)
+		ibmCSIDriver.AddShutdownHook(secretWatcher.Stop)
+	}
+	if driverMode.RunsNode() && *remountStaleMounts {
+		remounter := driver.NewRemountReconciler(ibmCSIDriver, k8sClient.Clientset, driver.RemountConfig{
+			KubeletDir:    *kubeletDir,
+			Interval:      *remountInterval,
+			RetryInterval: *remountRetryInterval,
+		}, logger)
+		ibmCSIDriver.AddShutdownHook(remounter.Stop)
+		go remounter.Start()
 	}
-
-	driver.WatchClusterConfigMap(k8sClient.Clientset.CoreV1().RESTClient(), logger)
 
 	ibmCSIDriver.Run(*endpoint)
 }
 
-func serveMetrics() {
+func serveMetrics(health *driver.HealthChecker) {
 	logger.Info("Starting metrics endpoint")
 	go func() {
 		http.Handle("/metrics", promhttp.Handler())
-		//http.Handle("/health-check", healthCheck)
+		http.Handle("/healthz", health.HealthzHandler())
+		http.Handle("/readyz", health.ReadyzHandler())
 		err := http.ListenAndServe(*metricsAddress, nil) // #nosec G114: Use of net/http serve function that has no support for setting timeouts.
 		logger.Error("Failed to start metrics service:", zap.Error(err))
 	}()
 	metrics.RegisterAll(csiConfig.CSIDriverGithubName)
 	libMetrics.RegisterAll()
+	driverMetrics.RegisterAll()
 }
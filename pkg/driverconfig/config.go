@@ -0,0 +1,212 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package driverconfig holds the runtime settings of the driver, loaded from the
+// environment and an optional YAML file, and reloads the settings that are safe
+// to change while the driver is running.
+package driverconfig
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ClusterEnvProduction verifies the RFS share certificates against the production host
+	ClusterEnvProduction = "prod"
+	// ClusterEnvStaging verifies the RFS share certificates against the staging host
+	ClusterEnvStaging = "stage"
+)
+
+// supportedOSTypes worker node operating systems the stunnel CA bundle is known for
+var supportedOSTypes = map[string]bool{"RHCOS": true, "RHEL": true, "Ubuntu": true}
+
+// Config runtime settings of the driver. Every field can be set with the environment
+// variable in its comment and is overridden by the config file. Fields marked reloadable
+// are applied when the config file changes, the others only at startup.
+type Config struct {
+	// VPCID VPC of the cluster, used to look up access point subnets and security groups.
+	// VPC_ID, reloadable and updated from the ibm-cloud-provider-data ConfigMap
+	VPCID string `json:"vpcID,omitempty"`
+
+	// VPCSubnetIDs comma separated subnets access points are created in when the storage class has none,
+	// also updated from the ibm-cloud-provider-data ConfigMap. VPC_SUBNET_IDS, reloadable
+	VPCSubnetIDs string `json:"vpcSubnetIDs,omitempty"`
+
+	// VPCZoneSubnetIDs comma separated subnets by zone, preferred over VPCSubnetIDs for volumes in a listed zone.
+	// Reloadable and updated from the ibm-cloud-provider-data ConfigMap
+	VPCZoneSubnetIDs map[string]string `json:"vpcZoneSubnetIDs,omitempty"`
+
+	// ClusterSecurityGroupID security group of the access points when the storage class has none, instead of
+	// looking up kube-<clusterID> by name. Reloadable and updated from the ibm-cloud-provider-data ConfigMap
+	ClusterSecurityGroupID string `json:"clusterSecurityGroupID,omitempty"`
+
+	// SnapshotEnabled CreateSnapshot is rejected when false. IS_SNAPSHOT_ENABLED, default true, reloadable
+	SnapshotEnabled bool `json:"snapshotEnabled"`
+
+	// OSType worker node OS selecting the stunnel CA bundle: RHCOS, RHEL or Ubuntu. OS_TYPE
+	OSType string `json:"osType,omitempty"`
+
+	// ClusterEnv prod or stage, selects the host RFS share certificates are verified against; other values
+	// fall back to prod. CLUSTER_ENV, default prod, reloadable
+	ClusterEnv string `json:"clusterEnv,omitempty"`
+
+	// NodeName Kubernetes node the node server runs on. KUBE_NODE_NAME
+	NodeName string `json:"nodeName,omitempty"`
+
+	// SidecarGroupID group the node server csi socket is shared with, must match the group of the
+	// livenessprobe and node-driver-registrar sidecars. SIDECAR_GROUP_ID, default 0 (root)
+	SidecarGroupID int `json:"sidecarGroupID,omitempty"`
+}
+
+// Default ...
+func Default() Config {
+	return Config{
+		SnapshotEnabled: true,
+		ClusterEnv:      ClusterEnvProduction,
+	}
+}
+
+// Load returns the defaults, overridden by the environment and then by the YAML file at path when path is set
+func Load(path string) (Config, error) {
+	config := Default()
+	if err := config.applyEnv(); err != nil {
+		return Config{}, err
+	}
+	if path != "" {
+		data, err := os.ReadFile(path) // #nosec G304: path of the config file is a command line flag
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		if err := yaml.UnmarshalStrict(data, &config); err != nil {
+			return Config{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+	config.normalize()
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Validate ...
+func (c Config) Validate() error {
+	if c.OSType != "" && !supportedOSTypes[c.OSType] {
+		return fmt.Errorf("unsupported osType '%s', must be one of RHCOS, RHEL or Ubuntu", c.OSType)
+	}
+	if c.SidecarGroupID < 0 {
+		return fmt.Errorf("sidecarGroupID must not be negative, got %d", c.SidecarGroupID)
+	}
+	if err := validateSubnetIDs("vpcSubnetIDs", c.VPCSubnetIDs); err != nil {
+		return err
+	}
+	for zone, subnetIDs := range c.VPCZoneSubnetIDs {
+		if zone == "" || subnetIDs == "" {
+			return fmt.Errorf("vpcZoneSubnetIDs must map a zone to a subnet list, got '%s': '%s'", zone, subnetIDs)
+		}
+		if err := validateSubnetIDs("vpcZoneSubnetIDs["+zone+"]", subnetIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubnetIDsForZone returns the subnets of the zone, or VPCSubnetIDs when the zone has no own list
+func (c Config) SubnetIDsForZone(zone string) string {
+	if subnetIDs, ok := c.VPCZoneSubnetIDs[zone]; ok {
+		return subnetIDs
+	}
+	return c.VPCSubnetIDs
+}
+
+func validateSubnetIDs(field, subnetIDs string) error {
+	for _, subnetID := range strings.Split(subnetIDs, ",") {
+		if subnetIDs != "" && subnetID == "" {
+			return fmt.Errorf("%s '%s' contains an empty subnet ID", field, subnetIDs)
+		}
+	}
+	return nil
+}
+
+func (c *Config) applyEnv() error {
+	if value, ok := os.LookupEnv("VPC_ID"); ok {
+		c.VPCID = value
+	}
+	if value, ok := os.LookupEnv("VPC_SUBNET_IDS"); ok {
+		c.VPCSubnetIDs = value
+	}
+	if value, ok := os.LookupEnv("IS_SNAPSHOT_ENABLED"); ok {
+		// only an explicit false disables snapshots, as before the config file existed
+		c.SnapshotEnabled = strings.ToLower(value) != "false"
+	}
+	if value, ok := os.LookupEnv("OS_TYPE"); ok {
+		c.OSType = value
+	}
+	if value, ok := os.LookupEnv("CLUSTER_ENV"); ok && value != "" {
+		c.ClusterEnv = value
+	}
+	if value, ok := os.LookupEnv("KUBE_NODE_NAME"); ok {
+		c.NodeName = value
+	}
+	if value, ok := os.LookupEnv("SIDECAR_GROUP_ID"); ok && strings.TrimSpace(value) != "" {
+		groupID, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid SIDECAR_GROUP_ID '%s': %w", value, err)
+		}
+		c.SidecarGroupID = groupID
+	}
+	return nil
+}
+
+func (c *Config) normalize() {
+	c.VPCID = strings.TrimSpace(c.VPCID)
+	c.OSType = strings.TrimSpace(c.OSType)
+	c.ClusterEnv = strings.TrimSpace(c.ClusterEnv)
+	c.NodeName = strings.TrimSpace(c.NodeName)
+	c.VPCSubnetIDs = normalizeSubnetIDs(c.VPCSubnetIDs)
+	c.VPCZoneSubnetIDs = normalizeZoneSubnetIDs(c.VPCZoneSubnetIDs)
+	c.ClusterSecurityGroupID = strings.TrimSpace(c.ClusterSecurityGroupID)
+}
+
+// normalizeSubnetIDs trims the spaces around the IDs of a comma separated subnet list
+func normalizeSubnetIDs(subnetIDs string) string {
+	if strings.TrimSpace(subnetIDs) == "" {
+		return ""
+	}
+	ids := strings.Split(subnetIDs, ",")
+	for i := range ids {
+		ids[i] = strings.TrimSpace(ids[i])
+	}
+	return strings.Join(ids, ",")
+}
+
+// normalizeZoneSubnetIDs returns a trimmed copy of the zone subnet lists, nil when there are none
+func normalizeZoneSubnetIDs(zoneSubnetIDs map[string]string) map[string]string {
+	if len(zoneSubnetIDs) == 0 {
+		return nil
+	}
+	normalized := make(map[string]string, len(zoneSubnetIDs))
+	for zone, subnetIDs := range zoneSubnetIDs {
+		normalized[strings.TrimSpace(zone)] = normalizeSubnetIDs(subnetIDs)
+	}
+	return normalized
+}
@@ -0,0 +1,149 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package driverconfig ...
+package driverconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+// clearConfigEnv unsets the config environment variables for the test
+func clearConfigEnv(t *testing.T) {
+	for _, name := range []string{"VPC_ID", "VPC_SUBNET_IDS", "IS_SNAPSHOT_ENABLED", "OS_TYPE", "CLUSTER_ENV", "KUBE_NODE_NAME", "SIDECAR_GROUP_ID"} {
+		t.Setenv(name, "")
+		_ = os.Unsetenv(name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		testCaseName   string
+		env            map[string]string
+		file           string
+		expectedConfig Config
+		expectedErr    bool
+	}{
+		{
+			testCaseName:   "Defaults",
+			expectedConfig: Config{SnapshotEnabled: true, ClusterEnv: ClusterEnvProduction},
+		},
+		{
+			testCaseName: "Environment",
+			env: map[string]string{
+				"VPC_ID":              "vpc-1",
+				"VPC_SUBNET_IDS":      "subnet-1, subnet-2",
+				"IS_SNAPSHOT_ENABLED": "FALSE",
+				"OS_TYPE":             "RHCOS",
+				"CLUSTER_ENV":         "stage",
+				"KUBE_NODE_NAME":      "node-1",
+				"SIDECAR_GROUP_ID":    "2121",
+			},
+			expectedConfig: Config{VPCID: "vpc-1", VPCSubnetIDs: "subnet-1,subnet-2", OSType: "RHCOS", ClusterEnv: ClusterEnvStaging, NodeName: "node-1", SidecarGroupID: 2121},
+		},
+		{
+			testCaseName:   "Empty CLUSTER_ENV keeps the default",
+			env:            map[string]string{"CLUSTER_ENV": "", "IS_SNAPSHOT_ENABLED": "yes"},
+			expectedConfig: Config{SnapshotEnabled: true, ClusterEnv: ClusterEnvProduction},
+		},
+		{
+			testCaseName:   "File overrides the environment",
+			env:            map[string]string{"VPC_ID": "vpc-1", "VPC_SUBNET_IDS": "subnet-1"},
+			file:           "vpcSubnetIDs: subnet-3,subnet-4\nsnapshotEnabled: false\nosType: Ubuntu\n",
+			expectedConfig: Config{VPCID: "vpc-1", VPCSubnetIDs: "subnet-3,subnet-4", OSType: "Ubuntu", ClusterEnv: ClusterEnvProduction},
+		},
+		{
+			testCaseName:   "Zone subnets",
+			file:           "vpcZoneSubnetIDs:\n  us-south-1: subnet-1, subnet-2\n",
+			expectedConfig: Config{VPCZoneSubnetIDs: map[string]string{"us-south-1": "subnet-1,subnet-2"}, SnapshotEnabled: true, ClusterEnv: ClusterEnvProduction},
+		},
+		{
+			testCaseName: "Zone without subnets",
+			file:         "vpcZoneSubnetIDs:\n  us-south-1: \"\"\n",
+			expectedErr:  true,
+		},
+		{
+			testCaseName: "Unknown field in file",
+			file:         "vpcSubnetId: subnet-3\n",
+			expectedErr:  true,
+		},
+		{
+			testCaseName: "Unsupported OS type",
+			env:          map[string]string{"OS_TYPE": "Windows"},
+			expectedErr:  true,
+		},
+		{
+			testCaseName: "Invalid sidecar group ID",
+			env:          map[string]string{"SIDECAR_GROUP_ID": "sidecars"},
+			expectedErr:  true,
+		},
+		{
+			testCaseName: "Negative sidecar group ID in file",
+			file:         "sidecarGroupID: -1\n",
+			expectedErr:  true,
+		},
+		{
+			testCaseName: "Empty subnet ID",
+			file:         "vpcSubnetIDs: subnet-1,,subnet-2\n",
+			expectedErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			clearConfigEnv(t)
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			path := ""
+			if tc.file != "" {
+				path = writeConfigFile(t, tc.file)
+			}
+
+			config, err := Load(path)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedConfig, config)
+		})
+	}
+}
+
+func TestSubnetIDsForZone(t *testing.T) {
+	config := Config{VPCSubnetIDs: "subnet-1,subnet-2", VPCZoneSubnetIDs: map[string]string{"us-south-1": "subnet-3"}}
+	assert.Equal(t, "subnet-3", config.SubnetIDsForZone("us-south-1"))
+	assert.Equal(t, "subnet-1,subnet-2", config.SubnetIDsForZone("us-south-2"))
+	assert.Equal(t, "subnet-1,subnet-2", config.SubnetIDsForZone(""))
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package driverconfig ...
+package driverconfig
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultReloadInterval how often the config file is checked for changes
+const DefaultReloadInterval = 30 * time.Second
+
+// Subscriber is called after the config changed, with the old and new config. Subscribers are called one at a time
+type Subscriber func(old, new Config)
+
+// Store serves the current config to concurrent readers and swaps it atomically on reload
+type Store struct {
+	path   string
+	logger *zap.Logger
+
+	current atomic.Pointer[Config]
+
+	// mu serializes updates so subscribers see the changes in order
+	mu          sync.Mutex
+	loaded      Config
+	modTime     time.Time
+	subscribers []Subscriber
+}
+
+// NewStore loads the config from the environment and the YAML file at path, path may be empty
+func NewStore(path string, logger *zap.Logger) (*Store, error) {
+	config, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: path, logger: logger, loaded: config}
+	if path != "" {
+		if info, err := os.Stat(path); err == nil {
+			s.modTime = info.ModTime()
+		}
+	}
+	s.current.Store(&config)
+	logger.Info("Loaded driver config", zap.String("path", path), zap.Reflect("config", config))
+	return s, nil
+}
+
+// NewStaticStore serves config without a file, for tests and tools
+func NewStaticStore(config Config, logger *zap.Logger) *Store {
+	s := &Store{logger: logger, loaded: config}
+	s.current.Store(&config)
+	return s
+}
+
+// Get returns the current config, callers get a copy and never see a partial update
+func (s *Store) Get() Config {
+	return *s.current.Load()
+}
+
+// Subscribe registers fn for the changes after this call
+func (s *Store) Subscribe(fn Subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// SetVPCSubnetIDs updates the subnet list, e.g. after the cluster subnets changed
+func (s *Store) SetVPCSubnetIDs(subnetIDs string) error {
+	return s.update(func(config *Config) {
+		config.VPCSubnetIDs = normalizeSubnetIDs(subnetIDs)
+	})
+}
+
+// ClusterSettings settings the cluster publishes in the ibm-cloud-provider-data ConfigMap, empty fields are left unchanged
+type ClusterSettings struct {
+	VPCID                  string
+	VPCSubnetIDs           string
+	VPCZoneSubnetIDs       map[string]string
+	ClusterSecurityGroupID string
+}
+
+// ApplyClusterSettings updates the config with the non empty cluster settings in one change
+func (s *Store) ApplyClusterSettings(settings ClusterSettings) error {
+	return s.update(func(config *Config) {
+		if vpcID := strings.TrimSpace(settings.VPCID); vpcID != "" {
+			config.VPCID = vpcID
+		}
+		if subnetIDs := normalizeSubnetIDs(settings.VPCSubnetIDs); subnetIDs != "" {
+			config.VPCSubnetIDs = subnetIDs
+		}
+		if zoneSubnetIDs := normalizeZoneSubnetIDs(settings.VPCZoneSubnetIDs); zoneSubnetIDs != nil {
+			config.VPCZoneSubnetIDs = zoneSubnetIDs
+		}
+		if securityGroupID := strings.TrimSpace(settings.ClusterSecurityGroupID); securityGroupID != "" {
+			config.ClusterSecurityGroupID = securityGroupID
+		}
+	})
+}
+
+// Reload re-reads the environment and the config file. Only the reloadable fields the file
+// changed are applied; changes of the other fields are logged and take effect after a restart.
+// A rejected reload keeps the previous file contents as the base of the next one
+func (s *Store) Reload() error {
+	loaded, err := Load(s.path)
+	if err != nil {
+		s.logger.Error("Failed to reload driver config, keeping the current config", zap.String("path", s.path), zap.Error(err))
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.loaded
+	if loaded.OSType != previous.OSType || loaded.NodeName != previous.NodeName || loaded.SidecarGroupID != previous.SidecarGroupID {
+		s.logger.Warn("osType, nodeName and sidecarGroupID changes are only applied after a restart", zap.String("path", s.path))
+	}
+	err = s.updateLocked(func(config *Config) {
+		if loaded.VPCID != previous.VPCID {
+			config.VPCID = loaded.VPCID
+		}
+		if loaded.VPCSubnetIDs != previous.VPCSubnetIDs {
+			config.VPCSubnetIDs = loaded.VPCSubnetIDs
+		}
+		if !reflect.DeepEqual(loaded.VPCZoneSubnetIDs, previous.VPCZoneSubnetIDs) {
+			config.VPCZoneSubnetIDs = loaded.VPCZoneSubnetIDs
+		}
+		if loaded.ClusterSecurityGroupID != previous.ClusterSecurityGroupID {
+			config.ClusterSecurityGroupID = loaded.ClusterSecurityGroupID
+		}
+		if loaded.SnapshotEnabled != previous.SnapshotEnabled {
+			config.SnapshotEnabled = loaded.SnapshotEnabled
+		}
+		if loaded.ClusterEnv != previous.ClusterEnv {
+			config.ClusterEnv = loaded.ClusterEnv
+		}
+	})
+	if err != nil {
+		return err
+	}
+	s.loaded = loaded
+	return nil
+}
+
+// WatchFile reloads the config whenever the modification time of the file changes, until stop is closed.
+// Stat follows symlinks, so the atomic update of a mounted ConfigMap is detected
+func (s *Store) WatchFile(interval time.Duration, stop <-chan struct{}) {
+	if s.path == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(s.path)
+			if err != nil {
+				s.logger.Warn("Failed to check driver config file for changes", zap.String("path", s.path), zap.Error(err))
+				continue
+			}
+			s.mu.Lock()
+			changed := info.ModTime() != s.modTime
+			s.modTime = info.ModTime()
+			s.mu.Unlock()
+			if changed {
+				_ = s.Reload() // #nosec G104: Reload logs the error and keeps the current config
+			}
+		}
+	}
+}
+
+// update applies mutate to a copy of the current config, swaps it in and notifies the subscribers.
+// The copy shares the maps of the current config, mutate must replace them instead of writing to them
+func (s *Store) update(mutate func(config *Config)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateLocked(mutate)
+}
+
+// updateLocked is update for callers holding s.mu
+func (s *Store) updateLocked(mutate func(config *Config)) error {
+	old := s.Get()
+	updated := old
+	mutate(&updated)
+	if err := updated.Validate(); err != nil {
+		s.logger.Error("Rejected driver config update", zap.Error(err))
+		return err
+	}
+	if reflect.DeepEqual(updated, old) {
+		return nil
+	}
+	s.current.Store(&updated)
+	s.logger.Info("Driver config updated", zap.Reflect("old", old), zap.Reflect("new", updated))
+	for _, subscriber := range s.subscribers {
+		subscriber(old, updated)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package driverconfig ...
+package driverconfig
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestStoreReload(t *testing.T) {
+	testCases := []struct {
+		testCaseName    string
+		initialFile     string
+		updatedFile     string
+		subnetUpdate    string
+		expectedConfig  Config
+		expectedChanges int
+		expectedErr     bool
+	}{
+		{
+			testCaseName:    "Reloadable fields are applied",
+			initialFile:     "vpcID: vpc-1\nvpcSubnetIDs: subnet-1\n",
+			updatedFile:     "vpcID: vpc-2\nvpcSubnetIDs: subnet-2\nvpcZoneSubnetIDs:\n  us-south-1: subnet-3\nclusterSecurityGroupID: sg-1\nsnapshotEnabled: false\nclusterEnv: stage\n",
+			expectedConfig:  Config{VPCID: "vpc-2", VPCSubnetIDs: "subnet-2", VPCZoneSubnetIDs: map[string]string{"us-south-1": "subnet-3"}, ClusterSecurityGroupID: "sg-1", ClusterEnv: ClusterEnvStaging},
+			expectedChanges: 1,
+		},
+		{
+			testCaseName:   "Other fields need a restart",
+			initialFile:    "vpcID: vpc-1\nosType: RHEL\n",
+			updatedFile:    "vpcID: vpc-1\nosType: Ubuntu\n",
+			expectedConfig: Config{VPCID: "vpc-1", OSType: "RHEL", SnapshotEnabled: true, ClusterEnv: ClusterEnvProduction},
+		},
+		{
+			testCaseName:    "Subnet update survives a reload without subnet changes",
+			initialFile:     "vpcSubnetIDs: subnet-1\n",
+			updatedFile:     "vpcSubnetIDs: subnet-1\nclusterEnv: stage\n",
+			subnetUpdate:    "subnet-3",
+			expectedConfig:  Config{VPCSubnetIDs: "subnet-3", SnapshotEnabled: true, ClusterEnv: ClusterEnvStaging},
+			expectedChanges: 2,
+		},
+		{
+			testCaseName:   "Invalid file keeps the current config",
+			initialFile:    "vpcSubnetIDs: subnet-1\n",
+			updatedFile:    "vpcSubnetIDs: [subnet-2]\n",
+			expectedConfig: Config{VPCSubnetIDs: "subnet-1", SnapshotEnabled: true, ClusterEnv: ClusterEnvProduction},
+			expectedErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			clearConfigEnv(t)
+			path := writeConfigFile(t, tc.initialFile)
+			store, err := NewStore(path, zap.NewNop())
+			assert.NoError(t, err)
+
+			changes := 0
+			store.Subscribe(func(old, new Config) {
+				assert.NotEqual(t, old, new)
+				changes++
+			})
+			if tc.subnetUpdate != "" {
+				assert.NoError(t, store.SetVPCSubnetIDs(tc.subnetUpdate))
+			}
+
+			assert.NoError(t, os.WriteFile(path, []byte(tc.updatedFile), 0600))
+			err = store.Reload()
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedConfig, store.Get())
+			assert.Equal(t, tc.expectedChanges, changes)
+		})
+	}
+}
+
+func TestStoreSetVPCSubnetIDs(t *testing.T) {
+	store := NewStaticStore(Config{VPCSubnetIDs: "subnet-1"}, zap.NewNop())
+	var notified []Config
+	store.Subscribe(func(old, new Config) {
+		notified = append(notified, new)
+	})
+
+	assert.NoError(t, store.SetVPCSubnetIDs("subnet-2 , subnet-3"))
+	assert.Equal(t, "subnet-2,subnet-3", store.Get().VPCSubnetIDs)
+	assert.NoError(t, store.SetVPCSubnetIDs("subnet-2,subnet-3"))
+	assert.Error(t, store.SetVPCSubnetIDs("subnet-4,,subnet-5"))
+	assert.Equal(t, "subnet-2,subnet-3", store.Get().VPCSubnetIDs)
+	assert.Len(t, notified, 1)
+}
+
+func TestStoreApplyClusterSettings(t *testing.T) {
+	store := NewStaticStore(Config{VPCID: "vpc-1", VPCSubnetIDs: "subnet-1", ClusterSecurityGroupID: "sg-1"}, zap.NewNop())
+
+	assert.NoError(t, store.ApplyClusterSettings(ClusterSettings{
+		VPCSubnetIDs:     "subnet-2, subnet-3",
+		VPCZoneSubnetIDs: map[string]string{" us-south-1 ": "subnet-2 "},
+	}))
+	assert.Equal(t, Config{
+		VPCID:                  "vpc-1",
+		VPCSubnetIDs:           "subnet-2,subnet-3",
+		VPCZoneSubnetIDs:       map[string]string{"us-south-1": "subnet-2"},
+		ClusterSecurityGroupID: "sg-1",
+	}, store.Get())
+
+	assert.Error(t, store.ApplyClusterSettings(ClusterSettings{VPCID: "vpc-2", VPCZoneSubnetIDs: map[string]string{"us-south-2": ""}}))
+	assert.Equal(t, "vpc-1", store.Get().VPCID)
+}
+
+func TestStoreWatchFile(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeConfigFile(t, "vpcSubnetIDs: subnet-1\n")
+	store, err := NewStore(path, zap.NewNop())
+	assert.NoError(t, err)
+
+	changed := make(chan Config, 1)
+	store.Subscribe(func(_, new Config) {
+		changed <- new
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go store.WatchFile(10*time.Millisecond, stop)
+
+	assert.NoError(t, os.WriteFile(path, []byte("vpcSubnetIDs: subnet-2\n"), 0600))
+	// make sure the mtime differs on file systems with a coarse timestamp resolution
+	assert.NoError(t, os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second)))
+	select {
+	case config := <-changed:
+		assert.Equal(t, "subnet-2", config.VPCSubnetIDs)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config file change was not reloaded")
+	}
+}
@@ -0,0 +1,268 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/ibmcloud-volume-interface/config"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+)
+
+const (
+	// accessorShareAPIVersion is the oldest VPC API version that creates accessor shares, a newer configured version is kept
+	accessorShareAPIVersion = "2024-06-11"
+
+	// accessorShareStable lifecycle state of an accessor share that file share targets can be created for
+	accessorShareStable = "stable"
+
+	// accessorShareFailed lifecycle state of an accessor share the VPC could not bind
+	accessorShareFailed = "failed"
+)
+
+// AccessorShareClient creates accessor shares through the VPC file share API, which the provider library
+// does not cover. An accessor share is bound to an origin share, possibly owned by another account, and
+// shares its data, profile and capacity. It is a regular share of this account otherwise: the file share
+// target is created and both are deleted through the provider session, and deleting the accessor share
+// only removes the binding, the origin share is left intact.
+type AccessorShareClient struct {
+	endpoint     string
+	apiVersion   string
+	client       *http.Client
+	pollInterval time.Duration
+	timeout      time.Duration
+}
+
+// NewAccessorShareClient sends the requests to the VPC endpoint of conf, the private one when it is set
+func NewAccessorShareClient(conf *config.Config) *AccessorShareClient {
+	shares := &AccessorShareClient{
+		apiVersion:   accessorShareAPIVersion,
+		client:       &http.Client{Timeout: time.Minute},
+		pollInterval: 5 * time.Second,
+		timeout:      5 * time.Minute,
+	}
+	if conf == nil || conf.VPC == nil {
+		return shares
+	}
+	shares.endpoint = conf.VPC.G2EndpointURL
+	if conf.VPC.G2EndpointPrivateURL != "" {
+		shares.endpoint = conf.VPC.G2EndpointPrivateURL
+	}
+	// API versions are dates, so the newer one sorts last
+	if conf.VPC.G2APIVersion > shares.apiVersion {
+		shares.apiVersion = conf.VPC.G2APIVersion
+	}
+	return shares
+}
+
+// accessorSharePrototype request body of an accessor share, profile, size, iops and encryption come from the origin share
+type accessorSharePrototype struct {
+	Name          string                  `json:"name"`
+	OriginShare   vpcShareReference       `json:"origin_share"`
+	ResourceGroup *provider.ResourceGroup `json:"resource_group,omitempty"`
+	UserTags      []string                `json:"user_tags,omitempty"`
+}
+
+type vpcShareReference struct {
+	CRN string `json:"crn"`
+}
+
+type vpcNameReference struct {
+	Name string `json:"name"`
+}
+
+// vpcShare the fields of a VPC file share the driver uses
+type vpcShare struct {
+	ID             string                  `json:"id"`
+	CRN            string                  `json:"crn"`
+	Href           string                  `json:"href"`
+	Name           string                  `json:"name"`
+	Size           int                     `json:"size"`
+	Iops           int                     `json:"iops"`
+	LifecycleState string                  `json:"lifecycle_state"`
+	Profile        vpcNameReference        `json:"profile"`
+	Zone           vpcNameReference        `json:"zone"`
+	ResourceGroup  *provider.ResourceGroup `json:"resource_group"`
+	MountTargets   []struct {
+		ID string `json:"id"`
+	} `json:"mount_targets"`
+}
+
+// vpcErrorResponse error body of the VPC API
+type vpcErrorResponse struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Trace string `json:"trace"`
+}
+
+// Create creates an accessor share of originShareCRN with the IAM token of session and waits until it is stable
+func (c *AccessorShareClient) Create(ctx context.Context, session provider.Session, volumeRequest provider.Volume, originShareCRN string) (*provider.Volume, error) {
+	if c == nil || c.endpoint == "" {
+		return nil, errors.New("the VPC endpoint is not configured, g2_riaas_endpoint_url is required for accessor shares")
+	}
+	credentials, ok := sessionCredentials(session)
+	if !ok || credentials.AuthType != provider.IAMAccessToken || credentials.Credential == "" {
+		return nil, errors.New("the provider session carries no IAM access token")
+	}
+
+	prototype := accessorSharePrototype{
+		OriginShare:   vpcShareReference{CRN: originShareCRN},
+		ResourceGroup: volumeRequest.ResourceGroup,
+		UserTags:      volumeRequest.Tags,
+	}
+	if volumeRequest.Name != nil {
+		prototype.Name = *volumeRequest.Name
+	}
+	share := &vpcShare{}
+	if err := c.do(ctx, credentials.Credential, http.MethodPost, "/v1/shares", prototype, share); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	for share.LifecycleState != accessorShareStable {
+		if share.LifecycleState == accessorShareFailed {
+			return nil, fmt.Errorf("accessor share %s of origin share %s failed", share.ID, originShareCRN)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("accessor share %s is %s, timed out waiting for it to be %s", share.ID, share.LifecycleState, accessorShareStable)
+		case <-time.After(c.pollInterval):
+		}
+		if err := c.do(ctx, credentials.Credential, http.MethodGet, "/v1/shares/"+url.PathEscape(share.ID), nil, share); err != nil {
+			return nil, err
+		}
+	}
+	return share.volume(volumeRequest), nil
+}
+
+// do sends a VPC API request and decodes the response into out
+func (c *AccessorShareClient) do(ctx context.Context, token, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+	query := url.Values{"version": {c.apiVersion}, "generation": {"2"}}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.endpoint, "/")+path+"?"+query.Encode(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if requestID, ok := ctx.Value(provider.RequestID).(string); ok && requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		vpcErr := vpcErrorResponse{}
+		_ = json.NewDecoder(resp.Body).Decode(&vpcErr)
+		messages := make([]string, 0, len(vpcErr.Errors))
+		for _, e := range vpcErr.Errors {
+			messages = append(messages, e.Code+": "+e.Message)
+		}
+		return fmt.Errorf("%s %s failed with %d: %s (trace %s)", method, path, resp.StatusCode, strings.Join(messages, "; "), vpcErr.Trace)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// volume converts the share to the provider volume CreateVolume returns
+func (s *vpcShare) volume(volumeRequest provider.Volume) *provider.Volume {
+	name, size := s.Name, s.Size
+	volume := &provider.Volume{
+		VolumeID:   s.ID,
+		Provider:   volumeRequest.Provider,
+		VolumeType: volumeRequest.VolumeType,
+		Name:       &name,
+		Capacity:   &size,
+		Az:         s.Zone.Name,
+	}
+	if s.Iops > 0 {
+		iops := strconv.Itoa(s.Iops)
+		volume.Iops = &iops
+	}
+	volume.CRN = s.CRN
+	volume.Href = s.Href
+	volume.ResourceGroup = s.ResourceGroup
+	volume.Tags = volumeRequest.Tags
+	if s.Profile.Name != "" {
+		volume.Profile = &provider.Profile{Name: s.Profile.Name}
+	}
+	if len(s.MountTargets) != 0 {
+		accessPoints := make([]provider.VolumeAccessPoint, 0, len(s.MountTargets))
+		for _, target := range s.MountTargets {
+			accessPoints = append(accessPoints, provider.VolumeAccessPoint{ID: target.ID})
+		}
+		volume.VolumeAccessPoints = &accessPoints
+	}
+	return volume
+}
+
+// CreateAccessorVolume creates an accessor share of originShareCRN with the credentials of the wrapped session
+func (s *instrumentedSession) CreateAccessorVolume(shares *AccessorShareClient, volumeRequest provider.Volume, originShareCRN string) (*provider.Volume, error) {
+	done := s.begin("CreateAccessorVolume")
+	volume, err := shares.Create(s.ctx, s.Session, volumeRequest, originShareCRN)
+	done(err)
+	return volume, err
+}
+
+// createAccessorVolume creates an accessor share of originShareCRN through shares
+func createAccessorVolume(ctx context.Context, session provider.Session, shares *AccessorShareClient, volumeRequest provider.Volume, originShareCRN string) (*provider.Volume, error) {
+	if instrumented, ok := session.(*instrumentedSession); ok {
+		return instrumented.CreateAccessorVolume(shares, volumeRequest, originShareCRN)
+	}
+	return shares.Create(ctx, session, volumeRequest, originShareCRN)
+}
+
+// validateOriginShareCRN checks that value is the CRN of a VPC file share
+// expected CRN -> crn:v1:bluemix:public:is:us-south-1:a/77f2bceddaeb577dcaddb4073fe82c1c::share:r006-2ea54e55-4f34-4cad-aacc-88d712a19330
+func validateOriginShareCRN(value string) error {
+	crnTokens := strings.Split(value, ":")
+	if len(crnTokens) != 10 || crnTokens[0] != "crn" || crnTokens[4] != "is" || crnTokens[8] != "share" || crnTokens[9] == "" {
+		return fmt.Errorf("%s:<%v> is not the CRN of a file share", OriginShareCRN, value)
+	}
+	if getAccountID(crnTokens[6]) == "" {
+		return fmt.Errorf("%s:<%v> does not contain the account of the origin share", OriginShareCRN, value)
+	}
+	return nil
+}
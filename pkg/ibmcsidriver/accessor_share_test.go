@@ -0,0 +1,287 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-csi-common/pkg/utils"
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/driverconfig"
+	"github.com/IBM/ibmcloud-volume-interface/config"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider/fake"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testOriginShareCRN = "crn:v1:bluemix:public:is:us-south-1:a/77f2bceddaeb577dcaddb4073fe82c1c::share:r006-2ea54e55-4f34-4cad-aacc-88d712a19330"
+
+// fakeVPCShares serves the VPC share API, the share turns stable after pending polls
+type fakeVPCShares struct {
+	mu       sync.Mutex
+	pending  int
+	status   int
+	requests []accessorSharePrototype
+	tokens   []string
+}
+
+func (f *fakeVPCShares) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens = append(f.tokens, r.Header.Get("Authorization"))
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		_, _ = w.Write([]byte(`{"errors":[{"code":"shares_origin_share_not_found","message":"origin share not found"}],"trace":"trace-1"}`))
+		return
+	}
+	if r.Method == http.MethodPost {
+		prototype := accessorSharePrototype{}
+		_ = json.NewDecoder(r.Body).Decode(&prototype)
+		f.requests = append(f.requests, prototype)
+	}
+	state := accessorShareStable
+	if f.pending > 0 {
+		f.pending--
+		state = "pending"
+	}
+	_, _ = w.Write([]byte(`{"id":"testAccessorId","crn":"crn:v1:bluemix:public:is:us-south-1:a/abc::share:testAccessorId","name":"test-name","size":500,"iops":3000,"lifecycle_state":"` + state + `","profile":{"name":"dp2"},"zone":{"name":"myzone"}}`))
+}
+
+// testAccessorShares returns a client for the fake VPC API of server
+func testAccessorShares(server *httptest.Server) *AccessorShareClient {
+	shares := NewAccessorShareClient(&config.Config{VPC: &config.VPCProviderConfig{G2EndpointURL: server.URL}})
+	shares.pollInterval = time.Millisecond
+	return shares
+}
+
+func TestNewAccessorShareClient(t *testing.T) {
+	shares := NewAccessorShareClient(&config.Config{VPC: &config.VPCProviderConfig{G2EndpointURL: "https://public", G2EndpointPrivateURL: "https://private", G2APIVersion: "2023-01-01"}})
+	assert.Equal(t, "https://private", shares.endpoint)
+	assert.Equal(t, accessorShareAPIVersion, shares.apiVersion)
+
+	shares = NewAccessorShareClient(&config.Config{VPC: &config.VPCProviderConfig{G2EndpointURL: "https://public", G2APIVersion: "2099-01-01"}})
+	assert.Equal(t, "https://public", shares.endpoint)
+	assert.Equal(t, "2099-01-01", shares.apiVersion)
+}
+
+func TestAccessorShareClientCreate(t *testing.T) {
+	name := "test-name"
+	request := provider.Volume{Name: &name}
+	request.ResourceGroup = &provider.ResourceGroup{ID: "rg-1"}
+	request.Tags = []string{"a", "b"}
+	tokenCredentials := provider.ContextCredentials{AuthType: provider.IAMAccessToken, Credential: "token-1"}
+
+	testCases := []struct {
+		name        string
+		credentials provider.ContextCredentials
+		pending     int
+		status      int
+		expectedErr string
+	}{
+		{name: "Stable at once", credentials: tokenCredentials},
+		{name: "Stable after polling", credentials: tokenCredentials, pending: 2},
+		{name: "Origin share not found", credentials: tokenCredentials, status: http.StatusNotFound, expectedErr: "shares_origin_share_not_found"},
+		{name: "No IAM token", credentials: provider.ContextCredentials{AuthType: provider.IAMAPIKey, Credential: "api-key"}, expectedErr: "IAM access token"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vpc := &fakeVPCShares{pending: tc.pending, status: tc.status}
+			server := httptest.NewServer(vpc)
+			defer server.Close()
+
+			session := &tokenSession{FakeSession: &fake.FakeSession{}, ContextCredentials: tc.credentials}
+			volume, err := testAccessorShares(server).Create(context.Background(), &instrumentedSession{Session: session, ctx: context.Background()}, request, testOriginShareCRN)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []accessorSharePrototype{{
+				Name:          name,
+				OriginShare:   vpcShareReference{CRN: testOriginShareCRN},
+				ResourceGroup: &provider.ResourceGroup{ID: "rg-1"},
+				UserTags:      []string{"a", "b"},
+			}}, vpc.requests)
+			assert.Len(t, vpc.tokens, 1+tc.pending)
+			assert.Equal(t, "Bearer token-1", vpc.tokens[0])
+			assert.Equal(t, "testAccessorId", volume.VolumeID)
+			assert.Equal(t, 500, *volume.Capacity)
+			assert.Equal(t, "3000", *volume.Iops)
+			assert.Equal(t, "dp2", volume.Profile.Name)
+			assert.Equal(t, "myzone", volume.Az)
+			assert.Nil(t, volume.VolumeAccessPoints)
+		})
+	}
+}
+
+func TestValidateOriginShareCRN(t *testing.T) {
+	testCases := []struct {
+		name        string
+		crn         string
+		expectedErr bool
+	}{
+		{name: "File share", crn: testOriginShareCRN},
+		{name: "Snapshot", crn: "crn:v1:bluemix:public:is:us-south-1:a/77f2bceddaeb577dcaddb4073fe82c1c::share-snapshot:r006-2ea54e55/r006-2c65c897", expectedErr: true},
+		{name: "Share ID", crn: "r006-2ea54e55-4f34-4cad-aacc-88d712a19330", expectedErr: true},
+		{name: "Missing account", crn: "crn:v1:bluemix:public:is:us-south-1:::share:r006-2ea54e55-4f34-4cad-aacc-88d712a19330", expectedErr: true},
+		{name: "Missing share ID", crn: "crn:v1:bluemix:public:is:us-south-1:a/77f2bceddaeb577dcaddb4073fe82c1c::share:", expectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateOriginShareCRN(tc.crn)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetVolumeParametersOriginShare(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+
+	testConfig := &config.Config{VPC: &config.VPCProviderConfig{ResourceGroupID: "10000000"}}
+	params := func(extra map[string]string) map[string]string {
+		p := map[string]string{Profile: DP2Profile, Zone: "myzone", Region: "myregion", IsENIEnabled: TrueStr, OriginShareCRN: testOriginShareCRN}
+		for k, v := range extra {
+			p[k] = v
+		}
+		return p
+	}
+	testCases := []struct {
+		name        string
+		params      map[string]string
+		source      *csi.VolumeContentSource
+		expectedErr bool
+	}{
+		{name: "Accessor share", params: params(nil)},
+		{name: "Empty origin share", params: params(map[string]string{OriginShareCRN: " "})},
+		{name: "Invalid origin share", params: params(map[string]string{OriginShareCRN: "share-1"}), expectedErr: true},
+		{name: "VPC mode", params: params(map[string]string{IsENIEnabled: FalseStr}), expectedErr: true},
+		{name: "Encryption key", params: params(map[string]string{EncryptionKey: "key"}), expectedErr: true},
+		{name: "IOPS", params: params(map[string]string{IOPS: "1000"}), expectedErr: true},
+		{name: "Owner", params: params(map[string]string{UID: "1000"}), expectedErr: true},
+		{
+			name:        "Snapshot source",
+			params:      params(nil),
+			source:      &csi.VolumeContentSource{Type: &csi.VolumeContentSource_Snapshot{Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: "snap-1"}}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := getVolumeParameters(logger, &csi.CreateVolumeRequest{
+				Name:                "test-name",
+				CapacityRange:       stdCapRange,
+				VolumeCapabilities:  stdVolCap,
+				Parameters:          tc.params,
+				VolumeContentSource: tc.source,
+			}, testConfig)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCreateVolumeAccessorShare(t *testing.T) {
+	volName := "test-name"
+	accessPoint := &provider.VolumeAccessPointResponse{
+		VolumeID:      "testAccessorId",
+		AccessPointID: "testVolumeAccessPointId",
+		Status:        "Stable",
+		MountPath:     "abc:/xyz/pqr",
+		CreatedAt:     &time.Time{},
+	}
+	params := map[string]string{OriginShareCRN: testOriginShareCRN}
+	for k, v := range stdENIParams {
+		params[k] = v
+	}
+
+	testCases := []struct {
+		name       string
+		status     int
+		expErrCode codes.Code
+	}{
+		{name: "Accessor share created"},
+		{name: "Accessor share failed", status: http.StatusForbidden, expErrCode: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vpc := &fakeVPCShares{status: tc.status}
+			server := httptest.NewServer(vpc)
+			defer server.Close()
+
+			icDriver := initIBMCSIDriver(t)
+			assert.NoError(t, icDriver.config.ApplyClusterSettings(driverconfig.ClusterSettings{VPCID: "vpc-cluster", VPCSubnetIDs: "subnet-cluster"}))
+			fakeSession := &fake.FakeSession{}
+			fakeSession.GetVolumeByNameReturns(nil, nil)
+			fakeSession.GetSubnetForVolumeAccessPointReturns("subnet-id", nil)
+			fakeSession.GetSecurityGroupForVolumeAccessPointReturns("sg-id", nil)
+			fakeSession.CreateVolumeAccessPointReturns(accessPoint, nil)
+			fakeSession.WaitForCreateVolumeAccessPointReturns(accessPoint, nil)
+			session := &tokenSession{FakeSession: fakeSession, ContextCredentials: provider.ContextCredentials{AuthType: provider.IAMAccessToken, Credential: "token-1"}}
+			icDriver.cs.CSIProvider = &fixedSessionProvider{CloudProviderInterface: icDriver.cs.CSIProvider, session: session}
+			icDriver.cs.Sessions = nil
+			icDriver.cs.AccessorShares = testAccessorShares(server)
+
+			resp, err := icDriver.cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               volName,
+				CapacityRange:      stdCapRange,
+				VolumeCapabilities: stdVolCap,
+				Parameters:         params,
+			})
+			assert.Equal(t, 0, fakeSession.CreateVolumeCallCount())
+			if tc.expErrCode != codes.OK {
+				assert.Equal(t, tc.expErrCode, status.Code(err))
+				assert.Contains(t, err.Error(), AccessorShareFailed)
+				assert.Equal(t, 0, fakeSession.CreateVolumeAccessPointCallCount())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, vpc.requests, 1)
+			// the file share target in the local VPC is created through the provider session
+			assert.Equal(t, 1, fakeSession.CreateVolumeAccessPointCallCount())
+			targetRequest := fakeSession.CreateVolumeAccessPointArgsForCall(0)
+			assert.Equal(t, "testAccessorId", targetRequest.VolumeID)
+			assert.Equal(t, "vpc-cluster", targetRequest.VPCID)
+			assert.Equal(t, "subnet-id", targetRequest.SubnetID)
+			assert.Equal(t, "testAccessorId"+VolumeIDSeperator+"testVolumeAccessPointId", resp.Volume.VolumeId)
+			assert.Equal(t, int64(500)*utils.GB, resp.Volume.CapacityBytes)
+			assert.Equal(t, testOriginShareCRN, resp.Volume.VolumeContext[OriginShareCRN])
+		})
+	}
+}
@@ -0,0 +1,219 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"github.com/IBM/ibmcloud-volume-interface/provider/local"
+	"go.uber.org/zap"
+)
+
+// accountTarget account and VPC a request is provisioned in, read from the provisioner, controller-expand
+// or snapshotter secret. The zero value targets the cluster account and VPC.
+type accountTarget struct {
+	AccountID string
+	APIKey    string `json:"-"`
+	VPCID     string
+	SubnetIDs string
+}
+
+// accountTargetFromSecrets returns the account and VPC target of the secret keys apiKey, accountID, vpcID and vpcSubnetIDs
+func accountTargetFromSecrets(secrets map[string]string) (accountTarget, error) {
+	target := accountTarget{
+		AccountID: strings.TrimSpace(secrets[AccountID]),
+		APIKey:    strings.TrimSpace(secrets[AccountAPIKey]),
+		VPCID:     strings.TrimSpace(secrets[TargetVPCID]),
+		SubnetIDs: strings.TrimSpace(secrets[TargetVPCSubnetIDs]),
+	}
+	if (target.APIKey == "") != (target.AccountID == "") {
+		return accountTarget{}, fmt.Errorf("secret keys '%s' and '%s' must be set together", AccountAPIKey, AccountID)
+	}
+	if target.SubnetIDs != "" && target.VPCID == "" {
+		return accountTarget{}, fmt.Errorf("secret key '%s' requires '%s'", TargetVPCSubnetIDs, TargetVPCID)
+	}
+	return target, nil
+}
+
+// requestAccountTarget returns the account target of the request secrets as a CSI error and records the account for auditing
+func requestAccountTarget(ctx context.Context, ctxLogger *zap.Logger, requestID string, secrets map[string]string) (accountTarget, error) {
+	target, err := accountTargetFromSecrets(secrets)
+	if err != nil {
+		return accountTarget{}, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, err)
+	}
+	auditRecordFromContext(ctx).setAccountID(target.AccountID)
+	return target, nil
+}
+
+// checkVolumeAccount fails the request of a volume when the request credentials are not the ones of the account the
+// volume was provisioned in, the share would be looked up in the wrong account otherwise. The account of the volume is
+// read from volumeContext, or from the PV of the share when it is nil. Volumes without a known PV are not checked.
+func (csiCS *CSIControllerServer) checkVolumeAccount(ctxLogger *zap.Logger, requestID, volumeID, shareID string, volumeContext map[string]string, target accountTarget) error {
+	if len(volumeContext) == 0 {
+		var ok bool
+		if volumeContext, ok = csiCS.Driver.events.VolumeContext(shareID); !ok {
+			return nil
+		}
+	}
+	if volumeAccount := volumeContext[AccountIDLabel]; volumeAccount != target.AccountID {
+		return commonError.GetCSIError(ctxLogger, VolumeAccountMismatch, requestID, nil, volumeID, accountName(volumeAccount), accountName(target.AccountID))
+	}
+	return nil
+}
+
+// accountName names the account of accountID in messages, the cluster account when it is empty
+func accountName(accountID string) string {
+	if accountID == "" {
+		return "the cluster account"
+	}
+	return fmt.Sprintf("account '%s'", accountID)
+}
+
+// crossAccount reports whether the request uses the credentials of another account
+func (t accountTarget) crossAccount() bool {
+	return t.APIKey != ""
+}
+
+// cacheKey identifies the credentials without keeping the API key in the session map
+func (t accountTarget) cacheKey() string {
+	sum := sha256.Sum256([]byte(t.AccountID + "\x00" + t.APIKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// AccountSessionOpener opens a VPC provider session with the IAM API key of another account
+type AccountSessionOpener interface {
+	OpenAccountSession(ctx context.Context, accountID, apiKey string, logger *zap.Logger) (provider.Session, error)
+}
+
+// NewAccountSessionOpener opens the sessions through the provider the cluster credentials use
+func NewAccountSessionOpener(prov local.Provider) AccountSessionOpener {
+	return &localAccountSessionOpener{provider: prov}
+}
+
+type localAccountSessionOpener struct {
+	provider local.Provider
+}
+
+// OpenAccountSession ...
+func (o *localAccountSessionOpener) OpenAccountSession(ctx context.Context, accountID, apiKey string, logger *zap.Logger) (provider.Session, error) {
+	credentialsFactory, err := o.provider.ContextCredentialsFactory(nil)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := credentialsFactory.ForIAMAPIKey(accountID, apiKey, logger)
+	if err != nil {
+		return nil, err
+	}
+	return o.provider.OpenSession(ctx, credentials, logger)
+}
+
+// accountProvider opens the sessions of one account, config and cluster ID are the ones of the cluster provider
+type accountProvider struct {
+	cloudProvider.CloudProviderInterface
+	opener AccountSessionOpener
+	target accountTarget
+}
+
+// GetProviderSession ...
+func (p *accountProvider) GetProviderSession(ctx context.Context, logger *zap.Logger) (provider.Session, error) {
+	return p.opener.OpenAccountSession(ctx, p.target.AccountID, p.target.APIKey, logger)
+}
+
+// AccountSessions caches one provider session per account used by cross-account
+// requests, separately from the session of the cluster credentials, so that a
+// tenant account never gets the session of another account. Sessions of
+// credentials that were not used for a TTL are dropped, e.g. after a rotation.
+type AccountSessions struct {
+	mu       sync.Mutex
+	base     cloudProvider.CloudProviderInterface
+	opener   AccountSessionOpener
+	ttl      time.Duration
+	accounts map[string]*accountSession
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+type accountSession struct {
+	provider *accountProvider
+	cache    *SessionCache
+	lastUsed time.Time
+}
+
+// NewAccountSessions opener may be nil, cross-account requests then fail
+func NewAccountSessions(base cloudProvider.CloudProviderInterface, opener AccountSessionOpener, ttl time.Duration, logger *zap.Logger) *AccountSessions {
+	return &AccountSessions{
+		base:     base,
+		opener:   opener,
+		ttl:      ttl,
+		accounts: map[string]*accountSession{},
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Get returns the session of the target account and the cache it came from, the cache is nil when sessions are not cached
+func (as *AccountSessions) Get(ctx context.Context, ctxLogger *zap.Logger, target accountTarget) (provider.Session, *SessionCache, error) {
+	if as == nil || as.opener == nil {
+		return nil, nil, errors.New("cross-account provisioning is not supported by the provider")
+	}
+	account := as.account(target)
+	ctxLogger.Info("Using provider session of account", zap.String("accountID", target.AccountID))
+	if account.cache == nil {
+		session, err := account.provider.GetProviderSession(ctx, ctxLogger)
+		return session, nil, err
+	}
+	session, err := account.cache.Get(ctx, ctxLogger)
+	return session, account.cache, err
+}
+
+// account returns the sessions of the target account, dropping the accounts that were not used for a TTL
+func (as *AccountSessions) account(target accountTarget) *accountSession {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	now := as.now()
+	for key, account := range as.accounts {
+		if now.Sub(account.lastUsed) > as.ttl {
+			delete(as.accounts, key)
+		}
+	}
+	key := target.cacheKey()
+	account, ok := as.accounts[key]
+	if !ok {
+		prov := &accountProvider{CloudProviderInterface: as.base, opener: as.opener, target: target}
+		account = &accountSession{provider: prov}
+		if as.ttl > 0 {
+			account.cache = &SessionCache{provider: prov, ttl: as.ttl, logger: as.logger, now: as.now}
+			as.accounts[key] = account
+		}
+	}
+	account.lastUsed = now
+	return account
+}
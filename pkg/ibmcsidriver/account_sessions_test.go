@@ -0,0 +1,512 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/driverconfig"
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider/fake"
+	providerError "github.com/IBM/ibmcloud-volume-interface/lib/utils"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// fixedSessionProvider returns the same session on every call
+type fixedSessionProvider struct {
+	cloudProvider.CloudProviderInterface
+	session provider.Session
+}
+
+func (fp *fixedSessionProvider) GetProviderSession(_ context.Context, _ *zap.Logger) (provider.Session, error) {
+	return fp.session, nil
+}
+
+// fakeAccountOpener opens the given session, or a new fake session when nil, and records the accounts
+type fakeAccountOpener struct {
+	mu       sync.Mutex
+	session  provider.Session
+	accounts []string
+	apiKeys  []string
+}
+
+func (o *fakeAccountOpener) OpenAccountSession(_ context.Context, accountID, apiKey string, _ *zap.Logger) (provider.Session, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.accounts = append(o.accounts, accountID)
+	o.apiKeys = append(o.apiKeys, apiKey)
+	if o.session != nil {
+		return o.session, nil
+	}
+	return &fake.FakeSession{}, nil
+}
+
+func TestAccountTargetFromSecrets(t *testing.T) {
+	testCases := []struct {
+		name        string
+		secrets     map[string]string
+		expected    accountTarget
+		expectedErr bool
+	}{
+		{
+			name:     "No secrets",
+			expected: accountTarget{},
+		},
+		{
+			name:     "Only other secrets",
+			secrets:  map[string]string{ResourceGroup: "rg-1"},
+			expected: accountTarget{},
+		},
+		{
+			name:     "Other account",
+			secrets:  map[string]string{AccountAPIKey: " key-1 ", AccountID: "account-1", ResourceGroup: "rg-1"},
+			expected: accountTarget{AccountID: "account-1", APIKey: "key-1"},
+		},
+		{
+			name:     "Other VPC",
+			secrets:  map[string]string{TargetVPCID: "vpc-1", TargetVPCSubnetIDs: "subnet-1,subnet-2"},
+			expected: accountTarget{VPCID: "vpc-1", SubnetIDs: "subnet-1,subnet-2"},
+		},
+		{
+			name:        "API key without account",
+			secrets:     map[string]string{AccountAPIKey: "key-1"},
+			expectedErr: true,
+		},
+		{
+			name:        "Account without API key",
+			secrets:     map[string]string{AccountID: "account-1"},
+			expectedErr: true,
+		},
+		{
+			name:        "Subnets without VPC",
+			secrets:     map[string]string{TargetVPCSubnetIDs: "subnet-1"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target, err := accountTargetFromSecrets(tc.secrets)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, target)
+		})
+	}
+}
+
+func TestAccountSessionsGet(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	account1 := accountTarget{AccountID: "account-1", APIKey: "key-1"}
+	account2 := accountTarget{AccountID: "account-2", APIKey: "key-2"}
+
+	opener := &fakeAccountOpener{}
+	as := NewAccountSessions(&countingProvider{}, opener, time.Minute, logger)
+	now := time.Now()
+	as.now = func() time.Time { return now }
+
+	first, cache, err := as.Get(context.Background(), logger, account1)
+	assert.NoError(t, err)
+	assert.NotNil(t, cache)
+	second, _, err := as.Get(context.Background(), logger, account1)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	other, _, err := as.Get(context.Background(), logger, account2)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, other)
+	assert.Equal(t, []string{"account-1", "account-2"}, opener.accounts)
+	assert.Equal(t, []string{"key-1", "key-2"}, opener.apiKeys)
+
+	// a rotated API key gets its own session
+	_, _, err = as.Get(context.Background(), logger, accountTarget{AccountID: "account-1", APIKey: "key-3"})
+	assert.NoError(t, err)
+	assert.Len(t, opener.accounts, 3)
+
+	// accounts unused for a ttl are dropped
+	now = now.Add(30 * time.Second)
+	_, _, err = as.Get(context.Background(), logger, account2)
+	assert.NoError(t, err)
+	now = now.Add(45 * time.Second)
+	_, _, err = as.Get(context.Background(), logger, account2)
+	assert.NoError(t, err)
+	assert.Len(t, as.accounts, 1)
+
+	// sessions of an account are refreshed like the cluster session
+	cache.Invalidate("provider rejected session token")
+	_, _, err = as.Get(context.Background(), logger, account1)
+	assert.NoError(t, err)
+	assert.Len(t, opener.accounts, 5)
+}
+
+func TestAccountSessionsNotCached(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	account := accountTarget{AccountID: "account-1", APIKey: "key-1"}
+
+	opener := &fakeAccountOpener{}
+	as := NewAccountSessions(&countingProvider{}, opener, 0, logger)
+	for i := 0; i < 2; i++ {
+		session, cache, err := as.Get(context.Background(), logger, account)
+		assert.NoError(t, err)
+		assert.NotNil(t, session)
+		assert.Nil(t, cache)
+	}
+	assert.Len(t, opener.accounts, 2)
+	assert.Empty(t, as.accounts)
+
+	_, _, err := NewAccountSessions(&countingProvider{}, nil, time.Minute, logger).Get(context.Background(), logger, account)
+	assert.Error(t, err)
+}
+
+func TestCreateVolumeAccountTarget(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+
+	volName := "test-name"
+	capacity := 20
+	volume := &provider.Volume{
+		Capacity: &capacity,
+		Name:     &volName,
+		VolumeID: "testVolumeId",
+		Az:       "myzone",
+		Region:   "myregion",
+		VPCVolume: provider.VPCVolume{
+			VPCFileVolume: provider.VPCFileVolume{
+				VolumeAccessPoints: &[]provider.VolumeAccessPoint{{ID: "testVolumeAccessPointId"}},
+			},
+		},
+	}
+	accessPoint := &provider.VolumeAccessPointResponse{
+		VolumeID:      "testVolumeId",
+		AccessPointID: "testVolumeAccessPointId",
+		Status:        "Stable",
+		MountPath:     "abc:/xyz/pqr",
+		CreatedAt:     &time.Time{},
+	}
+
+	testCases := []struct {
+		name             string
+		secrets          map[string]string
+		expErrCode       codes.Code
+		expAccounts      []string
+		expVPCID         string
+		expSubnetIDList  string
+		expClusterLookup bool
+	}{
+		{
+			name:             "Cluster account and VPC",
+			expAccounts:      nil,
+			expVPCID:         "vpc-cluster",
+			expSubnetIDList:  "subnet-cluster",
+			expClusterLookup: true,
+		},
+		{
+			name:             "Other account in the cluster VPC",
+			secrets:          map[string]string{AccountAPIKey: "key-1", AccountID: "account-1", ResourceGroup: "rg-1"},
+			expAccounts:      []string{"account-1"},
+			expVPCID:         "vpc-cluster",
+			expSubnetIDList:  "subnet-cluster",
+			expClusterLookup: true,
+		},
+		{
+			name:            "Other account and VPC",
+			secrets:         map[string]string{AccountAPIKey: "key-1", AccountID: "account-1", ResourceGroup: "rg-1", TargetVPCID: "vpc-tenant", TargetVPCSubnetIDs: "subnet-tenant"},
+			expAccounts:     []string{"account-1"},
+			expVPCID:        "vpc-tenant",
+			expSubnetIDList: "subnet-tenant",
+		},
+		{
+			name:       "Other VPC without subnets",
+			secrets:    map[string]string{TargetVPCID: "vpc-tenant"},
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name:       "Other account without resource group",
+			secrets:    map[string]string{AccountAPIKey: "key-1", AccountID: "account-1"},
+			expErrCode: codes.InvalidArgument,
+		},
+		{
+			name:       "API key without account",
+			secrets:    map[string]string{AccountAPIKey: "key-1", ResourceGroup: "rg-1"},
+			expErrCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			icDriver := initIBMCSIDriver(t)
+			assert.NoError(t, icDriver.config.ApplyClusterSettings(driverconfig.ClusterSettings{VPCID: "vpc-cluster", VPCSubnetIDs: "subnet-cluster"}))
+
+			clusterSession := &fake.FakeSession{}
+			icDriver.cs.CSIProvider = &fixedSessionProvider{CloudProviderInterface: icDriver.cs.CSIProvider, session: clusterSession}
+			icDriver.cs.Sessions = nil
+			accountSession := &fake.FakeSession{}
+			opener := &fakeAccountOpener{session: accountSession}
+			icDriver.cs.Accounts = NewAccountSessions(icDriver.cs.CSIProvider, opener, time.Minute, logger)
+			for _, session := range []*fake.FakeSession{clusterSession, accountSession} {
+				session.GetVolumeByNameReturns(nil, nil)
+				session.GetSubnetForVolumeAccessPointReturns("subnet-id", nil)
+				session.GetSecurityGroupForVolumeAccessPointReturns("sg-id", nil)
+				session.CreateVolumeReturns(volume, nil)
+				session.WaitForCreateVolumeAccessPointReturns(accessPoint, nil)
+			}
+
+			resp, err := icDriver.cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               volName,
+				CapacityRange:      stdCapRange,
+				VolumeCapabilities: stdVolCap,
+				Parameters:         stdENIParams,
+				Secrets:            tc.secrets,
+			})
+			if tc.expErrCode != codes.OK {
+				assert.Equal(t, tc.expErrCode, status.Code(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expAccounts, opener.accounts)
+
+			used := clusterSession
+			if len(tc.expAccounts) != 0 {
+				used = accountSession
+				assert.Equal(t, 0, clusterSession.CreateVolumeCallCount())
+				// later requests of the volume are checked against the account
+				assert.Equal(t, tc.expAccounts[0], resp.GetVolume().GetVolumeContext()[AccountIDLabel])
+			} else {
+				assert.NotContains(t, resp.GetVolume().GetVolumeContext(), AccountIDLabel)
+			}
+			assert.Equal(t, 1, used.CreateVolumeCallCount())
+			subnetRequest := used.GetSubnetForVolumeAccessPointArgsForCall(0)
+			assert.Equal(t, tc.expVPCID, subnetRequest.VPCID)
+			assert.Equal(t, tc.expSubnetIDList, subnetRequest.SubnetIDList)
+			assert.Equal(t, tc.expVPCID, used.GetSecurityGroupForVolumeAccessPointArgsForCall(0).VPCID)
+		})
+	}
+}
+
+func TestDeleteVolumeAccountTarget(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+
+	icDriver := initIBMCSIDriver(t)
+	clusterSession := &fake.FakeSession{}
+	icDriver.cs.CSIProvider = &fixedSessionProvider{CloudProviderInterface: icDriver.cs.CSIProvider, session: clusterSession}
+	icDriver.cs.Sessions = nil
+	accountSession := &fake.FakeSession{}
+	accountSession.GetVolumeReturns(&provider.Volume{VolumeID: "testVolumeId"}, nil)
+	opener := &fakeAccountOpener{session: accountSession}
+	icDriver.cs.Accounts = NewAccountSessions(icDriver.cs.CSIProvider, opener, time.Minute, logger)
+
+	_, err := icDriver.cs.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{
+		VolumeId: "testVolumeId" + VolumeIDSeperator + "testVolumeAccessPointId",
+		Secrets:  map[string]string{AccountAPIKey: "key-1", AccountID: "account-1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"account-1"}, opener.accounts)
+	assert.Equal(t, 1, accountSession.DeleteVolumeCallCount())
+	assert.Equal(t, 0, clusterSession.DeleteVolumeCallCount())
+
+	_, err = icDriver.cs.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{
+		VolumeId: "testVolumeId" + VolumeIDSeperator + "testVolumeAccessPointId",
+		Secrets:  map[string]string{AccountID: "account-1"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestVolumeAccountCheck(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	const (
+		tenantVolume  = "share-tenant#target-tenant"
+		clusterVolume = "share-cluster#target-cluster"
+		tenantCRN     = "crn:v1:staging:public:is:us-south-1:a/account-1::share-snapshot:share-tenant/snapshot-1"
+		otherCRN      = "crn:v1:staging:public:is:us-south-1:a/account-2::share-snapshot:share-other/snapshot-2"
+	)
+	tenantSecrets := map[string]string{AccountAPIKey: "key-1", AccountID: "account-1"}
+
+	testCases := []struct {
+		name       string
+		call       func(cs *CSIControllerServer) error
+		expErrCode codes.Code
+	}{
+		{
+			name: "Expand a volume of another account with its secret",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.ControllerExpandVolume(context.Background(), &csi.ControllerExpandVolumeRequest{VolumeId: tenantVolume, CapacityRange: stdCapRange, Secrets: tenantSecrets})
+				return err
+			},
+			expErrCode: codes.OK,
+		},
+		{
+			name: "Expand a volume of another account without a controller-expand secret",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.ControllerExpandVolume(context.Background(), &csi.ControllerExpandVolumeRequest{VolumeId: tenantVolume, CapacityRange: stdCapRange})
+				return err
+			},
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name: "Expand a cluster volume with the secret of another account",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.ControllerExpandVolume(context.Background(), &csi.ControllerExpandVolumeRequest{VolumeId: clusterVolume, CapacityRange: stdCapRange, Secrets: tenantSecrets})
+				return err
+			},
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name: "Expand a volume without PV",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.ControllerExpandVolume(context.Background(), &csi.ControllerExpandVolumeRequest{VolumeId: "share-static#target-static", CapacityRange: stdCapRange})
+				return err
+			},
+			expErrCode: codes.OK,
+		},
+		{
+			name: "Snapshot a volume of another account without a snapshotter secret",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.CreateSnapshot(context.Background(), &csi.CreateSnapshotRequest{Name: "snapshot-1", SourceVolumeId: tenantVolume})
+				return err
+			},
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name: "Delete a snapshot of another account without a snapshotter secret",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.DeleteSnapshot(context.Background(), &csi.DeleteSnapshotRequest{SnapshotId: tenantCRN})
+				return err
+			},
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name: "Delete a snapshot of a third account",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.DeleteSnapshot(context.Background(), &csi.DeleteSnapshotRequest{SnapshotId: otherCRN, Secrets: tenantSecrets})
+				return err
+			},
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name: "Delete a snapshot of another account with its secret",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.DeleteSnapshot(context.Background(), &csi.DeleteSnapshotRequest{SnapshotId: tenantCRN, Secrets: tenantSecrets})
+				return err
+			},
+			expErrCode: codes.OK,
+		},
+		{
+			name: "Validate a volume of another account from its volume context",
+			call: func(cs *CSIControllerServer) error {
+				_, err := cs.ValidateVolumeCapabilities(context.Background(), &csi.ValidateVolumeCapabilitiesRequest{
+					VolumeId:           "share-static#target-static",
+					VolumeCapabilities: stdVolCap,
+					VolumeContext:      map[string]string{AccountIDLabel: "account-1"},
+				})
+				return err
+			},
+			expErrCode: codes.FailedPrecondition,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			icDriver := initIBMCSIDriver(t)
+			clusterSession := &fake.FakeSession{}
+			icDriver.cs.CSIProvider = &fixedSessionProvider{CloudProviderInterface: icDriver.cs.CSIProvider, session: clusterSession}
+			icDriver.cs.Sessions = nil
+			accountSession := &fake.FakeSession{}
+			icDriver.cs.Accounts = NewAccountSessions(icDriver.cs.CSIProvider, &fakeAccountOpener{session: accountSession}, time.Minute, logger)
+			for _, session := range []*fake.FakeSession{clusterSession, accountSession} {
+				session.GetVolumeReturns(&provider.Volume{VolumeID: "share"}, nil)
+				session.CreateSnapshotReturns(&provider.Snapshot{SnapshotID: "snapshot-1", VolumeID: "share-tenant"}, nil)
+			}
+
+			events := newVolumeEventRecorder(k8sfake.NewSimpleClientset(
+				testVolumePV("pv-tenant", tenantVolume, map[string]string{AccountIDLabel: "account-1"}),
+				testVolumePV("pv-cluster", clusterVolume, map[string]string{}),
+			), record.NewFakeRecorder(10), "vpc.file.csi.ibm.io", true, logger)
+			events.Start()
+			defer events.Stop()
+			icDriver.events = events
+
+			err := tc.call(icDriver.cs)
+			assert.Equal(t, tc.expErrCode, status.Code(err), "%v", err)
+			if tc.expErrCode == codes.FailedPrecondition {
+				assert.Contains(t, err.Error(), VolumeAccountMismatch)
+			}
+		})
+	}
+}
+
+// testVolumePV PV of the driver with the volume handle and volume context
+func testVolumePV(name, volumeHandle string, volumeContext map[string]string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+			CSI: &v1.CSIPersistentVolumeSource{Driver: "vpc.file.csi.ibm.io", VolumeHandle: volumeHandle, VolumeAttributes: volumeContext},
+		}},
+	}
+}
+
+func TestAccountSessionsBypassCircuitBreaker(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+
+	icDriver := initIBMCSIDriver(t)
+	icDriver.cs.Breaker = NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute}, logger)
+	accountSession := &fake.FakeSession{}
+	accountSession.GetVolumeReturns(nil, providerError.Message{Code: "InternalError", Description: "Quota exceeded", RC: 500})
+	icDriver.cs.Accounts = NewAccountSessions(icDriver.cs.CSIProvider, &fakeAccountOpener{session: accountSession}, time.Minute, logger)
+	secrets := map[string]string{AccountAPIKey: "key-1", AccountID: "account-1"}
+
+	// failures of another account leave the circuit of the cluster account closed
+	for i := 0; i < 2; i++ {
+		_, err := icDriver.cs.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{
+			VolumeId: "testVolumeId" + VolumeIDSeperator + "testVolumeAccessPointId",
+			Secrets:  secrets,
+		})
+		assert.NotEqual(t, codes.Unavailable, status.Code(err))
+	}
+	assert.Equal(t, 2, accountSession.GetVolumeCallCount())
+	assert.Equal(t, CircuitClosed, icDriver.cs.Breaker.State())
+
+	// and an open circuit does not block other accounts
+	icDriver.cs.Breaker.RecordResult(providerError.Message{Code: "InternalError", RC: 500})
+	assert.Equal(t, CircuitOpen, icDriver.cs.Breaker.State())
+	_, err := icDriver.cs.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{
+		VolumeId: "testVolumeId" + VolumeIDSeperator + "testVolumeAccessPointId",
+		Secrets:  secrets,
+	})
+	assert.NotEqual(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 3, accountSession.GetVolumeCallCount())
+}
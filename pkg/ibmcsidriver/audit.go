@@ -0,0 +1,247 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// AuditSinkStdout writes audit records to stdout with the "audit" logger name
+	AuditSinkStdout = "stdout"
+
+	// auditLoggerName distinguishes audit records from the driver logs on a shared stream
+	auditLoggerName = "audit"
+
+	// redactedValue same masking used for the volume encryption key in the request logs
+	redactedValue = "********"
+)
+
+// auditedMethods mutating storage operations written to the audit sink
+var auditedMethods = map[string]bool{
+	"CreateVolume":           true,
+	"DeleteVolume":           true,
+	"ControllerExpandVolume": true,
+	"CreateSnapshot":         true,
+	"DeleteSnapshot":         true,
+}
+
+type auditContextKey struct{}
+
+// AuditLogger writes one JSON record per mutating storage operation outcome,
+// separate from the driver logs. The RPC handlers add what only they know
+// (requestID, share and key CRNs) to the record carried in the request context.
+type AuditLogger struct {
+	logger *zap.Logger
+}
+
+// NewAuditLogger returns nil when sink is empty. The sink is either
+// AuditSinkStdout or the path of a JSON lines file opened for append.
+func NewAuditLogger(sink string) (*AuditLogger, error) {
+	var out zapcore.WriteSyncer
+	switch sink {
+	case "":
+		return nil, nil
+	case AuditSinkStdout:
+		out = zapcore.Lock(os.Stdout)
+	default:
+		file, err := os.OpenFile(sink, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304: audit log path comes from the driver flags
+		if err != nil {
+			return nil, err
+		}
+		out = zapcore.Lock(file)
+	}
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.TimeKey = "timestamp"
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderCfg.CallerKey = ""
+	encoderCfg.StacktraceKey = ""
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), out, zap.InfoLevel)
+	return newAuditLogger(zap.New(core)), nil
+}
+
+func newAuditLogger(logger *zap.Logger) *AuditLogger {
+	return &AuditLogger{logger: logger.Named(auditLoggerName)}
+}
+
+// auditRecord facts about one operation that are only known inside the RPC handler
+type auditRecord struct {
+	mu               sync.Mutex
+	requestID        string
+	shareCRN         string
+	encryptionKeyCRN string
+	accountID        string
+}
+
+// auditRecordFromContext returns nil when auditing is disabled, all setters are nil safe
+func auditRecordFromContext(ctx context.Context) *auditRecord {
+	record, _ := ctx.Value(auditContextKey{}).(*auditRecord)
+	return record
+}
+
+func (r *auditRecord) setRequestID(requestID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requestID = strings.TrimSpace(requestID)
+}
+
+func (r *auditRecord) setShareCRN(crn string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shareCRN = crn
+}
+
+func (r *auditRecord) setAccountID(accountID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accountID = accountID
+}
+
+func (r *auditRecord) setEncryptionKeyCRN(crn string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.encryptionKeyCRN = crn
+}
+
+// UnaryServerInterceptor writes the audit record of the mutating controller RPCs
+func (a *AuditLogger) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := rpcName(info.FullMethod)
+	if a == nil || !auditedMethods[method] {
+		return handler(ctx, req)
+	}
+
+	record := &auditRecord{}
+	start := time.Now()
+	resp, err := handler(context.WithValue(ctx, auditContextKey{}, record), req)
+	a.write(method, req, resp, err, record, time.Since(start))
+	return resp, err
+}
+
+func (a *AuditLogger) write(method string, req, resp interface{}, err error, record *auditRecord, duration time.Duration) {
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	resultCode := csiErrorCode(err)
+	if resultCode == "" {
+		resultCode = status.Code(err).String()
+	}
+	fields := []zap.Field{
+		zap.String("operation", method),
+		zap.String("requestID", record.requestID),
+		zap.String("result", resultCode),
+		zap.String("grpcStatus", status.Code(err).String()),
+		zap.Int64("durationMs", duration.Milliseconds()),
+	}
+	fields = appendNonEmpty(fields, "shareCRN", record.shareCRN)
+	fields = appendNonEmpty(fields, "encryptionKeyCRN", record.encryptionKeyCRN)
+	fields = appendNonEmpty(fields, "accountID", record.accountID)
+
+	switch request := req.(type) {
+	case *csi.CreateVolumeRequest:
+		params := request.GetParameters()
+		fields = append(fields, zap.String("name", request.GetName()))
+		fields = appendNonEmpty(fields, "pvcNamespace", params[PVCNamespaceKey])
+		fields = appendNonEmpty(fields, "pvcName", params[PVCNameKey])
+		fields = appendNonEmpty(fields, "pvName", params[PVNameKey])
+		fields = appendNonEmpty(fields, "profile", params[Profile])
+		if capacity := request.GetCapacityRange().GetRequiredBytes(); capacity > 0 {
+			fields = append(fields, zap.Int64("requiredBytes", capacity))
+		}
+		if snapshot := request.GetVolumeContentSource().GetSnapshot(); snapshot != nil {
+			fields = append(fields, zap.String("sourceSnapshotID", snapshot.GetSnapshotId()))
+		}
+		fields = appendSecrets(fields, request.GetSecrets())
+		if response, ok := resp.(*csi.CreateVolumeResponse); ok && response.GetVolume() != nil {
+			fields = append(fields, zap.String("volumeID", response.GetVolume().GetVolumeId()))
+		}
+	case *csi.DeleteVolumeRequest:
+		fields = append(fields, zap.String("volumeID", request.GetVolumeId()))
+		fields = appendSecrets(fields, request.GetSecrets())
+	case *csi.ControllerExpandVolumeRequest:
+		fields = append(fields,
+			zap.String("volumeID", request.GetVolumeId()),
+			zap.Int64("requiredBytes", request.GetCapacityRange().GetRequiredBytes()))
+		fields = appendSecrets(fields, request.GetSecrets())
+	case *csi.CreateSnapshotRequest:
+		fields = append(fields,
+			zap.String("name", request.GetName()),
+			zap.String("sourceVolumeID", request.GetSourceVolumeId()))
+		fields = appendSecrets(fields, request.GetSecrets())
+		if response, ok := resp.(*csi.CreateSnapshotResponse); ok && response.GetSnapshot() != nil {
+			fields = append(fields, zap.String("snapshotID", response.GetSnapshot().GetSnapshotId()))
+		}
+	case *csi.DeleteSnapshotRequest:
+		fields = append(fields, zap.String("snapshotID", request.GetSnapshotId()))
+		fields = appendSecrets(fields, request.GetSecrets())
+	}
+
+	if err != nil {
+		fields = append(fields, zap.String("error", requestIDRegex.ReplaceAllString(status.Convert(err).Message(), "")))
+	}
+	a.logger.Info(method, fields...)
+}
+
+func appendNonEmpty(fields []zap.Field, key, value string) []zap.Field {
+	if value == "" {
+		return fields
+	}
+	return append(fields, zap.String(key, value))
+}
+
+// appendSecrets records which secret keys were passed, never their values
+func appendSecrets(fields []zap.Field, secrets map[string]string) []zap.Field {
+	if len(secrets) == 0 {
+		return fields
+	}
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	redacted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		redacted = append(redacted, key+"="+redactedValue)
+	}
+	return append(fields, zap.Strings("secrets", redacted))
+}
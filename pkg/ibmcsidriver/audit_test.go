@@ -0,0 +1,213 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider/fake"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+)
+
+func TestNewAuditLogger(t *testing.T) {
+	audit, err := NewAuditLogger("")
+	assert.Nil(t, err)
+	assert.Nil(t, audit)
+
+	audit, err = NewAuditLogger(AuditSinkStdout)
+	assert.Nil(t, err)
+	assert.NotNil(t, audit)
+
+	_, err = NewAuditLogger(filepath.Join(t.TempDir(), "missing", "audit.log"))
+	assert.NotNil(t, err)
+
+	// one JSON object per line
+	auditFile := filepath.Join(t.TempDir(), "audit.log")
+	audit, err = NewAuditLogger(auditFile)
+	assert.Nil(t, err)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		auditRecordFromContext(ctx).setRequestID("req-1 ")
+		return &csi.DeleteSnapshotResponse{}, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Controller/DeleteSnapshot"}
+	_, err = audit.UnaryServerInterceptor(context.Background(), &csi.DeleteSnapshotRequest{SnapshotId: "crn:snap"}, info, handler)
+	assert.Nil(t, err)
+
+	data, err := os.ReadFile(auditFile) // #nosec G304: test file
+	assert.Nil(t, err)
+	record := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(data, &record))
+	assert.Equal(t, "audit", record["logger"])
+	assert.Equal(t, "DeleteSnapshot", record["operation"])
+	assert.Equal(t, "req-1", record["requestID"])
+	assert.Equal(t, "crn:snap", record["snapshotID"])
+	assert.Equal(t, "OK", record["result"])
+}
+
+func TestAuditLoggerInterceptor(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	registerDriverMessages()
+
+	testcases := []struct {
+		testCaseName   string
+		method         string
+		req            interface{}
+		resp           interface{}
+		err            error
+		expectedRecord map[string]interface{}
+	}{
+		{
+			testCaseName: "CreateVolume success",
+			method:       "/csi.v1.Controller/CreateVolume",
+			req: &csi.CreateVolumeRequest{
+				Name:          "pvc-1234",
+				CapacityRange: &csi.CapacityRange{RequiredBytes: 10737418240},
+				Parameters:    map[string]string{Profile: "dp2", PVCNameKey: "data", PVCNamespaceKey: "app", PVNameKey: "pvc-1234"},
+				Secrets:       map[string]string{"iam_api_key": "secret-value", "encryptionKey": "crn:key"},
+			},
+			resp: &csi.CreateVolumeResponse{Volume: &csi.Volume{VolumeId: "share-1#target-1"}},
+			expectedRecord: map[string]interface{}{
+				"operation":        "CreateVolume",
+				"requestID":        "req-1",
+				"result":           "OK",
+				"grpcStatus":       "OK",
+				"name":             "pvc-1234",
+				"pvcName":          "data",
+				"pvcNamespace":     "app",
+				"pvName":           "pvc-1234",
+				"profile":          "dp2",
+				"requiredBytes":    int64(10737418240),
+				"volumeID":         "share-1#target-1",
+				"shareCRN":         "crn:share",
+				"encryptionKeyCRN": "crn:key",
+				"secrets":          []interface{}{"encryptionKey=********", "iam_api_key=********"},
+			},
+		},
+		{
+			testCaseName: "DeleteVolume failure",
+			method:       "/csi.v1.Controller/DeleteVolume",
+			req:          &csi.DeleteVolumeRequest{VolumeId: "share-1#target-1"},
+			err:          commonError.GetCSIError(logger, commonError.InternalError, "req-1", nil),
+			expectedRecord: map[string]interface{}{
+				"operation":  "DeleteVolume",
+				"requestID":  "req-1",
+				"result":     "InternalError",
+				"grpcStatus": "Internal",
+				"volumeID":   "share-1#target-1",
+				"shareCRN":   "crn:share",
+				"error":      "{Code: InternalError, Description: Internal error occurred, Action: Please check 'BackendError' tag for more details}",
+			},
+		},
+		{
+			testCaseName: "Read only RPC is not audited",
+			method:       "/csi.v1.Controller/ListVolumes",
+			req:          &csi.ListVolumesRequest{},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			audit := newAuditLogger(zap.New(core))
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				record := auditRecordFromContext(ctx)
+				record.setRequestID("req-1 ")
+				record.setShareCRN("crn:share")
+				if _, ok := req.(*csi.CreateVolumeRequest); ok {
+					record.setEncryptionKeyCRN("crn:key")
+				}
+				return testcase.resp, testcase.err
+			}
+			_, err := audit.UnaryServerInterceptor(context.Background(), testcase.req, &grpc.UnaryServerInfo{FullMethod: testcase.method}, handler)
+			assert.Equal(t, testcase.err, err)
+
+			if testcase.expectedRecord == nil {
+				assert.Equal(t, 0, logs.Len())
+				return
+			}
+			assert.Equal(t, 1, logs.Len())
+			entry := logs.All()[0]
+			assert.Equal(t, auditLoggerName, entry.LoggerName)
+			fields := entry.ContextMap()
+			assert.Contains(t, fields, "durationMs")
+			delete(fields, "durationMs")
+			if secrets, ok := fields["secrets"].([]string); ok {
+				asInterfaces := []interface{}{}
+				for _, secret := range secrets {
+					asInterfaces = append(asInterfaces, secret)
+				}
+				fields["secrets"] = asInterfaces
+			}
+			assert.Equal(t, testcase.expectedRecord, fields)
+			assert.NotContains(t, entry.Message+fieldsString(fields), "secret-value")
+		})
+	}
+}
+
+func TestAuditRecordFromContext(t *testing.T) {
+	// auditing disabled, setters are no-ops
+	record := auditRecordFromContext(context.Background())
+	assert.Nil(t, record)
+	record.setRequestID("req-1")
+	record.setShareCRN("crn:share")
+	record.setEncryptionKeyCRN("crn:key")
+
+	var audit *AuditLogger
+	called := false
+	_, err := audit.UnaryServerInterceptor(context.Background(), &csi.DeleteVolumeRequest{}, &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Controller/DeleteVolume"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return nil, nil
+		})
+	assert.Nil(t, err)
+	assert.True(t, called)
+}
+
+func TestDeleteVolumeAuditShareCRN(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	fakeSession, err := icDriver.cs.CSIProvider.GetProviderSession(context.Background(), icDriver.logger)
+	assert.Nil(t, err)
+	fakeStructSession, ok := fakeSession.(*fake.FakeSession)
+	assert.True(t, ok)
+	fakeStructSession.GetVolumeReturns(&provider.Volume{VolumeID: "share-1", VPCVolume: provider.VPCVolume{CRN: "crn:v1:share-1"}}, nil)
+
+	record := &auditRecord{}
+	ctx := context.WithValue(context.Background(), auditContextKey{}, record)
+	_, err = icDriver.cs.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: "share-1#target-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "crn:v1:share-1", record.shareCRN)
+	assert.NotEmpty(t, record.requestID)
+}
+
+func fieldsString(fields map[string]interface{}) string {
+	data, _ := json.Marshal(fields) // #nosec G104: test helper
+	return string(data)
+}
@@ -0,0 +1,231 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
+	providerError "github.com/IBM/ibmcloud-volume-interface/lib/utils"
+	"go.uber.org/zap"
+)
+
+// CircuitState ...
+type CircuitState int
+
+const (
+	// CircuitClosed backend calls are allowed
+	CircuitClosed CircuitState = iota
+	// CircuitOpen backend calls are rejected without reaching VPC
+	CircuitOpen
+	// CircuitHalfOpen a single probe call is allowed to test the backend
+	CircuitHalfOpen
+)
+
+const (
+	// DefaultCircuitBreakerFailureThreshold consecutive backend failures before the circuit opens
+	DefaultCircuitBreakerFailureThreshold = 5
+
+	// DefaultCircuitBreakerOpenTimeout time the circuit stays open before a probe is allowed
+	DefaultCircuitBreakerOpenTimeout = 30 * time.Second
+)
+
+// String ...
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerConfig ...
+type CircuitBreakerConfig struct {
+	// FailureThreshold consecutive backend failures that open the circuit, 0 disables the breaker
+	FailureThreshold int
+
+	// OpenTimeout time the circuit stays open before a half-open probe is allowed
+	OpenTimeout time.Duration
+}
+
+// CircuitBreaker guards the VPC provider session calls. After FailureThreshold
+// consecutive backend failures it fails fast for OpenTimeout and then lets a
+// single probe call through to decide whether to close again.
+type CircuitBreaker struct {
+	mu             sync.Mutex
+	config         CircuitBreakerConfig
+	state          CircuitState
+	failures       int
+	openedAt       time.Time
+	probeStartedAt time.Time
+	probeInFlight  bool
+	logger         *zap.Logger
+	now            func() time.Time
+}
+
+// NewCircuitBreaker returns nil when the breaker is disabled, all methods are nil safe
+func NewCircuitBreaker(config CircuitBreakerConfig, logger *zap.Logger) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		logger.Info("VPC provider circuit breaker is disabled")
+		return nil
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = DefaultCircuitBreakerOpenTimeout
+	}
+	logger.Info("VPC provider circuit breaker is enabled",
+		zap.Int("failureThreshold", config.FailureThreshold),
+		zap.Duration("openTimeout", config.OpenTimeout))
+	driverMetrics.CircuitBreakerState.Set(float64(CircuitClosed))
+	return &CircuitBreaker{
+		config: config,
+		state:  CircuitClosed,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Allow reports whether a backend call may proceed. When it may not, the
+// remaining time before the next probe is returned.
+func (cb *CircuitBreaker) Allow() (bool, time.Duration) {
+	if cb == nil {
+		return true, 0
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	switch cb.state {
+	case CircuitOpen:
+		retryAt := cb.openedAt.Add(cb.config.OpenTimeout)
+		if now.Before(retryAt) {
+			driverMetrics.CircuitBreakerRejected.Inc()
+			return false, retryAt.Sub(now)
+		}
+		cb.setState(CircuitHalfOpen)
+		cb.probeInFlight = true
+		cb.probeStartedAt = now
+		return true, 0
+	case CircuitHalfOpen:
+		// A probe that never reported back (e.g. the request failed validation
+		// before calling VPC) must not keep the circuit half-open forever.
+		if cb.probeInFlight && now.Before(cb.probeStartedAt.Add(cb.config.OpenTimeout)) {
+			driverMetrics.CircuitBreakerRejected.Inc()
+			return false, cb.probeStartedAt.Add(cb.config.OpenTimeout).Sub(now)
+		}
+		cb.probeInFlight = true
+		cb.probeStartedAt = now
+		return true, 0
+	}
+	return true, 0
+}
+
+// RecordResult updates the breaker with the outcome of a backend call
+func (cb *CircuitBreaker) RecordResult(err error) {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !isBackendFailure(err) {
+		cb.failures = 0
+		cb.probeInFlight = false
+		if cb.state != CircuitClosed {
+			cb.setState(CircuitClosed)
+		}
+		return
+	}
+
+	cb.failures++
+	switch cb.state {
+	case CircuitHalfOpen:
+		cb.probeInFlight = false
+		cb.open(err)
+	case CircuitClosed:
+		if cb.failures >= cb.config.FailureThreshold {
+			cb.open(err)
+		}
+	}
+}
+
+// State ...
+func (cb *CircuitBreaker) State() CircuitState {
+	if cb == nil {
+		return CircuitClosed
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// FailureThreshold ...
+func (cb *CircuitBreaker) FailureThreshold() int {
+	if cb == nil {
+		return 0
+	}
+	return cb.config.FailureThreshold
+}
+
+func (cb *CircuitBreaker) open(err error) {
+	cb.openedAt = cb.now()
+	cb.setState(CircuitOpen)
+	cb.logger.Warn("VPC provider circuit breaker opened, failing fast until the backend recovers",
+		zap.Int("consecutiveFailures", cb.failures),
+		zap.Duration("openTimeout", cb.config.OpenTimeout),
+		zap.Error(err))
+}
+
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	cb.logger.Info("VPC provider circuit breaker state change",
+		zap.String("from", cb.state.String()),
+		zap.String("to", state.String()))
+	cb.state = state
+	driverMetrics.CircuitBreakerState.Set(float64(state))
+}
+
+// isBackendFailure reports whether err means VPC itself is unhealthy. Errors
+// caused by the request (not found, invalid input, permission) prove the
+// backend is responding and do not count as failures.
+func isBackendFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg, ok := err.(providerError.Message)
+	if !ok {
+		// connection errors, timeouts etc.
+		return true
+	}
+	if msg.RC >= http.StatusInternalServerError || msg.RC == http.StatusTooManyRequests {
+		return true
+	}
+	if msg.RC == 0 {
+		switch msg.Type {
+		case providerError.FailedAccessToken, providerError.ErrorTypeFailed:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider/fake"
+	providerError "github.com/IBM/ibmcloud-volume-interface/lib/utils"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestCircuitBreaker(t *testing.T, threshold int, timeout time.Duration) (*CircuitBreaker, *time.Time) {
+	logger, teardown := GetTestLogger(t)
+	t.Cleanup(teardown)
+	now := time.Now()
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: threshold, OpenTimeout: timeout}, logger)
+	cb.now = func() time.Time { return now }
+	return cb, &now
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	cb := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 0}, logger)
+	assert.Nil(t, cb)
+
+	for i := 0; i < 10; i++ {
+		cb.RecordResult(errors.New("connection refused"))
+	}
+	allowed, _ := cb.Allow()
+	assert.True(t, allowed)
+	assert.Equal(t, CircuitClosed, cb.State())
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	cb, now := newTestCircuitBreaker(t, 3, 30*time.Second)
+	backendErr := errors.New("dial tcp: i/o timeout")
+
+	// failures below the threshold keep the circuit closed, a success resets the count
+	cb.RecordResult(backendErr)
+	cb.RecordResult(backendErr)
+	cb.RecordResult(nil)
+	cb.RecordResult(backendErr)
+	cb.RecordResult(backendErr)
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	cb.RecordResult(backendErr)
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	allowed, retryIn := cb.Allow()
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Second, retryIn)
+
+	// after the timeout a single probe is let through
+	*now = now.Add(31 * time.Second)
+	allowed, _ = cb.Allow()
+	assert.True(t, allowed)
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+	allowed, _ = cb.Allow()
+	assert.False(t, allowed)
+
+	// failed probe opens the circuit again
+	cb.RecordResult(backendErr)
+	assert.Equal(t, CircuitOpen, cb.State())
+	allowed, _ = cb.Allow()
+	assert.False(t, allowed)
+
+	// successful probe closes it
+	*now = now.Add(31 * time.Second)
+	allowed, _ = cb.Allow()
+	assert.True(t, allowed)
+	cb.RecordResult(nil)
+	assert.Equal(t, CircuitClosed, cb.State())
+	allowed, _ = cb.Allow()
+	assert.True(t, allowed)
+}
+
+func TestCircuitBreakerStaleProbe(t *testing.T) {
+	cb, now := newTestCircuitBreaker(t, 1, 10*time.Second)
+	cb.RecordResult(errors.New("connection reset by peer"))
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	*now = now.Add(11 * time.Second)
+	allowed, _ := cb.Allow()
+	assert.True(t, allowed)
+
+	// probe never reported a result, another one is allowed after the timeout
+	*now = now.Add(11 * time.Second)
+	allowed, _ = cb.Allow()
+	assert.True(t, allowed)
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+}
+
+func TestIsBackendFailure(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil error", err: nil, expected: false},
+		{name: "plain error", err: errors.New("dial tcp: i/o timeout"), expected: true},
+		{name: "server error", err: providerError.Message{Code: "InternalError", RC: 500, Type: providerError.ProvisioningFailed}, expected: true},
+		{name: "service unavailable", err: providerError.Message{Code: "ServiceUnavailable", RC: 503}, expected: true},
+		{name: "throttled", err: providerError.Message{Code: "TooManyRequests", RC: 429}, expected: true},
+		{name: "token failure", err: providerError.Message{Code: "FailedToGetToken", Type: providerError.FailedAccessToken}, expected: true},
+		{name: "not found", err: providerError.Message{Code: "StorageFindFailedWithVolumeName", Type: providerError.RetrivalFailed}, expected: false},
+		{name: "bad request", err: providerError.Message{Code: "InvalidRequest", RC: 400, Type: providerError.InvalidRequest}, expected: false},
+		{name: "forbidden", err: providerError.Message{Code: "Forbidden", RC: 403, Type: providerError.PermissionDenied}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isBackendFailure(tc.err))
+		})
+	}
+}
+
+func TestControllerFailsFastWhenCircuitOpen(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	icDriver.cs.Breaker = NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}, logger)
+
+	fakeSession, err := icDriver.cs.CSIProvider.GetProviderSession(context.Background(), icDriver.logger)
+	assert.Nil(t, err)
+	fakeStructSession, ok := fakeSession.(*fake.FakeSession)
+	assert.True(t, ok)
+	fakeStructSession.ListVolumesReturns(nil, providerError.Message{Code: "InternalError", Description: "List Volumes Failed", RC: 500})
+
+	for i := 0; i < 2; i++ {
+		_, err = icDriver.cs.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, CircuitOpen, icDriver.cs.Breaker.State())
+
+	callCount := fakeStructSession.ListVolumesCallCount()
+	_, err = icDriver.cs.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+	serverError, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unavailable, serverError.Code())
+	assert.Contains(t, serverError.Message(), BackendCircuitOpen)
+	assert.Equal(t, callCount, fakeStructSession.ListVolumesCallCount())
+
+	// an open circuit fails readiness only, restarting the plugin would not help
+	icDriver.HealthChecker().Refresh(context.Background())
+	assert.False(t, icDriver.HealthChecker().Check(context.Background()).Ready)
+	probe, err := icDriver.ids.Probe(context.Background(), &csi.ProbeRequest{})
+	assert.Nil(t, err)
+	assert.True(t, probe.GetReady().GetValue())
+
+	// Backend recovers, the half-open probe closes the circuit
+	icDriver.cs.Breaker.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	fakeStructSession.ListVolumesReturns(&provider.VolumeList{}, nil)
+	_, err = icDriver.cs.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, CircuitClosed, icDriver.cs.Breaker.State())
+}
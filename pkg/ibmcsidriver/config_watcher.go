@@ -20,30 +20,55 @@
 package ibmcsidriver
 
 import (
-	"os"
+	"context"
+	"encoding/json"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/driverconfig"
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 )
 
+const (
+	configEventAdd    = "add"
+	configEventUpdate = "update"
+	configEventDelete = "delete"
+
+	// ConfigmapSyncTimeout time startup waits for the initial cluster provider data
+	ConfigmapSyncTimeout = 30 * time.Second
+)
+
+// ConfigWatcher reconciles the driver config with the ibm-cloud-provider-data ConfigMap: VPC ID,
+// subnet list, per zone subnet lists and cluster security group. The initial list applies the
+// ConfigMap as it is at startup, updates apply the keys that changed and a deleted ConfigMap keeps
+// the last applied settings.
 type ConfigWatcher struct {
 	logger *zap.Logger
 	client rest.Interface
+	config *driverconfig.Store
+
+	stopOnce   sync.Once
+	stopCh     chan struct{}
+	controller cache.Controller
 }
 
-func NewConfigWatcher(client rest.Interface, log *zap.Logger) *ConfigWatcher {
+func NewConfigWatcher(client rest.Interface, config *driverconfig.Store, log *zap.Logger) *ConfigWatcher {
 	return &ConfigWatcher{
 		logger: log,
 		client: client,
+		config: config,
+		stopCh: make(chan struct{}),
 	}
 }
 
+// Start runs the informer in the background until Stop is called
 func (cw *ConfigWatcher) Start() {
 	watchlist := cache.NewListWatchFromClient(cw.client, "configmaps", ConfigmapNamespace, fields.Set{"metadata.name": ConfigmapName}.AsSelector())
 	informerOptions := cache.InformerOptions{
@@ -51,40 +76,122 @@ func (cw *ConfigWatcher) Start() {
 		ObjectType:    &v1.ConfigMap{},
 		ResyncPeriod:  time.Second * 0,
 		Handler: cache.ResourceEventHandlerFuncs{
-			AddFunc:    nil,
-			DeleteFunc: nil,
-			UpdateFunc: cw.updateSubnetList,
+			AddFunc:    cw.onAdd,
+			UpdateFunc: cw.onUpdate,
+			DeleteFunc: cw.onDelete,
 		},
 	}
-	_, controller := cache.NewInformerWithOptions(informerOptions)
-	stopch := wait.NeverStop
-	go controller.Run(stopch)
-	cw.logger.Info("ConfigWatcher started - start watching for any updates in subnet list", zap.Any("configmap name", ConfigmapName), zap.Any("configmap namespace", ConfigmapNamespace))
-	<-stopch
+	_, cw.controller = cache.NewInformerWithOptions(informerOptions)
+	go cw.controller.Run(cw.stopCh)
+	cw.logger.Info("ConfigWatcher started - start watching for any updates in cluster provider data", zap.Any("configmap name", ConfigmapName), zap.Any("configmap namespace", ConfigmapNamespace))
+}
+
+// WaitForSync waits until the ConfigMap was listed and applied, false after timeout or Stop
+func (cw *ConfigWatcher) WaitForSync(timeout time.Duration) bool {
+	if cw.controller == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-cw.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return cache.WaitForCacheSync(ctx.Done(), cw.controller.HasSynced)
 }
 
-// updateSubnetList - Updates the VPC_SUBNET_IDS when ibm-cloud-provider-data configmap is updated.
-func (cw *ConfigWatcher) updateSubnetList(oldObj, newObj interface{}) {
+// Stop stops the informer, safe to call more than once
+func (cw *ConfigWatcher) Stop() {
+	cw.stopOnce.Do(func() {
+		close(cw.stopCh)
+		cw.logger.Info("ConfigWatcher stopped", zap.Any("configmap name", ConfigmapName))
+	})
+}
+
+// onAdd applies the ConfigMap found by the initial list, or created later
+func (cw *ConfigWatcher) onAdd(obj interface{}) {
+	newData, ok := obj.(*v1.ConfigMap)
+	if !ok || strings.TrimSpace(newData.Name) != ConfigmapName {
+		return
+	}
+	cw.apply(configEventAdd, newData, nil)
+}
+
+// onUpdate applies the keys that changed when ibm-cloud-provider-data configmap is updated.
+func (cw *ConfigWatcher) onUpdate(oldObj, newObj interface{}) {
 	newData, _ := newObj.(*v1.ConfigMap)
 	oldData, _ := oldObj.(*v1.ConfigMap)
 	// Confirm if the event recieved is for ibm-cloud-provider-data configmap or not.
-	if strings.TrimSpace(newData.Name) == ConfigmapName {
-		newSubnetList := newData.Data[ConfigmapDataKey]
-		oldSubnetList := oldData.Data[ConfigmapDataKey]
-		// Env variable VPC_SUBNET_IDS will be updated only when there is
-		// non empty data and there is change in configmap
-		if newSubnetList != "" && (newSubnetList != oldSubnetList) {
-			err := os.Setenv("VPC_SUBNET_IDS", newSubnetList)
-			if err != nil {
-				cw.logger.Warn("Error updating the subnet list..", zap.Any("Subnet list update request", newSubnetList), zap.Error(err))
-				return
-			}
-			cw.logger.Info("Updated the vpc subnet list ", zap.Any("VPC_SUBNET_IDS", newSubnetList))
+	if newData == nil || strings.TrimSpace(newData.Name) != ConfigmapName {
+		return
+	}
+	cw.apply(configEventUpdate, newData, oldData)
+}
+
+// onDelete keeps the last applied settings, volumes keep being provisioned with them
+func (cw *ConfigWatcher) onDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	oldData, ok := obj.(*v1.ConfigMap)
+	if !ok || strings.TrimSpace(oldData.Name) != ConfigmapName {
+		return
+	}
+	driverMetrics.ClusterConfigUpdates.WithLabelValues(configEventDelete, "ignored").Inc()
+	cw.logger.Warn("Cluster provider data configmap deleted, keeping the last applied settings", zap.Any("configmap name", ConfigmapName), zap.Reflect("config", cw.config.Get()))
+}
+
+// apply updates the driver config with the keys of newData, only the keys that differ from oldData when it is set.
+// Empty values never clear a setting
+func (cw *ConfigWatcher) apply(event string, newData, oldData *v1.ConfigMap) {
+	changed := func(key string) bool {
+		return oldData == nil || newData.Data[key] != oldData.Data[key]
+	}
+	settings := driverconfig.ClusterSettings{}
+	if changed(ConfigmapVPCIDKey) {
+		settings.VPCID = newData.Data[ConfigmapVPCIDKey]
+	}
+	if changed(ConfigmapDataKey) {
+		settings.VPCSubnetIDs = newData.Data[ConfigmapDataKey]
+	}
+	if changed(ConfigmapSecurityGroupKey) {
+		settings.ClusterSecurityGroupID = newData.Data[ConfigmapSecurityGroupKey]
+	}
+	if changed(ConfigmapZoneSubnetsKey) && newData.Data[ConfigmapZoneSubnetsKey] != "" {
+		zoneSubnetIDs, err := parseZoneSubnetIDs(newData.Data[ConfigmapZoneSubnetsKey])
+		if err != nil {
+			// the other keys are still applied
+			cw.logger.Warn("Ignoring invalid zone subnet list", zap.String("key", ConfigmapZoneSubnetsKey), zap.Error(err))
+		} else {
+			settings.VPCZoneSubnetIDs = zoneSubnetIDs
 		}
 	}
+
+	if err := cw.config.ApplyClusterSettings(settings); err != nil {
+		driverMetrics.ClusterConfigUpdates.WithLabelValues(event, "error").Inc()
+		cw.logger.Warn("Error updating the cluster provider data..", zap.String("event", event), zap.Any("Update request", settings), zap.Error(err))
+		return
+	}
+	driverMetrics.ClusterConfigUpdates.WithLabelValues(event, "success").Inc()
+	driverMetrics.SetClusterConfigApplied(newData.ResourceVersion)
+	cw.logger.Info("Applied cluster provider data", zap.String("event", event), zap.String("resourceVersion", newData.ResourceVersion), zap.Reflect("config", cw.config.Get()))
+}
+
+// parseZoneSubnetIDs parses the JSON object of zone to comma separated subnet list
+func parseZoneSubnetIDs(value string) (map[string]string, error) {
+	zoneSubnetIDs := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &zoneSubnetIDs); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of zone to comma separated subnet IDs: %v", err)
+	}
+	return zoneSubnetIDs, nil
 }
 
-func WatchClusterConfigMap(client rest.Interface, log *zap.Logger) {
-	configWatcher := NewConfigWatcher(client, log)
-	go configWatcher.Start()
+// WatchClusterConfigMap starts a ConfigWatcher, the caller stops it on shutdown
+func WatchClusterConfigMap(client rest.Interface, config *driverconfig.Store, log *zap.Logger) *ConfigWatcher {
+	configWatcher := NewConfigWatcher(client, config, log)
+	configWatcher.Start()
+	return configWatcher
 }
@@ -19,15 +19,19 @@ package ibmcsidriver
 
 import (
 	"bytes"
-	"os"
 	"testing"
+	"time"
 
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/driverconfig"
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	restfake "k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/tools/cache"
 )
 
 // TestWatchClusterConfigMap ...
@@ -49,7 +53,12 @@ func TestWatchClusterConfigMap(t *testing.T) {
 	for _, testcase := range testcases {
 		t.Run(testcase.testcasename, func(t *testing.T) {
 			c := new(restfake.RESTClient)
-			WatchClusterConfigMap(c, logger)
+			cw := WatchClusterConfigMap(c, driverconfig.NewStaticStore(driverconfig.Default(), logger), logger)
+			// the fake client never lists the configmap
+			assert.False(t, cw.WaitForSync(100*time.Millisecond))
+			cw.Stop()
+			cw.Stop()
+			assert.False(t, cw.WaitForSync(time.Minute))
 		})
 	}
 }
@@ -111,22 +120,124 @@ func TestUpdateSubnetList(t *testing.T) {
 	}
 
 	c := new(restfake.RESTClient)
-	cw := NewConfigWatcher(c, logger)
 
 	for _, testcase := range testcases {
 		t.Run(testcase.testCaseName, func(t *testing.T) {
-			err := os.Setenv("VPC_SUBNET_IDS", testcase.currentSubnetID)
-			if err != nil {
-				return
+			config := driverconfig.Default()
+			config.VPCSubnetIDs = testcase.currentSubnetID
+			store := driverconfig.NewStaticStore(config, logger)
+			cw := NewConfigWatcher(c, store, logger)
+			cw.onUpdate(testcase.oldConfigMap, testcase.newConfigMap)
+			assert.Equal(t, testcase.expectedSubnetID, store.Get().VPCSubnetIDs)
+		})
+	}
+}
+
+func TestConfigWatcherReconcile(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+
+	configMap := func(resourceVersion string, data map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ConfigmapName, Namespace: ConfigmapNamespace, ResourceVersion: resourceVersion},
+			Data:       data,
+		}
+	}
+	initial := driverconfig.Config{VPCID: "vpc-1", VPCSubnetIDs: "subnet-1"}
+
+	testcases := []struct {
+		testCaseName            string
+		events                  func(cw *ConfigWatcher)
+		expectedConfig          driverconfig.Config
+		expectedResourceVersion string
+	}{
+		{
+			testCaseName: "Initial add applies all keys",
+			events: func(cw *ConfigWatcher) {
+				cw.onAdd(configMap("10", map[string]string{
+					ConfigmapVPCIDKey:         "vpc-2",
+					ConfigmapDataKey:          "subnet-2,subnet-3",
+					ConfigmapZoneSubnetsKey:   `{"us-south-1": "subnet-2", "us-south-2": "subnet-3"}`,
+					ConfigmapSecurityGroupKey: "sg-1",
+				}))
+			},
+			expectedConfig: driverconfig.Config{
+				VPCID:                  "vpc-2",
+				VPCSubnetIDs:           "subnet-2,subnet-3",
+				VPCZoneSubnetIDs:       map[string]string{"us-south-1": "subnet-2", "us-south-2": "subnet-3"},
+				ClusterSecurityGroupID: "sg-1",
+			},
+			expectedResourceVersion: "10",
+		},
+		{
+			testCaseName: "Update applies only changed keys",
+			events: func(cw *ConfigWatcher) {
+				old := configMap("10", map[string]string{ConfigmapVPCIDKey: "vpc-2", ConfigmapDataKey: "subnet-2"})
+				cw.onAdd(old)
+				assert.NoError(t, cw.config.SetVPCSubnetIDs("subnet-from-file"))
+				cw.onUpdate(old, configMap("11", map[string]string{ConfigmapVPCIDKey: "vpc-3", ConfigmapDataKey: "subnet-2"}))
+			},
+			expectedConfig:          driverconfig.Config{VPCID: "vpc-3", VPCSubnetIDs: "subnet-from-file"},
+			expectedResourceVersion: "11",
+		},
+		{
+			testCaseName: "Empty values keep the current settings",
+			events: func(cw *ConfigWatcher) {
+				cw.onAdd(configMap("12", map[string]string{ConfigmapVPCIDKey: "", ConfigmapDataKey: " "}))
+			},
+			expectedConfig:          initial,
+			expectedResourceVersion: "12",
+		},
+		{
+			testCaseName: "Invalid zone subnets are skipped",
+			events: func(cw *ConfigWatcher) {
+				cw.onAdd(configMap("13", map[string]string{ConfigmapZoneSubnetsKey: "us-south-1:subnet-2", ConfigmapSecurityGroupKey: "sg-2"}))
+			},
+			expectedConfig:          driverconfig.Config{VPCID: "vpc-1", VPCSubnetIDs: "subnet-1", ClusterSecurityGroupID: "sg-2"},
+			expectedResourceVersion: "13",
+		},
+		{
+			testCaseName: "Rejected update keeps the last applied version",
+			events: func(cw *ConfigWatcher) {
+				cw.onAdd(configMap("14", map[string]string{ConfigmapDataKey: "subnet-2"}))
+				cw.onUpdate(configMap("14", map[string]string{ConfigmapDataKey: "subnet-2"}), configMap("15", map[string]string{ConfigmapDataKey: "subnet-3,,subnet-4"}))
+			},
+			expectedConfig:          driverconfig.Config{VPCID: "vpc-1", VPCSubnetIDs: "subnet-2"},
+			expectedResourceVersion: "14",
+		},
+		{
+			testCaseName: "Delete keeps the last applied settings",
+			events: func(cw *ConfigWatcher) {
+				applied := configMap("16", map[string]string{ConfigmapDataKey: "subnet-2"})
+				cw.onAdd(applied)
+				cw.onDelete(cache.DeletedFinalStateUnknown{Key: ConfigmapNamespace + "/" + ConfigmapName, Obj: applied})
+			},
+			expectedConfig:          driverconfig.Config{VPCID: "vpc-1", VPCSubnetIDs: "subnet-2"},
+			expectedResourceVersion: "16",
+		},
+		{
+			testCaseName: "Other configmaps are ignored",
+			events: func(cw *ConfigWatcher) {
+				other := configMap("17", map[string]string{ConfigmapDataKey: "subnet-2"})
+				other.Name = "other"
+				cw.onAdd(other)
+			},
+			expectedConfig: initial,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			driverMetrics.ClusterConfigApplied.Reset()
+			cw := NewConfigWatcher(new(restfake.RESTClient), driverconfig.NewStaticStore(initial, logger), logger)
+			testcase.events(cw)
+			assert.Equal(t, testcase.expectedConfig, cw.config.Get())
+			if testcase.expectedResourceVersion == "" {
+				assert.Equal(t, 0, testutil.CollectAndCount(driverMetrics.ClusterConfigApplied))
+			} else {
+				assert.Equal(t, 1, testutil.CollectAndCount(driverMetrics.ClusterConfigApplied))
+				assert.Equal(t, float64(1), testutil.ToFloat64(driverMetrics.ClusterConfigApplied.WithLabelValues(testcase.expectedResourceVersion)))
 			}
-			defer func() {
-				err = os.Unsetenv("VPC_SUBNET_IDS")
-				if err != nil {
-					return
-				}
-			}()
-			cw.updateSubnetList(testcase.oldConfigMap, testcase.newConfigMap)
-			assert.Equal(t, testcase.expectedSubnetID, os.Getenv("VPC_SUBNET_IDS"))
 		})
 	}
 }
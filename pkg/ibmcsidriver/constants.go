@@ -127,6 +127,9 @@ const (
 	// VolumeCRNLabel ...
 	VolumeCRNLabel = "volumeCRN"
 
+	// AccountIDLabel ... account of a volume provisioned with the credentials of another account than the cluster one
+	AccountIDLabel = "accountID"
+
 	//VolumeHrefLabel ...
 	VolumeHrefLabel = "volumeHref"
 
@@ -193,9 +196,33 @@ const (
 	// SubnetID ...
 	SubnetID = "subnetID"
 
+	// OriginShareCRN ... CRN of an existing file share, possibly in another account, the volume is created as accessor share of
+	OriginShareCRN = "originShareCRN"
+
+	// AccountAPIKey ... provisioner secret only, IAM API key of the account the shares are created in
+	AccountAPIKey = "apiKey"
+
+	// AccountID ... provisioner secret only, account of AccountAPIKey
+	AccountID = "accountID"
+
+	// TargetVPCID ... provisioner secret only, VPC the file share targets are created in instead of the cluster VPC
+	TargetVPCID = "vpcID"
+
+	// TargetVPCSubnetIDs ... provisioner secret only, comma separated subnets of TargetVPCID
+	TargetVPCSubnetIDs = "vpcSubnetIDs"
+
 	// VMState ... Parameter to identify VM persistent state volumes (vTPM)
 	VMState = "vmState"
 
+	// PVCNameKey ... PVC name passed by csi-provisioner with --extra-create-metadata
+	PVCNameKey = "csi.storage.k8s.io/pvc/name"
+
+	// PVCNamespaceKey ... PVC namespace passed by csi-provisioner with --extra-create-metadata
+	PVCNamespaceKey = "csi.storage.k8s.io/pvc/namespace"
+
+	// PVNameKey ... PV name passed by csi-provisioner with --extra-create-metadata
+	PVNameKey = "csi.storage.k8s.io/pv/name"
+
 	// ConfigmapName ...
 	ConfigmapName = "ibm-cloud-provider-data"
 
@@ -205,6 +232,15 @@ const (
 	// ConfigmapDataKey ...
 	ConfigmapDataKey = "vpc_subnet_ids"
 
+	// ConfigmapVPCIDKey ...
+	ConfigmapVPCIDKey = "vpc_id"
+
+	// ConfigmapZoneSubnetsKey JSON object of zone to comma separated subnet IDs
+	ConfigmapZoneSubnetsKey = "vpc_zone_subnet_ids"
+
+	// ConfigmapSecurityGroupKey ...
+	ConfigmapSecurityGroupKey = "cluster_security_group_id"
+
 	// MinimumRFSVolumeSizeInBytes ... This is minimum size require for rfs profile
 	MinimumRFSVolumeSizeInBytes int64 = 1 * utils.GiB
 )
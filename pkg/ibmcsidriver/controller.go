@@ -20,7 +20,7 @@
 package ibmcsidriver
 
 import (
-	"os"
+	"fmt"
 	"strings"
 	"time"
 
@@ -35,12 +35,21 @@ import (
 	"context"
 
 	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
 )
 
 // CSIControllerServer ...
 type CSIControllerServer struct {
 	Driver      *IBMCSIDriver
 	CSIProvider cloudProvider.CloudProviderInterface
+	Breaker     *CircuitBreaker
+	Sessions    *SessionCache
+	// Accounts sessions of the accounts in provisioner secrets, see accountTargetFromSecrets
+	Accounts *AccountSessions
+	// AccessorShares creates the accessor shares of storage classes with originShareCRN
+	AccessorShares *AccessorShareClient
+	// SubDirectories manages the directories of sub directory volumes, nil unless --subdirectory-volumes is set
+	SubDirectories *SubDirectoryManager
 	csi.UnimplementedControllerServer
 }
 
@@ -53,11 +62,11 @@ var _ csi.ControllerServer = &CSIControllerServer{}
 
 // ControllerGetCapabilities allows kubernetes to check the supported capabilities of controller service provided by the Plugin
 func (csiCS *CSIControllerServer) ControllerGetCapabilities(ctx context.Context, req *csi.ControllerGetCapabilitiesRequest) (*csi.ControllerGetCapabilitiesResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	// populate requestID in the context
 	_ = context.WithValue(ctx, provider.RequestID, requestID)
 
-	ctxLogger.Info("CSIControllerServer-ControllerGetCapabilities", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-ControllerGetCapabilities", zap.Reflect("Request", redactSecrets(req)))
 	// Return the capabilities as per provider volume capabilities
 	return &csi.ControllerGetCapabilitiesResponse{
 		Capabilities: csiCS.Driver.cscap,
@@ -70,10 +79,11 @@ It takes the csi createVolumeRequest as input and populates the provider volume.
 then CreateVolumeAccessPoint call from provider-library. The function returns a csi CreateVolumeResponse if successful and error otherwise.
 */
 func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	auditRecordFromContext(ctx).setRequestID(requestID)
 	// populate requestID in the context
 	ctx = context.WithValue(ctx, provider.RequestID, requestID)
-	ctxLogger.Info("CSIControllerServer-CreateVolume... ", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-CreateVolume... ", zap.Reflect("Request", redactSecrets(req)))
 	defer metrics.UpdateDurationFromStart(ctxLogger, "CSICreateVolume", time.Now())
 
 	// Check basic parameters validations i.e PVC name given
@@ -93,6 +103,11 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 		return nil, commonError.GetCSIError(ctxLogger, commonError.VolumeCapabilitiesNotSupported, requestID, nil)
 	}
 
+	// Volumes of a class with parentVolumeHandle or parentShareName are directories of a share
+	if isSubDirectoryClass(req.GetParameters()) {
+		return csiCS.createSubDirectoryVolume(ctx, ctxLogger, requestID, req)
+	}
+
 	// Get volume input Parameters
 	requestedVolume, err := getVolumeParameters(ctxLogger, req, csiCS.CSIProvider.GetConfig())
 	if requestedVolume != nil {
@@ -110,18 +125,36 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 		ctxLogger.Error("Unable to extract parameters", zap.Error(err))
 		return nil, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, err)
 	}
+	if requestedVolume.VPCVolume.VolumeEncryptionKey != nil {
+		auditRecordFromContext(ctx).setEncryptionKeyCRN(requestedVolume.VPCVolume.VolumeEncryptionKey.CRN)
+	}
 
 	// Check if RFS Profile is accessible
 	if requestedVolume.Profile != nil && requestedVolume.Profile.Name == RFSProfile && !csiCS.Driver.rfsEnabled {
 		return nil, commonError.GetCSIError(ctxLogger, commonError.ProfileNotAllowlisted, requestID, nil, RFSProfile)
 	}
 
+	// Account and VPC the share is created in, the cluster ones unless the provisioner secret sets them
+	target, err := requestAccountTarget(ctx, ctxLogger, requestID, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+	if target.crossAccount() && strings.TrimSpace(req.GetSecrets()[ResourceGroup]) == "" {
+		// the resource group of the cluster does not exist in the other account
+		return nil, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, fmt.Errorf("secret key '%s' is required with '%s'", ResourceGroup, AccountAPIKey))
+	}
+	config := csiCS.Driver.config.Get()
+	vpcID, subnetIDList := config.VPCID, config.SubnetIDsForZone(requestedVolume.Az)
+	if target.VPCID != "" {
+		vpcID, subnetIDList = target.VPCID, target.SubnetIDs
+	}
+
 	// TODO: Determine Zones and Region for the disk
 
 	// Validate if volume Already Exists
-	session, err := csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
+	session, err := csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, commonError.InternalError, target)
 	if err != nil {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+		return nil, err
 	}
 
 	volumeSource := req.GetVolumeContentSource()
@@ -143,12 +176,15 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 		}
 	}
 
+	// Accessor shares take their capacity from the origin share, so only the name is compared with an existing share
+	originShareCRN := strings.TrimSpace(req.GetParameters()[OriginShareCRN])
+
 	var isVolumeExist bool = false
 
 	volumeObj, err := checkIfVolumeExists(session, *requestedVolume, ctxLogger)
 	if volumeObj != nil && err == nil {
 		ctxLogger.Info("Volume already exists", zap.Reflect("ExistingVolume", volumeObj))
-		if volumeObj.Capacity != nil && requestedVolume.Capacity != nil && *volumeObj.Capacity == *requestedVolume.Capacity {
+		if len(originShareCRN) != 0 || volumeObj.Capacity != nil && requestedVolume.Capacity != nil && *volumeObj.Capacity == *requestedVolume.Capacity {
 			isVolumeExist = true
 		} else {
 			return nil, commonError.GetCSIError(ctxLogger, commonError.VolumeAlreadyExists, requestID, err, name, *requestedVolume.Capacity)
@@ -209,7 +245,6 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 		subnetID := requestedVolume.SubnetID
 
 		if len(subnetID) == 0 && (requestedVolume.PrimaryIP == nil || len(requestedVolume.PrimaryIP.ID) == 0) {
-			subnetIDList := os.Getenv("VPC_SUBNET_IDS")
 			ctxLogger.Info("List of subnetIDs considered", zap.Any("subnetIDList", subnetIDList))
 
 			//We need to abort here as there is no use of going ahead and fetching the matching subnet with empty list
@@ -220,7 +255,7 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 			subnetReq := provider.SubnetRequest{
 				SubnetIDList:  subnetIDList,
 				ZoneName:      requestedVolume.Az,
-				VPCID:         os.Getenv("VPC_ID"),
+				VPCID:         vpcID,
 				ResourceGroup: requestedVolume.ResourceGroup,
 			}
 
@@ -235,11 +270,19 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 			ctxLogger.Info("Subnet fetched for VolumeAccessPoint", zap.Reflect("subnetID", subnetID))
 		}
 
-		//If securityGroup parameter is not populated via storage class
+		//If securityGroup parameter is not populated via storage class, use the cluster security group when it is published
+		if clusterSecurityGroupID := config.ClusterSecurityGroupID; requestedVolume.SecurityGroups == nil && clusterSecurityGroupID != "" && target.VPCID == "" {
+			requestedVolume.SecurityGroups = &[]provider.SecurityGroup{
+				{
+					ID: clusterSecurityGroupID,
+				},
+			}
+			ctxLogger.Info("Using cluster SecurityGroup for VolumeAccessPoint", zap.Reflect("securityGroupID", clusterSecurityGroupID))
+		}
 		if requestedVolume.SecurityGroups == nil {
 			securityGroupReq := provider.SecurityGroupRequest{
 				Name:          "kube-" + csiCS.CSIProvider.GetClusterID(),
-				VPCID:         os.Getenv("VPC_ID"),
+				VPCID:         vpcID,
 				ResourceGroup: requestedVolume.ResourceGroup,
 			}
 
@@ -249,6 +292,8 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 			if err != nil || len(securityGroupID) == 0 {
 				// If IKS Cluster SG is not available pass empty SG. VPC IAAS will consider VPC Default SG.
 				ctxLogger.Warn("SecurityGroup find failed for VolumeAccessPoint.VPC default SG will be considered", zap.Error(err))
+				csiCS.Driver.events.PVCEvent(req.GetParameters(), v1.EventTypeWarning, SecurityGroupFallbackReason,
+					fmt.Sprintf("Security group %s was not found, the file share target uses the VPC default security group. Pass securityGroupIDs in the storage class if the default security group does not allow NFS traffic from the worker nodes.", securityGroupReq.Name))
 			} else {
 				requestedVolume.SecurityGroups = &[]provider.SecurityGroup{
 					{
@@ -259,14 +304,21 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 			}
 		}
 	} else { // IF VPC Mode
-		requestedVolume.VPCID = os.Getenv("VPC_ID")
+		requestedVolume.VPCID = vpcID
 	}
 
 	// Create volume if it does no exist
 	if !isVolumeExist {
-		ctxLogger.Info("Creating Volume...")
-
-		volumeObj, err = session.CreateVolume(*requestedVolume)
+		if len(originShareCRN) != 0 {
+			ctxLogger.Info("Creating accessor Volume...", zap.String("originShareCRN", originShareCRN))
+			volumeObj, err = createAccessorVolume(ctx, session, csiCS.AccessorShares, *requestedVolume, originShareCRN)
+			if err != nil {
+				return nil, commonError.GetCSIError(ctxLogger, AccessorShareFailed, requestID, err, originShareCRN)
+			}
+		} else {
+			ctxLogger.Info("Creating Volume...")
+			volumeObj, err = session.CreateVolume(*requestedVolume)
+		}
 		if err != nil {
 			// According to CSI Driver Sanity Tester, should fail with ObjectNotFound error code if the snapshot is not found.The below error handling is just for CSI Driver Sanity to pass.
 			// As per error flow we categorize the backend errors from library either as rpc Internal error or rpc InvalidParameters.
@@ -279,6 +331,7 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 
 		ctxLogger.Info("Volume Created", zap.Reflect("Volume", volumeObj))
 	}
+	auditRecordFromContext(ctx).setShareCRN(volumeObj.CRN)
 
 	// Prepare input for WaitForCreateVolumeAccessPoint
 	volumeAccesspointReq := provider.VolumeAccessPointRequest{
@@ -289,13 +342,13 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 	if volumeAccessPoints != nil && len(*volumeAccessPoints) != 0 {
 		//Pass in the VolumeAccessPointID ID for efficient retrival in WaitForCreateVolumeAccessPoint()
 		volumeAccesspointReq.AccessPointID = (*volumeAccessPoints)[0].ID
-	} else { // This will only hit if Volume is created without VolumeAccessPoint which is rare case.
+	} else { // This will only hit if Volume is created without VolumeAccessPoint, i.e. for accessor shares or in rare cases.
 		//Try Creating VolumeAccess Point
 		//No need to check for access point existence as library takes care of the same
 		ctxLogger.Info("Re attempting to create VolumeAccessPoint...")
 
 		//Pass in the VPC ID for filtering VolumeAccesspoint within volume.
-		volumeAccesspointReq.VPCID = os.Getenv("VPC_ID")
+		volumeAccesspointReq.VPCID = vpcID
 		volumeAccesspointReq.AccessControlMode = requestedVolume.AccessControlMode
 		volumeAccesspointReq.SecurityGroups = requestedVolume.SecurityGroups
 		volumeAccesspointReq.ResourceGroup = requestedVolume.ResourceGroup
@@ -325,8 +378,21 @@ func (csiCS *CSIControllerServer) CreateVolume(ctx context.Context, req *csi.Cre
 	volumeObj.SecurityGroups = requestedVolume.SecurityGroups
 	volumeObj.SubnetID = requestedVolume.SubnetID
 
+	capBytes := int64(*(requestedVolume.Capacity) * utils.GB)
+	// The accessor share reports the capacity of the origin share
+	if len(originShareCRN) != 0 && volumeObj.Capacity != nil && *volumeObj.Capacity > 0 {
+		capBytes = int64(*volumeObj.Capacity * utils.GB)
+	}
 	// return csi volume object
-	return createCSIVolumeResponse(*volumeObj, *volumeAccessPointObj, int64(*(requestedVolume.Capacity)*utils.GB), nil, csiCS.CSIProvider.GetClusterID(), csiCS.Driver.region), nil
+	volumeResponse := createCSIVolumeResponse(*volumeObj, *volumeAccessPointObj, capBytes, nil, csiCS.CSIProvider.GetClusterID(), csiCS.Driver.region)
+	if len(originShareCRN) != 0 {
+		volumeResponse.Volume.VolumeContext[OriginShareCRN] = originShareCRN
+	}
+	if target.crossAccount() {
+		// requests of the volume with the credentials of another account are rejected, see checkVolumeAccount
+		volumeResponse.Volume.VolumeContext[AccountIDLabel] = target.AccountID
+	}
+	return volumeResponse, nil
 }
 
 // DeleteVolume ...
@@ -335,11 +401,12 @@ It takes the csi deleteVolumeRequest as input and creates a provider session to
 then DeleteVolume call from provider-library. The function returns a csi DeleteVolumeResponse if successful and error otherwise.
 */
 func (csiCS *CSIControllerServer) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	auditRecordFromContext(ctx).setRequestID(requestID)
 	// populate requestID in the context
 	ctx = context.WithValue(ctx, provider.RequestID, requestID)
 	defer metrics.UpdateDurationFromStart(ctxLogger, "CSIDeleteVolume", time.Now())
-	ctxLogger.Info("CSIControllerServer-DeleteVolume... ", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-DeleteVolume... ", zap.Reflect("Request", redactSecrets(req)))
 
 	// Validate arguments
 	volumeID := req.GetVolumeId()
@@ -351,10 +418,19 @@ func (csiCS *CSIControllerServer) DeleteVolume(ctx context.Context, req *csi.Del
 	// Get the volume name by using volume ID
 	// and delete volume by name
 
+	target, err := requestAccountTarget(ctx, ctxLogger, requestID, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
 	// get the session
-	session, err := csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
+	session, err := csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, commonError.FailedPrecondition, target)
 	if err != nil {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.FailedPrecondition, requestID, err)
+		return nil, err
+	}
+
+	if subDirectory, ok := parseSubDirectoryVolumeID(volumeID); ok {
+		return csiCS.deleteSubDirectoryVolume(ctx, ctxLogger, requestID, session, subDirectory)
 	}
 
 	//Volume ID is in format volumeID:accesspointID or volumeID#accesspointID
@@ -372,7 +448,12 @@ func (csiCS *CSIControllerServer) DeleteVolume(ctx context.Context, req *csi.Del
 		ctxLogger.Info("Volume not found. Returning success without deletion...")
 		return &csi.DeleteVolumeResponse{}, nil
 	}
+	if existingVol != nil {
+		auditRecordFromContext(ctx).setShareCRN(existingVol.CRN)
+	}
 
+	// For accessor shares the volume is the accessor share in this account, deleting it removes the binding
+	// and leaves the origin share intact.
 	//If volume exists no need to check for access point existence as library takes care of the same
 	volumeAccesspointReq := provider.VolumeAccessPointRequest{
 		VolumeID:      volume.VolumeID,
@@ -413,10 +494,10 @@ func (csiCS *CSIControllerServer) DeleteVolume(ctx context.Context, req *csi.Del
 This RPC call SHALL return confirmed only if all the volume capabilities specified in the request are supported.
 */
 func (csiCS *CSIControllerServer) ValidateVolumeCapabilities(ctx context.Context, req *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	// populate requestID in the context
 	ctx = context.WithValue(ctx, provider.RequestID, requestID)
-	ctxLogger.Info("CSIControllerServer-ValidateVolumeCapabilities", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-ValidateVolumeCapabilities", zap.Reflect("Request", redactSecrets(req)))
 
 	// Validate Arguments
 	if req.GetVolumeCapabilities() == nil || len(req.GetVolumeCapabilities()) == 0 {
@@ -427,17 +508,25 @@ func (csiCS *CSIControllerServer) ValidateVolumeCapabilities(ctx context.Context
 		return nil, commonError.GetCSIError(ctxLogger, commonError.EmptyVolumeID, requestID, nil)
 	}
 
-	//Volume ID is in format volumeID:accesspointID or volumeID#accesspointID
+	//Volume ID is in format volumeID:accesspointID or volumeID#accesspointID, volumeID#accesspointID#directory for sub directory volumes
 	tokens := getTokens(volumeID)
-	if len(tokens) != 2 {
+	if _, ok := parseSubDirectoryVolumeID(volumeID); len(tokens) != 2 && !ok {
 		ctxLogger.Info("CSIControllerServer-ValidateVolumeCapabilities...", zap.Reflect("Volume ID is not in format volumeID:accesspointID or volumeID#accesspointID", tokens))
 		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, nil)
 	}
 
+	target, err := requestAccountTarget(ctx, ctxLogger, requestID, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+	if err := csiCS.checkVolumeAccount(ctxLogger, requestID, volumeID, tokens[0], req.GetVolumeContext(), target); err != nil {
+		return nil, err
+	}
+
 	// Check if Requested Volume exists
-	session, err := csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
+	session, err := csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, commonError.InternalError, target)
 	if err != nil {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+		return nil, err
 	}
 
 	// Get volume details by using volume ID, it should exists with provider
@@ -464,15 +553,15 @@ func (csiCS *CSIControllerServer) ValidateVolumeCapabilities(ctx context.Context
 
 // ListVolumes is responsible for returning the information about all the volumes that it knows about.
 func (csiCS *CSIControllerServer) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	// populate requestID in the context
 	ctx = context.WithValue(ctx, provider.RequestID, requestID)
-	ctxLogger.Info("CSIControllerServer-ListVolumes...", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-ListVolumes...", zap.Reflect("Request", redactSecrets(req)))
 	defer metrics.UpdateDurationFromStart(ctxLogger, metrics.FunctionLabel("CSIListVolumes"), time.Now())
 
-	session, err := csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
+	session, err := csiCS.getProviderSession(ctx, ctxLogger, requestID, commonError.InternalError)
 	if err != nil {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+		return nil, err
 	}
 
 	maxEntries := int(req.MaxEntries)
@@ -512,7 +601,8 @@ It takes ControllerExpandVolumeRequest as input and creates a provider session t
 from provider-library. The function returns a csi ControllerExpandVolumeResponse if successful and error otherwise.
 */
 func (csiCS *CSIControllerServer) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	auditRecordFromContext(ctx).setRequestID(requestID)
 	// populate requestID in the context
 	_ = context.WithValue(ctx, provider.RequestID, requestID)
 	defer metrics.UpdateDurationFromStart(ctxLogger, "CSIExpandVolume", time.Now())
@@ -523,12 +613,10 @@ func (csiCS *CSIControllerServer) ControllerExpandVolume(ctx context.Context, re
 		return nil, commonError.GetCSIError(ctxLogger, commonError.EmptyVolumeID, requestID, nil)
 	}
 
-	// get the session
-	session, err := csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
-	if err != nil {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.FailedPrecondition, requestID, err)
+	// A sub directory has no size of its own, it grows within the capacity of the parent share
+	if _, ok := parseSubDirectoryVolumeID(volumeID); ok {
+		return &csi.ControllerExpandVolumeResponse{CapacityBytes: capacity, NodeExpansionRequired: false}, nil
 	}
-	requestedVolume := &provider.Volume{}
 
 	//Volume ID is in format volumeID:accesspointID or volumeID#accesspointID
 	tokens := getTokens(volumeID)
@@ -537,6 +625,22 @@ func (csiCS *CSIControllerServer) ControllerExpandVolume(ctx context.Context, re
 		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, nil)
 	}
 
+	// the controller-expand secret of the storage class
+	target, err := requestAccountTarget(ctx, ctxLogger, requestID, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+	if err := csiCS.checkVolumeAccount(ctxLogger, requestID, volumeID, tokens[0], nil, target); err != nil {
+		return nil, err
+	}
+
+	// get the session
+	session, err := csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, commonError.FailedPrecondition, target)
+	if err != nil {
+		return nil, err
+	}
+	requestedVolume := &provider.Volume{}
+
 	requestedVolume.VolumeID = tokens[0]
 	volDetail, err := checkIfVolumeExists(session, *requestedVolume, ctxLogger)
 
@@ -546,6 +650,7 @@ func (csiCS *CSIControllerServer) ControllerExpandVolume(ctx context.Context, re
 	} else if err != nil { // In case of other errors apart from volume not  found
 		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
 	}
+	auditRecordFromContext(ctx).setShareCRN(volDetail.CRN)
 
 	volumeExpansionReq := provider.ExpandVolumeRequest{
 		VolumeID: requestedVolume.VolumeID,
@@ -560,44 +665,45 @@ func (csiCS *CSIControllerServer) ControllerExpandVolume(ctx context.Context, re
 
 // ControllerPublishVolume ...
 func (csiCS *CSIControllerServer) ControllerPublishVolume(ctx context.Context, req *csi.ControllerPublishVolumeRequest) (*csi.ControllerPublishVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	// populate requestID in the context
 	_ = context.WithValue(ctx, provider.RequestID, requestID)
 
-	ctxLogger.Info("CSIControllerServer-ControllerPublishVolume", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-ControllerPublishVolume", zap.Reflect("Request", redactSecrets(req)))
 	return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnsupported, requestID, nil, "PublishVolume")
 }
 
 // ControllerUnpublishVolume ...
 func (csiCS *CSIControllerServer) ControllerUnpublishVolume(ctx context.Context, req *csi.ControllerUnpublishVolumeRequest) (*csi.ControllerUnpublishVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	// populate requestID in the context
 	_ = context.WithValue(ctx, provider.RequestID, requestID)
 
-	ctxLogger.Info("CSIControllerServer-ControllerUnpublishVolume", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-ControllerUnpublishVolume", zap.Reflect("Request", redactSecrets(req)))
 	return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnsupported, requestID, nil, "UnpublishVolume")
 }
 
 // GetCapacity ...
 func (csiCS *CSIControllerServer) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (*csi.GetCapacityResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	// populate requestID in the context
 	_ = context.WithValue(ctx, provider.RequestID, requestID)
 
-	ctxLogger.Info("CSIControllerServer-GetCapacity", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-GetCapacity", zap.Reflect("Request", redactSecrets(req)))
 	return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnimplemented, requestID, nil, "GetCapacity")
 }
 
 // CreateSnapshot ...
 func (csiCS *CSIControllerServer) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	auditRecordFromContext(ctx).setRequestID(requestID)
 	// populate requestID in the context
 	ctx = context.WithValue(ctx, provider.RequestID, requestID)
-	ctxLogger.Info("CSIControllerServer-CreateSnapshot... ", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-CreateSnapshot... ", zap.Reflect("Request", redactSecrets(req)))
 	defer metrics.UpdateDurationFromStart(ctxLogger, "CreateSnapshot", time.Now())
 
 	//Feature flag to enable/disable CreateSnapshot feature.
-	if strings.ToLower(os.Getenv("IS_SNAPSHOT_ENABLED")) == "false" {
+	if !csiCS.Driver.config.Get().SnapshotEnabled {
 		ctxLogger.Warn("CreateSnapshot functionality is disabled.")
 		time.Sleep(10 * time.Minute) //To avoid multiple retries from kubernetes to CSI Driver
 		return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnimplemented, requestID, nil, "CreateSnapshot functionality is disabled.")
@@ -613,6 +719,11 @@ func (csiCS *CSIControllerServer) CreateSnapshot(ctx context.Context, req *csi.C
 		return nil, commonError.GetCSIError(ctxLogger, commonError.MissingSourceVolumeID, requestID, nil)
 	}
 
+	// A snapshot of the parent share would hold all its sub directories
+	if subDirectory, ok := parseSubDirectoryVolumeID(sourceVolumeID); ok {
+		return nil, commonError.GetCSIError(ctxLogger, SubDirectorySnapshotNotSupported, requestID, nil, sourceVolumeID, subDirectory.Name, subDirectory.ShareID)
+	}
+
 	//Volume ID is in format volumeID#accesspointID
 	volumeID := getTokens(sourceVolumeID)
 	if len(volumeID) != 2 {
@@ -620,10 +731,19 @@ func (csiCS *CSIControllerServer) CreateSnapshot(ctx context.Context, req *csi.C
 		return nil, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, nil)
 	}
 
+	// the snapshotter secret of the volume snapshot class
+	target, err := requestAccountTarget(ctx, ctxLogger, requestID, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+	if err := csiCS.checkVolumeAccount(ctxLogger, requestID, sourceVolumeID, volumeID[0], nil, target); err != nil {
+		return nil, err
+	}
+
 	// Validate if Snapshot Already Exists
-	session, err := csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
+	session, err := csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, commonError.InternalError, target)
 	if err != nil {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+		return nil, err
 	}
 
 	snapshot, _ := session.GetSnapshotByName(snapshotName, volumeID[0]) // #nosec G104: Errors are intentionally not handled as we are checking for the presence of the expected output only.
@@ -652,11 +772,12 @@ func (csiCS *CSIControllerServer) CreateSnapshot(ctx context.Context, req *csi.C
 
 // DeleteSnapshot ...
 func (csiCS *CSIControllerServer) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	auditRecordFromContext(ctx).setRequestID(requestID)
 	// populate requestID in the context
 	ctx = context.WithValue(ctx, provider.RequestID, requestID)
 	defer metrics.UpdateDurationFromStart(ctxLogger, "DeleteSnapshot", time.Now())
-	ctxLogger.Info("CSIControllerServer-DeleteSnapshot... ", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-DeleteSnapshot... ", zap.Reflect("Request", redactSecrets(req)))
 
 	// Validate arguments
 	snapshotID := req.GetSnapshotId()
@@ -664,19 +785,31 @@ func (csiCS *CSIControllerServer) DeleteSnapshot(ctx context.Context, req *csi.D
 		return nil, commonError.GetCSIError(ctxLogger, commonError.EmptySnapshotID, requestID, nil)
 	}
 
+	target, err := requestAccountTarget(ctx, ctxLogger, requestID, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+
 	// get the session
-	session, err := csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
+	session, err := csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, commonError.InternalError, target)
 	if err != nil {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+		return nil, err
 	}
 
 	//snapshotID should always be in crn format --> crn:v1:staging:public:is:us-south-1:a/77f2bceddaeb577dcaddb4073fe82c1c::share-snapshot:r134-2ea54e55-4f34-4cad-aacc-88d712a19330/r134-2c65c897-4af9-4671-89ba-5a5939c35610
-	volumeID, snapshotID, _ := getVolumeSnapshotAndAccountIDsFromCRN(snapshotID)
+	volumeID, snapshotID, snapshotAccount := getVolumeSnapshotAndAccountIDsFromCRN(snapshotID)
 	if volumeID == "" {
 		// According to CSI Driver Sanity Tester, should succeed when an invalid snapshot id is used
 		ctxLogger.Warn("CSIControllerServer-DeleteSnapshot...", zap.Reflect("Snapshot ID is not in crn format, sourceVolumeID is mandatory", snapshotID))
 		return &csi.DeleteSnapshotResponse{}, nil
 	}
+	// the snapshot CRN names its account, a snapshot of another account would not be found and never deleted
+	if target.crossAccount() && snapshotAccount != target.AccountID {
+		return nil, commonError.GetCSIError(ctxLogger, VolumeAccountMismatch, requestID, nil, req.GetSnapshotId(), accountName(snapshotAccount), accountName(target.AccountID))
+	}
+	if err := csiCS.checkVolumeAccount(ctxLogger, requestID, req.GetSnapshotId(), volumeID, nil, target); err != nil {
+		return nil, err
+	}
 
 	snapshot := &provider.Snapshot{}
 	snapshot.SnapshotID = snapshotID
@@ -691,15 +824,19 @@ func (csiCS *CSIControllerServer) DeleteSnapshot(ctx context.Context, req *csi.D
 
 // ListSnapshots ...
 func (csiCS *CSIControllerServer) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	// populate requestID in the context
 	ctx = context.WithValue(ctx, provider.RequestID, requestID)
-	ctxLogger.Info("CSIControllerServer-ListSnapshots...", zap.Reflect("Request", req))
+	ctxLogger.Info("CSIControllerServer-ListSnapshots...", zap.Reflect("Request", redactSecrets(req)))
 	defer metrics.UpdateDurationFromStart(ctxLogger, metrics.FunctionLabel("ListSnapshots"), time.Now())
 
-	session, err := csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
+	target, err := requestAccountTarget(ctx, ctxLogger, requestID, req.GetSecrets())
 	if err != nil {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+		return nil, err
+	}
+	session, err := csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, commonError.InternalError, target)
+	if err != nil {
+		return nil, err
 	}
 
 	entries := []*csi.ListSnapshotsResponse_Entry{}
@@ -757,7 +894,7 @@ func (csiCS *CSIControllerServer) ListSnapshots(ctx context.Context, req *csi.Li
 
 // ControllerGetVolume ...
 func (csiCS *CSIControllerServer) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	// populate requestID in the context
 	_ = context.WithValue(ctx, provider.RequestID, requestID)
 	return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnimplemented, requestID, nil, "ControllerGetVolume")
@@ -765,6 +902,6 @@ func (csiCS *CSIControllerServer) ControllerGetVolume(ctx context.Context, req *
 
 // ControllerModifyVolume ...
 func (csiCS *CSIControllerServer) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (*csi.ControllerModifyVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
+	ctxLogger, requestID := getContextLogger(ctx, false)
 	return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnimplemented, requestID, nil, "ControllerModifyVolume")
 }
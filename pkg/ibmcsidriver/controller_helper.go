@@ -137,6 +137,7 @@ func getVolumeParameters(logger *zap.Logger, req *csi.CreateVolumeRequest, confi
 	var err error
 	var uid int
 	var gid int
+	var originShareCRN string
 	volume := &provider.Volume{}
 	volume.Name = &req.Name
 	volume.VPCVolume.AccessControlMode = SecurityGroup //Default mode is ENI/VNI
@@ -262,6 +263,13 @@ func getVolumeParameters(logger *zap.Logger, req *csi.CreateVolumeRequest, confi
 		case VMState:
 			// Accept vmState parameter - validation will be handled elsewhere
 			logger.Info("vmState parameter accepted", zap.String("value", value))
+		case PVCNameKey, PVCNamespaceKey, PVNameKey:
+			// csi-provisioner metadata, only used to post events against the PVC
+		case OriginShareCRN:
+			originShareCRN = strings.TrimSpace(value)
+			if len(originShareCRN) != 0 {
+				err = validateOriginShareCRN(originShareCRN)
+			}
 		default:
 			err = fmt.Errorf("<%s> is an invalid parameter", key)
 		}
@@ -357,6 +365,23 @@ func getVolumeParameters(logger *zap.Logger, req *csi.CreateVolumeRequest, confi
 		return volume, err
 	}
 
+	// Accessor shares inherit data, encryption, iops and ownership from the origin share and can only be
+	// mounted through file share targets with a virtual network interface
+	if len(originShareCRN) != 0 {
+		switch {
+		case volume.VPCVolume.AccessControlMode != SecurityGroup:
+			err = fmt.Errorf("ENI must be enabled i.e accessControlMode must be set to security_group for creating accessor shares of '%s'. Set 'isENIEnabled' to 'true' in storage class parameters", OriginShareCRN)
+		case isSnapshotRestore:
+			err = fmt.Errorf("'%s' cannot be combined with a volume content source", OriginShareCRN)
+		case volume.VPCVolume.VolumeEncryptionKey != nil || (volume.Iops != nil && len(strings.TrimSpace(*volume.Iops)) > 0) || volume.VPCVolume.Bandwidth > 0 || volume.InitialOwner != nil:
+			err = fmt.Errorf("encryptionKey, iops, throughput, uid and gid are inherited from the origin share; please remove them from the storage class using '%s'", OriginShareCRN)
+		}
+		if err != nil {
+			logger.Error("getVolumeParameters", zap.NamedError("InvalidParameter", err))
+			return volume, err
+		}
+	}
+
 	//TODO port the code from VPC BLOCK to find region if zone is given
 
 	// validate bandwidth for dp2 profile
@@ -592,6 +617,8 @@ func overrideParams(logger *zap.Logger, req *csi.CreateVolumeRequest, config *co
 			err = checkAndSetISENIEnabled(volume, key, strings.ToLower(value))
 		case IsEITEnabled:
 			err = checkAndSetISEITEnabled(volume, key, strings.ToLower(value))
+		case AccountAPIKey, AccountID, TargetVPCID, TargetVPCSubnetIDs:
+			// account and VPC target, see accountTargetFromSecrets
 		default:
 			err = fmt.Errorf("<%s> is an invalid parameter", key)
 		}
@@ -138,9 +138,14 @@ func TestAreVolumeCapabilitiesSupported(t *testing.T) {
 			volumeCap:     []*csi.VolumeCapability{{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER}}},
 			expectedValue: true,
 		},
+		{
+			testCaseName:  "Supported read-only volume capability",
+			volumeCap:     []*csi.VolumeCapability{{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY}}},
+			expectedValue: true,
+		},
 		{
 			testCaseName:  "Unsupported volume capability",
-			volumeCap:     []*csi.VolumeCapability{{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY}}},
+			volumeCap:     []*csi.VolumeCapability{{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER}}},
 			expectedValue: false,
 		},
 	}
@@ -427,6 +432,9 @@ func TestGetVolumeParameters(t *testing.T) {
 					IOPS:               noIops,
 					UID:                "2020",
 					GID:                "12345",
+					PVCNameKey:         "pvc-name",
+					PVCNamespaceKey:    "default",
+					PVNameKey:          "pvc-1234",
 				},
 			},
 			expectedVolume: &provider.Volume{Name: &volumeName,
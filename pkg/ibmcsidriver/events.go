@@ -0,0 +1,443 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+)
+
+const (
+	// SecurityGroupFallbackReason event reason when the cluster security group was not found and
+	// the VPC default security group is used for the file share target
+	SecurityGroupFallbackReason = "SecurityGroupFallback"
+
+	// eventMessageMaxLen events with a longer note are rejected by the API server
+	eventMessageMaxLen = 1024
+
+	// eventLookupTimeout bounds the PVC lookup and the wait for the PV cache to sync
+	eventLookupTimeout = 10 * time.Second
+
+	// eventQueueSize events waiting for their object to be resolved, further events are dropped
+	eventQueueSize = 256
+
+	// eventWorkers goroutines resolving the event objects and posting the events
+	eventWorkers = 2
+
+	// pvVolumeHandleIndex indexes the PVs of the driver by CSI volume handle
+	pvVolumeHandleIndex = "volumeHandle"
+
+	// pvFileShareIndex indexes the PVs of the driver by file share ID, the first token of the volume handle
+	pvFileShareIndex = "fileShareID"
+
+	// kubeletVolumeDataFile written by kubelet next to the staging path, it carries the PV name
+	kubeletVolumeDataFile = "vol_data.json"
+)
+
+// requestIDRegex matches the per request part of a CSI error message, it is
+// removed so that repeated failures of the same volume are deduplicated into one event
+var requestIDRegex = regexp.MustCompile(`RequestID:\s*[^,}]*,?\s*`)
+
+// volumeIDRequest is implemented by every CSI request that carries a volume ID
+type volumeIDRequest interface {
+	GetVolumeId() string
+}
+
+// volumeEvent is an event waiting for its object to be resolved, the PVC when
+// parameters are set and the PV of volumeID otherwise. path is the kubelet path
+// of a node request, the PV name is taken from it when PVs are not watched.
+type volumeEvent struct {
+	parameters map[string]string
+	volumeID   string
+	path       string
+	eventType  string
+	reason     string
+	message    string
+}
+
+// VolumeEventRecorder posts Kubernetes Events for volume failures against the
+// PVC (CreateVolume, using the csi-provisioner --extra-create-metadata parameters)
+// or the PV (every other RPC), so that application teams see them with kubectl
+// describe. The controller finds the PV by volume handle in a PV informer cache,
+// the node server reads the PV named in the kubelet path of the request, so that
+// nodes only need get on persistentvolumes. Resolving the object is
+// done by a fixed number of workers off the RPC path, events beyond the queue
+// size are dropped. Events go through the client-go event correlator, which
+// rate-limits them per object and folds repeats of the same failure into a
+// single event with a count. All methods are nil safe.
+type VolumeEventRecorder struct {
+	client     kubernetes.Interface
+	recorder   record.EventRecorder
+	driverName string
+	logger     *zap.Logger
+
+	pvInformer cache.SharedIndexInformer
+	queue      chan volumeEvent
+	stopOnce   sync.Once
+	stopCh     chan struct{}
+}
+
+// NewVolumeEventRecorder ... host is the node name reported as the event source, empty for the controller.
+// watchPVs runs the PV informer, only the controller needs it.
+func NewVolumeEventRecorder(client kubernetes.Interface, driverName, component, host string, watchPVs bool, logger *zap.Logger) *VolumeEventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: component, Host: host})
+	return newVolumeEventRecorder(client, recorder, driverName, watchPVs, logger)
+}
+
+func newVolumeEventRecorder(client kubernetes.Interface, recorder record.EventRecorder, driverName string, watchPVs bool, logger *zap.Logger) *VolumeEventRecorder {
+	r := &VolumeEventRecorder{
+		client:     client,
+		recorder:   recorder,
+		driverName: driverName,
+		logger:     logger,
+		queue:      make(chan volumeEvent, eventQueueSize),
+		stopCh:     make(chan struct{}),
+	}
+	if watchPVs {
+		r.pvInformer = coreinformers.NewPersistentVolumeInformer(client, 0, cache.Indexers{pvVolumeHandleIndex: r.volumeHandle, pvFileShareIndex: r.fileShareID})
+	}
+	return r
+}
+
+// Start runs the PV informer, if any, and the event workers until Stop is called
+func (r *VolumeEventRecorder) Start() {
+	if r == nil {
+		return
+	}
+	if r.pvInformer != nil {
+		go r.pvInformer.Run(r.stopCh)
+	}
+	for i := 0; i < eventWorkers; i++ {
+		go r.run()
+	}
+}
+
+// Stop stops the PV informer and the event workers, safe to call more than once
+func (r *VolumeEventRecorder) Stop() {
+	if r == nil {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+}
+
+// UnaryServerInterceptor posts a Warning event for every failed volume RPC. The
+// event reason is the classified error code and the note carries the error
+// description and the recommended action.
+func (r *VolumeEventRecorder) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil && r != nil {
+		if event, ok := failureEvent(info.FullMethod, req, err); ok {
+			r.enqueue(event)
+		}
+	}
+	return resp, err
+}
+
+// PVCEvent posts an event against the PVC a CreateVolume request is provisioning
+func (r *VolumeEventRecorder) PVCEvent(parameters map[string]string, eventType, reason, message string) {
+	if r == nil {
+		return
+	}
+	if parameters[PVCNameKey] == "" || parameters[PVCNamespaceKey] == "" {
+		r.logger.Debug("PVC metadata not passed by csi-provisioner, skipping event. Enable --extra-create-metadata on the provisioner to get PVC events.")
+		return
+	}
+	r.enqueue(volumeEvent{parameters: parameters, eventType: eventType, reason: reason, message: message})
+}
+
+// PVEvent posts an event against a PV of this driver, e.g. for node side repairs outside of an RPC
+func (r *VolumeEventRecorder) PVEvent(pv *v1.PersistentVolume, eventType, reason, message string) {
+	if r == nil || pv == nil {
+		return
+	}
+	ref, err := reference.GetReference(scheme.Scheme, pv)
+	if err != nil {
+		r.logger.Warn("Unable to get PV reference for event", zap.String("pv", pv.Name), zap.Error(err))
+		return
+	}
+	r.recorder.Event(ref, eventType, reason, truncateEventMessage(message))
+}
+
+// failureEvent returns the event for a failed RPC, false when the failure is not reported
+func failureEvent(method string, req interface{}, err error) (volumeEvent, bool) {
+	st := status.Convert(err)
+	// Aborted means another operation is in flight for the volume, it is retried and not a failure
+	if st.Code() == codes.Aborted || st.Code() == codes.OK {
+		return volumeEvent{}, false
+	}
+
+	event := volumeEvent{eventType: v1.EventTypeWarning}
+	switch request := req.(type) {
+	case *csi.CreateVolumeRequest:
+		if request.GetParameters()[PVCNameKey] == "" || request.GetParameters()[PVCNamespaceKey] == "" {
+			return volumeEvent{}, false
+		}
+		event.parameters = request.GetParameters()
+	case volumeIDRequest:
+		if request.GetVolumeId() == "" {
+			return volumeEvent{}, false
+		}
+		event.volumeID = request.GetVolumeId()
+		event.path = requestPath(req)
+	default:
+		return volumeEvent{}, false
+	}
+
+	event.reason = csiErrorCode(err)
+	if event.reason == "" {
+		event.reason = st.Code().String()
+	}
+	event.message = fmt.Sprintf("%s failed: %s", rpcName(method), requestIDRegex.ReplaceAllString(st.Message(), ""))
+	return event, true
+}
+
+// requestPath returns the kubelet path of a node request, the target path of a publish before its staging path
+func requestPath(req interface{}) string {
+	switch request := req.(type) {
+	case interface{ GetTargetPath() string }:
+		return request.GetTargetPath()
+	case interface{ GetVolumePath() string }:
+		return request.GetVolumePath()
+	case interface{ GetStagingTargetPath() string }:
+		return request.GetStagingTargetPath()
+	}
+	return ""
+}
+
+// enqueue hands the event to the workers without blocking the caller
+func (r *VolumeEventRecorder) enqueue(event volumeEvent) {
+	select {
+	case r.queue <- event:
+	default:
+		r.logger.Warn("Event queue is full, dropping event", zap.String("reason", event.reason), zap.String("volumeID", event.volumeID))
+	}
+}
+
+// run posts the queued events until Stop is called
+func (r *VolumeEventRecorder) run() {
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case event := <-r.queue:
+			r.post(event)
+		}
+	}
+}
+
+// post resolves the object of event and records it
+func (r *VolumeEventRecorder) post(event volumeEvent) {
+	var ref *v1.ObjectReference
+	switch {
+	case event.parameters != nil:
+		ref = r.pvcReference(event.parameters)
+	case r.pvInformer != nil:
+		ref = r.pvReference(event.volumeID)
+	default:
+		ref = r.pvReferenceByPath(event.volumeID, event.path)
+	}
+	if ref == nil {
+		return
+	}
+	r.recorder.Event(ref, event.eventType, event.reason, truncateEventMessage(event.message))
+}
+
+// pvcReference resolves the PVC from the csi-provisioner extra create metadata. The
+// PVC is read so that the event carries its UID, kubectl describe filters on it.
+func (r *VolumeEventRecorder) pvcReference(parameters map[string]string) *v1.ObjectReference {
+	name, namespace := parameters[PVCNameKey], parameters[PVCNamespaceKey]
+	ctx, cancel := context.WithTimeout(context.Background(), eventLookupTimeout)
+	defer cancel()
+	pvc, err := r.client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		r.logger.Warn("Unable to get PVC for event", zap.String("namespace", namespace), zap.String("name", name), zap.Error(err))
+		return nil
+	}
+	ref, err := reference.GetReference(scheme.Scheme, pvc)
+	if err != nil {
+		r.logger.Warn("Unable to get PVC reference for event", zap.String("namespace", namespace), zap.String("name", name), zap.Error(err))
+		return nil
+	}
+	return ref
+}
+
+// pvReference finds the PV of this driver whose volume handle is volumeID in the informer cache
+func (r *VolumeEventRecorder) pvReference(volumeID string) *v1.ObjectReference {
+	ctx, cancel := context.WithTimeout(context.Background(), eventLookupTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), r.pvInformer.HasSynced) {
+		r.logger.Warn("PV cache not synced, skipping event", zap.String("volumeID", volumeID))
+		return nil
+	}
+	pvs, err := r.pvInformer.GetIndexer().ByIndex(pvVolumeHandleIndex, volumeID)
+	if err != nil || len(pvs) == 0 {
+		r.logger.Debug("No PV found for volume, skipping event", zap.String("volumeID", volumeID), zap.Error(err))
+		return nil
+	}
+	pv, ok := pvs[0].(*v1.PersistentVolume)
+	if !ok {
+		return nil
+	}
+	ref, err := reference.GetReference(scheme.Scheme, pv)
+	if err != nil {
+		r.logger.Warn("Unable to get PV reference for event", zap.String("volumeID", volumeID), zap.Error(err))
+		return nil
+	}
+	return ref
+}
+
+// pvReferenceByPath reads the PV named in the kubelet path of a node request, it must be the PV of volumeID
+func (r *VolumeEventRecorder) pvReferenceByPath(volumeID, path string) *v1.ObjectReference {
+	name := kubeletPVName(path, r.driverName)
+	if name == "" {
+		r.logger.Debug("No PV name in the request path, skipping event", zap.String("volumeID", volumeID), zap.String("path", path))
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventLookupTimeout)
+	defer cancel()
+	pv, err := r.client.CoreV1().PersistentVolumes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		r.logger.Warn("Unable to get PV for event", zap.String("pv", name), zap.String("volumeID", volumeID), zap.Error(err))
+		return nil
+	}
+	if handles, _ := r.volumeHandle(pv); len(handles) == 0 || handles[0] != volumeID {
+		r.logger.Debug("PV is not the PV of the volume, skipping event", zap.String("pv", name), zap.String("volumeID", volumeID))
+		return nil
+	}
+	ref, err := reference.GetReference(scheme.Scheme, pv)
+	if err != nil {
+		r.logger.Warn("Unable to get PV reference for event", zap.String("volumeID", volumeID), zap.Error(err))
+		return nil
+	}
+	return ref
+}
+
+// kubeletPVName returns the PV name of a kubelet pod or staging path of driverName, empty when it is not one
+func kubeletPVName(path, driverName string) string {
+	if path == "" {
+		return ""
+	}
+	parts := strings.Split(filepath.Clean(path), string(filepath.Separator))
+	n := len(parts)
+	switch {
+	// pods/<pod uid>/volumes/kubernetes.io~csi/<pv name>/mount
+	case n >= 6 && parts[n-6] == "pods" && parts[n-4] == "volumes" && parts[n-3] == "kubernetes.io~csi" && parts[n-1] == "mount":
+		return parts[n-2]
+	// plugins/kubernetes.io/csi/pv/<pv name>/globalmount, used by kubelet before 1.24
+	case n >= 5 && parts[n-5] == "kubernetes.io" && parts[n-4] == "csi" && parts[n-3] == "pv" && parts[n-1] == "globalmount":
+		return parts[n-2]
+	// plugins/kubernetes.io/csi/<driver>/<sha256 of the volume handle>/globalmount
+	case n >= 5 && parts[n-5] == "kubernetes.io" && parts[n-4] == "csi" && parts[n-3] == driverName && parts[n-1] == "globalmount":
+		return stagedPVName(filepath.Dir(path), driverName)
+	}
+	return ""
+}
+
+// stagedPVName reads the PV name from the volume data kubelet writes to dir before staging a volume
+func stagedPVName(dir, driverName string) string {
+	data, err := os.ReadFile(filepath.Join(dir, kubeletVolumeDataFile)) // #nosec G304: path under the kubelet plugin directory
+	if err != nil {
+		return ""
+	}
+	volumeData := map[string]string{}
+	if err := json.Unmarshal(data, &volumeData); err != nil || volumeData["driverName"] != driverName {
+		return ""
+	}
+	return volumeData["specVolID"]
+}
+
+// volumeHandle index function, only the PVs of this driver are indexed
+func (r *VolumeEventRecorder) volumeHandle(obj interface{}) ([]string, error) {
+	pv, ok := obj.(*v1.PersistentVolume)
+	if !ok || pv.Spec.CSI == nil || pv.Spec.CSI.Driver != r.driverName {
+		return nil, nil
+	}
+	return []string{pv.Spec.CSI.VolumeHandle}, nil
+}
+
+// fileShareID index function, the sub directory volumes of a share are indexed by its ID as well
+func (r *VolumeEventRecorder) fileShareID(obj interface{}) ([]string, error) {
+	handles, err := r.volumeHandle(obj)
+	if len(handles) == 0 || err != nil {
+		return nil, err
+	}
+	return []string{getTokens(handles[0])[0]}, nil
+}
+
+// VolumeContext returns the volume attributes of a PV of this driver for the file share shareID,
+// false when no such PV is known
+func (r *VolumeEventRecorder) VolumeContext(shareID string) (map[string]string, bool) {
+	if r == nil || r.pvInformer == nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventLookupTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), r.pvInformer.HasSynced) {
+		r.logger.Warn("PV cache not synced, volume context unknown", zap.String("shareID", shareID))
+		return nil, false
+	}
+	pvs, err := r.pvInformer.GetIndexer().ByIndex(pvFileShareIndex, shareID)
+	if err != nil || len(pvs) == 0 {
+		return nil, false
+	}
+	pv, ok := pvs[0].(*v1.PersistentVolume)
+	if !ok {
+		return nil, false
+	}
+	return pv.Spec.CSI.VolumeAttributes, true
+}
+
+// rpcName returns the RPC name of a gRPC full method
+func rpcName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+func truncateEventMessage(message string) string {
+	if len(message) <= eventMessageMaxLen {
+		return message
+	}
+	return message[:eventMessageMaxLen-3] + "..."
+}
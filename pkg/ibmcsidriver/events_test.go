@@ -0,0 +1,323 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestEventRecorder(t *testing.T) (*VolumeEventRecorder, *record.FakeRecorder, *fake.Clientset) {
+	logger, teardown := GetTestLogger(t)
+	t.Cleanup(teardown)
+	registerDriverMessages()
+
+	client := fake.NewSimpleClientset(
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "app", UID: "pvc-uid"}},
+		&v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pvc-1234", UID: "pv-uid"},
+			Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: "vpc.file.csi.ibm.io", VolumeHandle: "share-1#target-1"},
+			}},
+		},
+		&v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-driver"},
+			Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+				CSI: &v1.CSIPersistentVolumeSource{Driver: "vpc.block.csi.ibm.io", VolumeHandle: "share-2#target-2"},
+			}},
+		},
+	)
+	fakeRecorder := record.NewFakeRecorder(10)
+	fakeRecorder.IncludeObject = true
+	events := newVolumeEventRecorder(client, fakeRecorder, "vpc.file.csi.ibm.io", true, logger)
+	events.Start()
+	t.Cleanup(events.Stop)
+	return events, fakeRecorder, client
+}
+
+func TestVolumeEventRecorderRecordFailure(t *testing.T) {
+	pvcParams := map[string]string{PVCNameKey: "data", PVCNamespaceKey: "app", PVNameKey: "pvc-1234"}
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	registerDriverMessages()
+
+	testcases := []struct {
+		testCaseName  string
+		method        string
+		req           interface{}
+		err           error
+		expectedEvent string
+	}{
+		{
+			testCaseName:  "CreateVolume failure posted on PVC",
+			method:        "/csi.v1.Controller/CreateVolume",
+			req:           &csi.CreateVolumeRequest{Name: "pvc-1234", Parameters: pvcParams},
+			err:           commonError.GetCSIError(logger, commonError.SubnetIDListNotFound, "req-1", nil),
+			expectedEvent: "Warning SubnetIDListNotFound CreateVolume failed: {Code: SubnetIDListNotFound, Description: Cluster subnet list",
+		},
+		{
+			testCaseName: "CreateVolume without provisioner metadata",
+			method:       "/csi.v1.Controller/CreateVolume",
+			req:          &csi.CreateVolumeRequest{Name: "pvc-1234"},
+			err:          commonError.GetCSIError(logger, commonError.SubnetIDListNotFound, "req-1", nil),
+		},
+		{
+			testCaseName:  "NodePublishVolume failure posted on PV",
+			method:        "/csi.v1.Node/NodePublishVolume",
+			req:           &csi.NodePublishVolumeRequest{VolumeId: "share-1#target-1"},
+			err:           commonError.GetCSIError(logger, StunnelSetupFailed, "req-2", errors.New("no free port"), "share-1#target-1"),
+			expectedEvent: "Warning StunnelSetupFailed NodePublishVolume failed: {Code: StunnelSetupFailed",
+		},
+		{
+			testCaseName:  "Error without code uses the gRPC code",
+			method:        "/csi.v1.Controller/DeleteVolume",
+			req:           &csi.DeleteVolumeRequest{VolumeId: "share-1#target-1"},
+			err:           status.Error(codes.Internal, "boom"),
+			expectedEvent: "Warning Internal DeleteVolume failed: boom",
+		},
+		{
+			testCaseName: "Volume of another driver",
+			method:       "/csi.v1.Node/NodePublishVolume",
+			req:          &csi.NodePublishVolumeRequest{VolumeId: "share-2#target-2"},
+			err:          status.Error(codes.Internal, "boom"),
+		},
+		{
+			testCaseName: "Aborted is not posted",
+			method:       "/csi.v1.Controller/DeleteVolume",
+			req:          &csi.DeleteVolumeRequest{VolumeId: "share-1#target-1"},
+			err:          status.Error(codes.Aborted, "operation in progress"),
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			events, fakeRecorder, _ := newTestEventRecorder(t)
+			if event, ok := failureEvent(testcase.method, testcase.req, testcase.err); ok {
+				events.post(event)
+			}
+			if testcase.expectedEvent == "" {
+				assert.Len(t, fakeRecorder.Events, 0)
+				return
+			}
+			assert.Len(t, fakeRecorder.Events, 1)
+			event := <-fakeRecorder.Events
+			assert.Contains(t, event, testcase.expectedEvent)
+			// the requestID is dropped so that repeated failures are deduplicated
+			assert.NotContains(t, event, "RequestID")
+		})
+	}
+}
+
+func TestVolumeEventRecorderObjectReference(t *testing.T) {
+	events, fakeRecorder, client := newTestEventRecorder(t)
+
+	// posted by the workers
+	events.PVCEvent(map[string]string{PVCNameKey: "data", PVCNamespaceKey: "app"}, v1.EventTypeWarning, SecurityGroupFallbackReason, "default security group used")
+	select {
+	case event := <-fakeRecorder.Events:
+		assert.Contains(t, event, "Warning SecurityGroupFallback default security group used")
+		assert.Contains(t, event, "kind=PersistentVolumeClaim")
+	case <-time.After(eventLookupTimeout):
+		t.Fatalf("PVC event was not posted")
+	}
+
+	pvcRef := events.pvcReference(map[string]string{PVCNameKey: "data", PVCNamespaceKey: "app"})
+	assert.NotNil(t, pvcRef)
+	assert.Equal(t, "app", pvcRef.Namespace)
+	assert.Equal(t, "data", pvcRef.Name)
+	assert.Equal(t, "pvc-uid", string(pvcRef.UID))
+	assert.Nil(t, events.pvcReference(map[string]string{PVCNameKey: "missing", PVCNamespaceKey: "app"}))
+
+	ref := events.pvReference("share-1#target-1")
+	assert.NotNil(t, ref)
+	assert.Equal(t, "PersistentVolume", ref.Kind)
+	assert.Equal(t, "pvc-1234", ref.Name)
+	assert.Nil(t, events.pvReference("share-2#target-2"))
+	assert.Nil(t, events.pvReference("unknown"))
+
+	// PVs created later are picked up by the informer
+	_, err := client.CoreV1().PersistentVolumes().Create(context.Background(), &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-5678"},
+		Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+			CSI: &v1.CSIPersistentVolumeSource{Driver: "vpc.file.csi.ibm.io", VolumeHandle: "share-3#target-3"},
+		}},
+	}, metav1.CreateOptions{})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return events.pvReference("share-3#target-3") != nil }, eventLookupTimeout, 10*time.Millisecond)
+}
+
+func TestVolumeEventRecorderNodePVLookup(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	registerDriverMessages()
+
+	kubeletDir := t.TempDir()
+	stagingDir := filepath.Join(kubeletDir, "plugins", "kubernetes.io", "csi", "vpc.file.csi.ibm.io", "abc")
+	assert.Nil(t, os.MkdirAll(stagingDir, 0750))
+	assert.Nil(t, os.WriteFile(filepath.Join(stagingDir, kubeletVolumeDataFile), []byte(`{"driverName":"vpc.file.csi.ibm.io","specVolID":"pvc-1234","volumeHandle":"share-1#target-1"}`), 0600))
+	podPath := func(pvName string) string {
+		return filepath.Join(kubeletDir, "pods", "pod-uid", "volumes", "kubernetes.io~csi", pvName, "mount")
+	}
+
+	testcases := []struct {
+		testCaseName  string
+		method        string
+		req           interface{}
+		expectedEvent bool
+	}{
+		{
+			testCaseName:  "PV named in the target path",
+			method:        "/csi.v1.Node/NodePublishVolume",
+			req:           &csi.NodePublishVolumeRequest{VolumeId: "share-1#target-1", TargetPath: podPath("pvc-1234"), StagingTargetPath: filepath.Join(stagingDir, "globalmount")},
+			expectedEvent: true,
+		},
+		{
+			testCaseName:  "PV named in the volume data of the staging path",
+			method:        "/csi.v1.Node/NodeStageVolume",
+			req:           &csi.NodeStageVolumeRequest{VolumeId: "share-1#target-1", StagingTargetPath: filepath.Join(stagingDir, "globalmount")},
+			expectedEvent: true,
+		},
+		{
+			testCaseName:  "PV named in a staging path of kubelet before 1.24",
+			method:        "/csi.v1.Node/NodeUnstageVolume",
+			req:           &csi.NodeUnstageVolumeRequest{VolumeId: "share-1#target-1", StagingTargetPath: filepath.Join(kubeletDir, "plugins", "kubernetes.io", "csi", "pv", "pvc-1234", "globalmount")},
+			expectedEvent: true,
+		},
+		{
+			testCaseName: "Staging path without volume data",
+			method:       "/csi.v1.Node/NodeStageVolume",
+			req:          &csi.NodeStageVolumeRequest{VolumeId: "share-1#target-1", StagingTargetPath: filepath.Join(kubeletDir, "plugins", "kubernetes.io", "csi", "vpc.file.csi.ibm.io", "def", "globalmount")},
+		},
+		{
+			testCaseName: "PV of another volume",
+			method:       "/csi.v1.Node/NodeGetVolumeStats",
+			req:          &csi.NodeGetVolumeStatsRequest{VolumeId: "share-9#target-9", VolumePath: podPath("pvc-1234")},
+		},
+		{
+			testCaseName: "PV of another driver",
+			method:       "/csi.v1.Node/NodeUnpublishVolume",
+			req:          &csi.NodeUnpublishVolumeRequest{VolumeId: "share-2#target-2", TargetPath: podPath("other-driver")},
+		},
+		{
+			testCaseName: "Unknown PV",
+			method:       "/csi.v1.Node/NodeUnpublishVolume",
+			req:          &csi.NodeUnpublishVolumeRequest{VolumeId: "share-1#target-1", TargetPath: podPath("missing")},
+		},
+		{
+			testCaseName: "Controller request without path",
+			method:       "/csi.v1.Controller/DeleteVolume",
+			req:          &csi.DeleteVolumeRequest{VolumeId: "share-1#target-1"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			client := fake.NewSimpleClientset(
+				&v1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{Name: "pvc-1234"},
+					Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+						CSI: &v1.CSIPersistentVolumeSource{Driver: "vpc.file.csi.ibm.io", VolumeHandle: "share-1#target-1"},
+					}},
+				},
+				&v1.PersistentVolume{
+					ObjectMeta: metav1.ObjectMeta{Name: "other-driver"},
+					Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+						CSI: &v1.CSIPersistentVolumeSource{Driver: "vpc.block.csi.ibm.io", VolumeHandle: "share-2#target-2"},
+					}},
+				},
+			)
+			fakeRecorder := record.NewFakeRecorder(10)
+			fakeRecorder.IncludeObject = true
+			events := newVolumeEventRecorder(client, fakeRecorder, "vpc.file.csi.ibm.io", false, logger)
+			assert.Nil(t, events.pvInformer)
+
+			event, ok := failureEvent(testcase.method, testcase.req, status.Error(codes.Internal, "boom"))
+			assert.True(t, ok)
+			events.post(event)
+			ref := events.pvReferenceByPath(event.volumeID, event.path)
+			if !testcase.expectedEvent {
+				assert.Len(t, fakeRecorder.Events, 0)
+				assert.Nil(t, ref)
+			} else if assert.Len(t, fakeRecorder.Events, 1) && assert.NotNil(t, ref) {
+				assert.Contains(t, <-fakeRecorder.Events, "Warning Internal")
+				assert.Equal(t, "pvc-1234", ref.Name)
+			}
+			// nodes only get PVs by name
+			for _, action := range client.Actions() {
+				assert.Equal(t, "get", action.GetVerb())
+			}
+			_, found := events.VolumeContext("share-1")
+			assert.False(t, found)
+		})
+	}
+}
+
+func TestVolumeEventRecorderQueueFull(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	fakeRecorder := record.NewFakeRecorder(1)
+	// not started, nothing drains the queue
+	events := newVolumeEventRecorder(fake.NewSimpleClientset(), fakeRecorder, "vpc.file.csi.ibm.io", true, logger)
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Internal, "boom")
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Node/NodePublishVolume"}
+	for i := 0; i < eventQueueSize+10; i++ {
+		_, err := events.UnaryServerInterceptor(context.Background(), &csi.NodePublishVolumeRequest{VolumeId: "share-1#target-1"}, info, handler)
+		assert.NotNil(t, err)
+	}
+	assert.Len(t, events.queue, eventQueueSize)
+}
+
+func TestVolumeEventRecorderNil(t *testing.T) {
+	var events *VolumeEventRecorder
+	events.PVCEvent(map[string]string{PVCNameKey: "data", PVCNamespaceKey: "app"}, v1.EventTypeWarning, SecurityGroupFallbackReason, "message")
+
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, status.Error(codes.Internal, "boom")
+	}
+	_, err := events.UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Node/NodePublishVolume"}, handler)
+	assert.True(t, called)
+	assert.NotNil(t, err)
+}
+
+func TestTruncateEventMessage(t *testing.T) {
+	assert.Equal(t, "short", truncateEventMessage("short"))
+	long := truncateEventMessage(string(make([]byte, 2*eventMessageMaxLen)))
+	assert.Len(t, long, eventMessageMaxLen)
+}
@@ -23,7 +23,6 @@ package ibmcsidriver
 
 import (
 	"os"
-	"strconv"
 
 	"go.uber.org/zap"
 )
@@ -52,20 +51,11 @@ func (f *opsSocketPermission) Chmod(name string, mode os.FileMode) error {
 }
 
 // setupSidecar updates owner/group and permission of the file given(addr)
-func setupSidecar(addr string, ops socketPermission, logger *zap.Logger) error {
-	groupSt := os.Getenv("SIDECAR_GROUP_ID")
+func setupSidecar(addr string, group int, ops socketPermission, logger *zap.Logger) error {
+	logger.Info("Setting owner and permissions of csi socket file. SIDECAR_GROUP_ID env or sidecarGroupID config must match the 'livenessprobe' sidecar container groupID for csi socket connection.", zap.Int("group", group))
 
-	logger.Info("Setting owner and permissions of csi socket file. SIDECAR_GROUP_ID env must match the 'livenessprobe' sidecar container groupID for csi socket connection.")
-
-	// If env is not set, set default to 0
-	if groupSt == "" {
-		logger.Warn("Unable to fetch SIDECAR_GROUP_ID environment variable. Sidecar container(s) might fail...")
-		groupSt = "0"
-	}
-
-	group, err := strconv.Atoi(groupSt)
-	if err != nil {
-		return err
+	if group == 0 {
+		logger.Warn("SIDECAR_GROUP_ID is not set, the csi socket stays owned by root. Sidecar container(s) might fail...")
 	}
 
 	// Change group of csi socket to non-root user for enabling the csi sidecar
@@ -20,7 +20,6 @@ package ibmcsidriver
 
 import (
 	"errors"
-	"os"
 	"testing"
 
 	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/ibmcsidriver/ibmcsidriverfakes"
@@ -30,7 +29,7 @@ import (
 func TestSetupSidecar(t *testing.T) {
 	tests := []struct {
 		name               string
-		groupID            string
+		groupID            int
 		expectedErr        bool
 		chownErr           error
 		chmodErr           error
@@ -40,7 +39,7 @@ func TestSetupSidecar(t *testing.T) {
 	}{
 		{
 			name:               "ValidGroupID",
-			groupID:            "2121",
+			groupID:            2121,
 			expectedErr:        false,
 			chownErr:           nil,
 			chmodErr:           nil,
@@ -49,18 +48,18 @@ func TestSetupSidecar(t *testing.T) {
 			expectedGroupID:    2121,
 		},
 		{
-			name:               "EmptyGroupID",
-			groupID:            "",
+			name:               "RootGroupID",
+			groupID:            0,
 			expectedErr:        false,
 			chownErr:           nil,
 			chmodErr:           nil,
 			expectedChownCalls: 1,
 			expectedChmodCalls: 1,
-			expectedGroupID:    0, // Default to 0 if SIDECAR_GROUP_ID is empty
+			expectedGroupID:    0, // SIDECAR_GROUP_ID not set
 		},
 		{
 			name:               "ChownError",
-			groupID:            "1000",
+			groupID:            1000,
 			expectedErr:        true,
 			chownErr:           errors.New("chown error"),
 			chmodErr:           nil,
@@ -70,7 +69,7 @@ func TestSetupSidecar(t *testing.T) {
 		},
 		{
 			name:               "ChmodError",
-			groupID:            "1000",
+			groupID:            1000,
 			expectedErr:        true,
 			chownErr:           nil,
 			chmodErr:           errors.New("chmod error"),
@@ -82,26 +81,6 @@ func TestSetupSidecar(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			// Set SIDECAR_GROUP_ID environment variable
-			if tc.groupID != "" {
-				err := os.Setenv("SIDECAR_GROUP_ID", tc.groupID)
-				if err != nil {
-					return
-				}
-
-			} else {
-				err := os.Unsetenv("SIDECAR_GROUP_ID")
-				if err != nil {
-					return
-				}
-			}
-			defer func() {
-				err := os.Unsetenv("SIDECAR_GROUP_ID")
-				if err != nil {
-					return
-				}
-			}()
-
 			// Create the fake object generated by counterfeiter
 			fakeSocketPermission := new(ibmcsidriverfakes.FakeSocketPermission)
 
@@ -114,7 +93,7 @@ func TestSetupSidecar(t *testing.T) {
 			defer teardown()
 
 			// Call the function under test
-			err := setupSidecar("/path/to/socket", fakeSocketPermission, logger)
+			err := setupSidecar("/path/to/socket", tc.groupID, fakeSocketPermission, logger)
 
 			// Verify the result
 			if tc.expectedErr {
@@ -0,0 +1,314 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	"go.uber.org/zap"
+)
+
+const (
+	// HealthCheckProviderConfig controller: VPC provider config and cluster ID are loaded
+	HealthCheckProviderConfig = "provider-config"
+
+	// HealthCheckProviderSession controller: a VPC provider session can be opened with the current credentials
+	HealthCheckProviderSession = "provider-session"
+
+	// HealthCheckCircuitBreaker controller: VPC calls are not being short-circuited
+	HealthCheckCircuitBreaker = "vpc-circuit-breaker"
+
+	// HealthCheckNodeMetadata node: zone, region and worker ID are known
+	HealthCheckNodeMetadata = "node-metadata"
+
+	// HealthCheckStunnel node: stunnel runs while tunnels exist and tunnel configs match the port allocation
+	HealthCheckStunnel = "stunnel"
+
+	// DefaultHealthCheckInterval results of the readiness checks are reused for this long
+	DefaultHealthCheckInterval = 10 * time.Second
+
+	// healthCheckTimeout bounds one round of readiness checks
+	healthCheckTimeout = 30 * time.Second
+)
+
+// errHealthCheckPending reported by readiness checks before their first round finished
+var errHealthCheckPending = errors.New("not checked yet")
+
+// HealthCheckResult ...
+type HealthCheckResult struct {
+	Name string `json:"name"`
+	// Liveness failing checks also fail /healthz, the others only affect readiness
+	Liveness bool   `json:"liveness"`
+	Healthy  bool   `json:"healthy"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HealthStatus ...
+type HealthStatus struct {
+	Alive     bool                `json:"alive"`
+	Ready     bool                `json:"ready"`
+	CheckedAt time.Time           `json:"checkedAt"`
+	Checks    []HealthCheckResult `json:"checks"`
+}
+
+// HealthChecker runs the deep health checks of the controller or node server
+// behind /healthz, /readyz and Probe. The liveness checks only look at local
+// state and run on every call. The readiness checks call VPC or stunnel, they
+// run in the background when their last result is older than the interval and
+// callers get the last result instead of waiting for them.
+type HealthChecker struct {
+	driver   *IBMCSIDriver
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu         sync.Mutex
+	readiness  []HealthCheckResult
+	readyAt    time.Time
+	refreshing bool
+	now        func() time.Time
+}
+
+// NewHealthChecker ...
+func NewHealthChecker(icDriver *IBMCSIDriver, interval time.Duration, logger *zap.Logger) *HealthChecker {
+	return &HealthChecker{
+		driver:   icDriver,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Check returns the liveness checks and the last result of the readiness checks, which are
+// refreshed in the background when that result is too old. Until the first round of readiness
+// checks finished the status is not ready.
+func (hc *HealthChecker) Check(ctx context.Context) HealthStatus {
+	status := hc.Liveness(ctx)
+
+	hc.mu.Lock()
+	readiness := hc.readiness
+	if readiness == nil {
+		for _, check := range hc.checks() {
+			if !check.liveness {
+				readiness = append(readiness, HealthCheckResult{Name: check.name, Error: errHealthCheckPending.Error()})
+			}
+		}
+	}
+	if !hc.refreshing && (hc.readiness == nil || hc.now().Sub(hc.readyAt) >= hc.interval) {
+		hc.refreshing = true
+		go hc.Refresh(context.Background())
+	}
+	hc.mu.Unlock()
+
+	for _, result := range readiness {
+		if !result.Healthy {
+			status.Ready = false
+		}
+		status.Checks = append(status.Checks, result)
+	}
+	return status
+}
+
+// Liveness runs only the liveness checks, Ready reflects them as well
+func (hc *HealthChecker) Liveness(ctx context.Context) HealthStatus {
+	status := HealthStatus{Alive: true, Ready: true, CheckedAt: hc.now()}
+	for _, check := range hc.checks() {
+		if !check.liveness {
+			continue
+		}
+		result := hc.run(ctx, check)
+		if !result.Healthy {
+			status.Alive = false
+			status.Ready = false
+		}
+		status.Checks = append(status.Checks, result)
+	}
+	return status
+}
+
+// Refresh runs one round of the readiness checks and keeps its result for Check
+func (hc *HealthChecker) Refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	readiness := []HealthCheckResult{}
+	for _, check := range hc.checks() {
+		if !check.liveness {
+			readiness = append(readiness, hc.run(ctx, check))
+		}
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.readiness = readiness
+	hc.readyAt = hc.now()
+	hc.refreshing = false
+}
+
+func (hc *HealthChecker) run(ctx context.Context, check healthCheck) HealthCheckResult {
+	result := HealthCheckResult{Name: check.name, Liveness: check.liveness, Healthy: true}
+	if err := check.run(ctx); err != nil {
+		result.Healthy = false
+		result.Error = err.Error()
+		hc.logger.Warn("Health check failed", zap.String("check", check.name), zap.Bool("liveness", check.liveness), zap.Error(err))
+	}
+	return result
+}
+
+type healthCheck struct {
+	name     string
+	liveness bool
+	run      func(ctx context.Context) error
+}
+
+// checks returns the controller and node checks of the services the driver mode runs
+func (hc *HealthChecker) checks() []healthCheck {
+	var checks []healthCheck
+	if hc.driver.mode.RunsController() {
+		checks = append(checks,
+			healthCheck{name: HealthCheckProviderConfig, liveness: true, run: hc.checkProviderConfig},
+			healthCheck{name: HealthCheckCircuitBreaker, liveness: false, run: hc.checkCircuitBreaker},
+			healthCheck{name: HealthCheckProviderSession, liveness: false, run: hc.checkProviderSession},
+		)
+	}
+	if hc.driver.mode.RunsNode() {
+		checks = append(checks,
+			healthCheck{name: HealthCheckNodeMetadata, liveness: true, run: hc.checkNodeMetadata},
+			healthCheck{name: HealthCheckStunnel, liveness: false, run: hc.checkStunnel},
+		)
+	}
+	return checks
+}
+
+func (hc *HealthChecker) checkProviderConfig(_ context.Context) error {
+	cs := hc.driver.cs
+	if cs == nil {
+		return errors.New("controller server is not initialized")
+	}
+	if err := validateProviderConfig(cs.CSIProvider); err != nil {
+		return err
+	}
+	if cs.CSIProvider.GetClusterID() == "" {
+		return errors.New("cluster ID is not set")
+	}
+	return nil
+}
+
+// validateProviderConfig checks the settings the controller needs to call the VPC APIs
+func validateProviderConfig(provider cloudProvider.CloudProviderInterface) error {
+	if provider == nil {
+		return errors.New("provider is not initialized")
+	}
+	if config := provider.GetConfig(); config == nil || config.VPC == nil {
+		return errors.New("VPC provider config is not loaded")
+	}
+	return nil
+}
+
+func (hc *HealthChecker) checkCircuitBreaker(_ context.Context) error {
+	if state := hc.driver.cs.Breaker.State(); state == CircuitOpen {
+		return fmt.Errorf("VPC provider circuit breaker is %s after %d consecutive failures", state, hc.driver.cs.Breaker.FailureThreshold())
+	}
+	return nil
+}
+
+// checkProviderSession opens (or reuses the cached) session without going
+// through the circuit breaker, so that health checks never use up its probe.
+// Reusing the cached session does not authenticate, so after VPC rejected its
+// token the credentials are verified with a cheap authenticated call until one
+// succeeds.
+func (hc *HealthChecker) checkProviderSession(ctx context.Context) error {
+	cs := hc.driver.cs
+	if cs.Sessions == nil {
+		if _, err := cs.CSIProvider.GetProviderSession(ctx, hc.logger); err != nil {
+			return fmt.Errorf("failed to open VPC provider session: %w", err)
+		}
+		return nil
+	}
+	session, err := cs.Sessions.Get(ctx, hc.logger)
+	if err != nil {
+		return fmt.Errorf("failed to open VPC provider session: %w", err)
+	}
+	if cs.Sessions.AuthFailure() == nil {
+		return nil
+	}
+	_, err = session.GetVolumeProfileByName(DP2Profile)
+	cs.Sessions.RecordResult(err)
+	if err := cs.Sessions.AuthFailure(); err != nil {
+		return fmt.Errorf("VPC rejected the provider session token: %w", err)
+	}
+	return nil
+}
+
+func (hc *HealthChecker) checkNodeMetadata(_ context.Context) error {
+	ns := hc.driver.ns
+	if ns == nil {
+		return errors.New("node server is not initialized")
+	}
+	metadata, err := ns.getNodeMetadata(hc.logger)
+	if err != nil {
+		return fmt.Errorf("failed to read node metadata: %w", err)
+	}
+	if metadata.GetWorkerID() == "" || metadata.GetZone() == "" || metadata.GetRegion() == "" {
+		return fmt.Errorf("incomplete node metadata, workerID: '%s', zone: '%s', region: '%s'", metadata.GetWorkerID(), metadata.GetZone(), metadata.GetRegion())
+	}
+	return nil
+}
+
+func (hc *HealthChecker) checkStunnel(_ context.Context) error {
+	ns := hc.driver.ns
+	if ns == nil || ns.StunnelMgr == nil {
+		// stunnel is only needed for RFS encryption in transit, NodePublishVolume reports its absence
+		return nil
+	}
+	return ns.StunnelMgr.HealthCheck()
+}
+
+// HealthzHandler serves liveness, 503 when a liveness check fails
+func (hc *HealthChecker) HealthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := hc.Check(r.Context())
+		hc.writeStatus(w, status, status.Alive)
+	})
+}
+
+// ReadyzHandler serves readiness, 503 when any check fails
+func (hc *HealthChecker) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := hc.Check(r.Context())
+		hc.writeStatus(w, status, status.Ready)
+	})
+}
+
+func (hc *HealthChecker) writeStatus(w http.ResponseWriter, status HealthStatus, healthy bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		hc.logger.Warn("Failed to write health status", zap.Error(err))
+	}
+}
@@ -0,0 +1,234 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	nodeMetadata "github.com/IBM/ibmcloud-volume-file-vpc/pkg/metadata"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	providerError "github.com/IBM/ibmcloud-volume-interface/lib/utils"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestHealthCheckerController(t *testing.T) {
+	testcases := []struct {
+		testCaseName   string
+		setup          func(icDriver *IBMCSIDriver)
+		expectedAlive  bool
+		expectedReady  bool
+		expectedFailed []string
+	}{
+		{
+			testCaseName:  "Healthy controller",
+			setup:         func(icDriver *IBMCSIDriver) {},
+			expectedAlive: true,
+			expectedReady: true,
+		},
+		{
+			testCaseName: "Missing cluster ID",
+			setup: func(icDriver *IBMCSIDriver) {
+				icDriver.cs.CSIProvider.(*cloudProvider.FakeIBMCloudStorageProvider).ClusterID = ""
+			},
+			expectedAlive:  false,
+			expectedReady:  false,
+			expectedFailed: []string{HealthCheckProviderConfig},
+		},
+		{
+			testCaseName: "Expired credentials",
+			setup: func(icDriver *IBMCSIDriver) {
+				cp := &countingProvider{err: errors.New("failed to get IAM token")}
+				icDriver.cs.Sessions = NewSessionCache(cp, time.Hour, icDriver.logger)
+			},
+			expectedAlive:  true,
+			expectedReady:  false,
+			expectedFailed: []string{HealthCheckProviderSession},
+		},
+		{
+			testCaseName: "Token rejected by VPC",
+			setup: func(icDriver *IBMCSIDriver) {
+				unauthorized := providerError.Message{Code: "Unauthorized", RC: 401, Type: providerError.Unauthenticated}
+				cp := &countingProvider{profileErr: unauthorized}
+				icDriver.cs.Sessions = NewSessionCache(cp, time.Hour, icDriver.logger)
+				icDriver.cs.Sessions.RecordResult(unauthorized)
+			},
+			expectedAlive:  true,
+			expectedReady:  false,
+			expectedFailed: []string{HealthCheckProviderSession},
+		},
+		{
+			testCaseName: "Token accepted again",
+			setup: func(icDriver *IBMCSIDriver) {
+				cp := &countingProvider{}
+				icDriver.cs.Sessions = NewSessionCache(cp, time.Hour, icDriver.logger)
+				icDriver.cs.Sessions.RecordResult(providerError.Message{Code: "Unauthorized", RC: 401, Type: providerError.Unauthenticated})
+			},
+			expectedAlive: true,
+			expectedReady: true,
+		},
+		{
+			testCaseName: "Circuit breaker open",
+			setup: func(icDriver *IBMCSIDriver) {
+				icDriver.cs.Breaker = NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute}, icDriver.logger)
+				icDriver.cs.Breaker.RecordResult(errors.New("connection refused"))
+			},
+			expectedAlive:  true,
+			expectedReady:  false,
+			expectedFailed: []string{HealthCheckCircuitBreaker},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			icDriver := initIBMCSIDriver(t)
+			testcase.setup(icDriver)
+
+			icDriver.HealthChecker().Refresh(context.Background())
+			status := icDriver.HealthChecker().Check(context.Background())
+			assert.Equal(t, testcase.expectedAlive, status.Alive)
+			assert.Equal(t, testcase.expectedReady, status.Ready)
+			assert.Equal(t, testcase.expectedFailed, failedChecks(status))
+
+			// Probe drives the livenessprobe sidecar and only reflects liveness
+			probe, err := icDriver.ids.Probe(context.Background(), &csi.ProbeRequest{})
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.expectedAlive, probe.GetReady().GetValue())
+		})
+	}
+}
+
+func TestHealthCheckerNode(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	icDriver.SetMode(ModeNode)
+	health := icDriver.HealthChecker()
+
+	// the readiness checks have not run yet
+	status := health.Check(context.Background())
+	assert.True(t, status.Alive)
+	assert.False(t, status.Ready)
+	assert.Equal(t, []string{HealthCheckStunnel}, failedChecks(status))
+
+	// the first Check refreshed them in the background
+	assert.Eventually(t, func() bool { return health.Check(context.Background()).Ready }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{HealthCheckNodeMetadata, HealthCheckStunnel}, checkNames(health.Check(context.Background())))
+
+	incomplete := &nodeMetadata.FakeNodeMetadata{}
+	incomplete.GetWorkerIDReturns("testworker")
+	incomplete.GetRegionReturns("testregion")
+	icDriver.ns.Metadata = incomplete
+
+	// liveness checks run on every call
+	status = health.Check(context.Background())
+	assert.False(t, status.Alive)
+	assert.False(t, status.Ready)
+	assert.Equal(t, []string{HealthCheckNodeMetadata}, failedChecks(status))
+	assert.False(t, health.Liveness(context.Background()).Alive)
+}
+
+func TestHealthCheckerReadinessInBackground(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	health := icDriver.HealthChecker()
+	health.Refresh(context.Background())
+	assert.True(t, health.Check(context.Background()).Ready)
+
+	// a hanging VPC call neither blocks Check nor Probe, the last result is served meanwhile
+	release := make(chan struct{})
+	defer close(release)
+	icDriver.cs.Sessions = NewSessionCache(&blockingProvider{release: release}, time.Hour, icDriver.logger)
+	health.now = func() time.Time { return time.Now().Add(time.Minute) }
+
+	done := make(chan HealthStatus)
+	go func() { done <- health.Check(context.Background()) }()
+	select {
+	case status := <-done:
+		assert.True(t, status.Ready)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Check waited for the readiness checks")
+	}
+
+	probe, err := icDriver.ids.Probe(context.Background(), &csi.ProbeRequest{})
+	assert.Nil(t, err)
+	assert.True(t, probe.GetReady().GetValue())
+}
+
+// blockingProvider opens sessions only after release is closed
+type blockingProvider struct {
+	countingProvider
+	release chan struct{}
+}
+
+func (bp *blockingProvider) GetProviderSession(ctx context.Context, logger *zap.Logger) (provider.Session, error) {
+	select {
+	case <-bp.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return bp.countingProvider.GetProviderSession(ctx, logger)
+}
+
+func TestHealthHandlers(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	icDriver.cs.Breaker = NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute}, icDriver.logger)
+	icDriver.cs.Breaker.RecordResult(errors.New("connection refused"))
+	health := icDriver.HealthChecker()
+	health.Refresh(context.Background())
+
+	// only readiness is affected by an open circuit
+	recorder := httptest.NewRecorder()
+	health.HealthzHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	health.ReadyzHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	status := HealthStatus{}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	assert.False(t, status.Ready)
+	assert.Equal(t, []string{HealthCheckCircuitBreaker}, failedChecks(status))
+}
+
+func failedChecks(status HealthStatus) []string {
+	var failed []string
+	for _, check := range status.Checks {
+		if !check.Healthy {
+			failed = append(failed, check.Name)
+		}
+	}
+	return failed
+}
+
+func checkNames(status HealthStatus) []string {
+	var names []string
+	for _, check := range status.Checks {
+		names = append(names, check.Name)
+	}
+	return names
+}
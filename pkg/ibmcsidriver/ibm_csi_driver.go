@@ -22,16 +22,19 @@ package ibmcsidriver
 import (
 	"context"
 	"fmt"
-	"os"
+	"time"
 
 	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
 	mountManager "github.com/IBM/ibm-csi-common/pkg/mountmanager"
 	"github.com/IBM/ibm-csi-common/pkg/utils"
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/driverconfig"
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
 	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/rfseit"
 	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
 	nodeMetadata "github.com/IBM/ibmcloud-volume-file-vpc/pkg/metadata"
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 // IBMCSIDriver ...
@@ -41,6 +44,21 @@ type IBMCSIDriver struct {
 	logger        *zap.Logger
 	region        string
 	rfsEnabled    bool
+	mode          DriverMode
+	config        *driverconfig.Store
+
+	circuitBreakerConfig CircuitBreakerConfig
+	sessionCacheTTL      time.Duration
+	mountHealthTimeout   time.Duration
+	volumeStatsConfig    VolumeStatsConfig
+	subDirectoryVolumes  bool
+	accountSessionOpener AccountSessionOpener
+	events               *VolumeEventRecorder
+	audit                *AuditLogger
+	health               *HealthChecker
+	shutdownDrainTimeout time.Duration
+	tlsConfig            TLSConfig
+	shutdownHooks        []func()
 
 	ids *CSIIdentityServer
 	ns  *CSINodeServer
@@ -53,7 +71,104 @@ type IBMCSIDriver struct {
 
 // GetIBMCSIDriver ...
 func GetIBMCSIDriver() *IBMCSIDriver {
-	return &IBMCSIDriver{}
+	return &IBMCSIDriver{
+		circuitBreakerConfig: CircuitBreakerConfig{
+			FailureThreshold: DefaultCircuitBreakerFailureThreshold,
+			OpenTimeout:      DefaultCircuitBreakerOpenTimeout,
+		},
+		sessionCacheTTL:    DefaultSessionCacheTTL,
+		mountHealthTimeout: DefaultMountHealthTimeout,
+		volumeStatsConfig: VolumeStatsConfig{
+			CacheTTL: DefaultVolumeStatsCacheTTL,
+			Timeout:  DefaultVolumeStatsTimeout,
+		},
+		shutdownDrainTimeout: DefaultShutdownDrainTimeout,
+	}
+}
+
+// SetMode selects the services and subsystems of this instance, must be called before SetupIBMCSIDriver.
+// Without it the mode is derived from the deprecated IS_NODE_SERVER environment variable
+func (icDriver *IBMCSIDriver) SetMode(mode DriverMode) {
+	icDriver.mode = mode
+}
+
+// Mode ...
+func (icDriver *IBMCSIDriver) Mode() DriverMode {
+	return icDriver.mode
+}
+
+// SetConfigStore sets the runtime settings, must be called before SetupIBMCSIDriver.
+// Without it the settings are read from the environment once during setup
+func (icDriver *IBMCSIDriver) SetConfigStore(config *driverconfig.Store) {
+	icDriver.config = config
+}
+
+// SetCircuitBreakerConfig overrides the VPC provider circuit breaker settings, must be called before SetupIBMCSIDriver
+func (icDriver *IBMCSIDriver) SetCircuitBreakerConfig(config CircuitBreakerConfig) {
+	icDriver.circuitBreakerConfig = config
+}
+
+// SetSessionCacheTTL overrides the maximum age of the cached provider session, 0 disables caching. Must be called before SetupIBMCSIDriver
+func (icDriver *IBMCSIDriver) SetSessionCacheTTL(ttl time.Duration) {
+	icDriver.sessionCacheTTL = ttl
+}
+
+// SetMountHealthTimeout overrides the time a mount may take to answer before the stale mount reconciler considers it stale, must be called before SetupIBMCSIDriver
+func (icDriver *IBMCSIDriver) SetMountHealthTimeout(timeout time.Duration) {
+	icDriver.mountHealthTimeout = timeout
+}
+
+// SetVolumeStatsConfig overrides the caching and timeout of NodeGetVolumeStats, must be called before SetupIBMCSIDriver
+func (icDriver *IBMCSIDriver) SetVolumeStatsConfig(config VolumeStatsConfig) {
+	icDriver.volumeStatsConfig = config
+}
+
+// SetSubDirectoryVolumes enables storage classes with parentVolumeHandle, whose volumes are directories of an existing share.
+// The controller mounts the parent share to create and delete them. Must be called before SetupIBMCSIDriver
+func (icDriver *IBMCSIDriver) SetSubDirectoryVolumes(enabled bool) {
+	icDriver.subDirectoryVolumes = enabled
+}
+
+// SetAccountSessionOpener enables cross-account provisioning with the API key in provisioner secrets, must be called before SetupIBMCSIDriver
+func (icDriver *IBMCSIDriver) SetAccountSessionOpener(opener AccountSessionOpener) {
+	icDriver.accountSessionOpener = opener
+}
+
+// InvalidateProviderSession drops the cached provider session, e.g. after the IBM Cloud credentials are rotated
+func (icDriver *IBMCSIDriver) InvalidateProviderSession(reason string) {
+	if icDriver.cs != nil {
+		icDriver.cs.Sessions.Invalidate(reason)
+	}
+}
+
+// SetEventRecorder enables Kubernetes Events for volume failures, must be called before Run
+func (icDriver *IBMCSIDriver) SetEventRecorder(events *VolumeEventRecorder) {
+	icDriver.events = events
+}
+
+// SetAuditLogger enables the audit records of mutating storage operations, must be called before Run
+func (icDriver *IBMCSIDriver) SetAuditLogger(audit *AuditLogger) {
+	icDriver.audit = audit
+}
+
+// SetShutdownDrainTimeout overrides how long in-flight RPCs may run after SIGTERM, must be called before Run
+func (icDriver *IBMCSIDriver) SetShutdownDrainTimeout(timeout time.Duration) {
+	icDriver.shutdownDrainTimeout = timeout
+}
+
+// AddShutdownHook runs hook after the in-flight RPCs drained on SIGTERM, must be called before Run
+func (icDriver *IBMCSIDriver) AddShutdownHook(hook func()) {
+	icDriver.shutdownHooks = append(icDriver.shutdownHooks, hook)
+}
+
+// SetTLSConfig enables TLS on tcp endpoints, must be called before Run
+func (icDriver *IBMCSIDriver) SetTLSConfig(config TLSConfig) {
+	icDriver.tlsConfig = config
+}
+
+// HealthChecker returns the checks behind /healthz and /readyz, nil before SetupIBMCSIDriver
+func (icDriver *IBMCSIDriver) HealthChecker() *HealthChecker {
+	return icDriver.health
 }
 
 // SetupIBMCSIDriver ...
@@ -75,41 +190,77 @@ func (icDriver *IBMCSIDriver) SetupIBMCSIDriver(provider cloudProvider.CloudProv
 
 	// Setup messaging
 	commonError.MessagesEn = commonError.InitMessages()
+	registerDriverMessages()
 
 	//icDriver.provider = provider
 	icDriver.name = name
 	icDriver.vendorVersion = vendorVersion
 
+	if icDriver.mode == "" {
+		icDriver.mode = legacyDriverMode()
+	}
+	icDriver.logger.Info("Driver mode", zap.String("mode", string(icDriver.mode)))
+	if icDriver.config == nil {
+		config, err := driverconfig.NewStore("", icDriver.logger)
+		if err != nil {
+			return fmt.Errorf("invalid driver configuration: %v", err)
+		}
+		icDriver.config = config
+	}
+	if icDriver.mode.RunsController() {
+		if err := validateProviderConfig(provider); err != nil {
+			return fmt.Errorf("invalid provider configuration for %s mode: %v", icDriver.mode, err)
+		}
+	}
+
 	// Adding Capabilities
+	// With the SINGLE_NODE_MULTI_WRITER capability the sidecars send SINGLE_NODE_MULTI_WRITER for RWO
+	// and SINGLE_NODE_SINGLE_WRITER for RWOP instead of SINGLE_NODE_WRITER
 	vcam := []csi.VolumeCapability_AccessMode_Mode{
-		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,      // RWO
-		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER, // RWX
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,        // RWO
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,   // ROX on a single node
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER, // RWOP
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,  // RWO
+		csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,    // ROX
+		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,   // RWX
 	}
 
 	_ = icDriver.AddVolumeCapabilityAccessModes(vcam) // #nosec G104: Attempt to AddVolumeCapabilityAccessModes only on best-effort basis. Error cannot be usefully handled.
-	csc := []csi.ControllerServiceCapability_RPC_Type{
-		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
-		//csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
-		csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
-		// csi.ControllerServiceCapability_RPC_GET_CAPACITY,
-		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
-		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
-		// csi.ControllerServiceCapability_RPC_PUBLISH_READONLY,
-		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+	if icDriver.mode.RunsController() {
+		csc := []csi.ControllerServiceCapability_RPC_Type{
+			csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
+			//csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
+			csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
+			// csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+			csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+			csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+			// csi.ControllerServiceCapability_RPC_PUBLISH_READONLY,
+			csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+			csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+		}
+		_ = icDriver.AddControllerServiceCapabilities(csc) // #nosec G104: Attempt to AddControllerServiceCapabilities only on best-effort basis. Error cannot be usefully handled.
 	}
-	_ = icDriver.AddControllerServiceCapabilities(csc) // #nosec G104: Attempt to AddControllerServiceCapabilities only on best-effort basis. Error cannot be usefully handled.
 
-	ns := []csi.NodeServiceCapability_RPC_Type{
-		//csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
-		csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
-		//csi.NodeServiceCapability_RPC_EXPAND_VOLUME,
+	if icDriver.mode.RunsNode() {
+		ns := []csi.NodeServiceCapability_RPC_Type{
+			csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
+			csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
+			csi.NodeServiceCapability_RPC_VOLUME_CONDITION,
+			csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+			csi.NodeServiceCapability_RPC_VOLUME_MOUNT_GROUP,
+			//csi.NodeServiceCapability_RPC_EXPAND_VOLUME,
+		}
+		_ = icDriver.AddNodeServiceCapabilities(ns) // #nosec G104: Attempt to AddNodeServiceCapabilities only on best-effort basis. Error cannot be usefully handled.
 	}
-	_ = icDriver.AddNodeServiceCapabilities(ns) // #nosec G104: Attempt to AddNodeServiceCapabilities only on best-effort basis. Error cannot be usefully handled.
 
 	// Set up CSI RPC Servers
 	icDriver.ids = NewIdentityServer(icDriver)
 	icDriver.ns = NewNodeServer(icDriver, mounter, statsUtil, metadata)
 	icDriver.cs = NewControllerServer(icDriver, provider)
+	if icDriver.subDirectoryVolumes && icDriver.mode.RunsController() {
+		icDriver.cs.SubDirectories = NewSubDirectoryManager(mounter, icDriver.logger)
+	}
+	icDriver.health = NewHealthChecker(icDriver, DefaultHealthCheckInterval, icDriver.logger)
 
 	icDriver.logger.Info("Successfully setup IBM CSI driver")
 
@@ -122,25 +273,21 @@ func (icDriver *IBMCSIDriver) SetupIBMCSIDriver(provider cloudProvider.CloudProv
 
 	// get the session
 	icDriver.rfsEnabled = false
-	session, err := provider.GetProviderSession(context.Background(), lgr)
+	session, err := icDriver.cs.getProviderSession(context.Background(), lgr, "", commonError.InternalError)
 	if err != nil {
 		icDriver.logger.Warn("Cannot fetch session for verifying RFS profile")
-		return nil
-	}
-
-	_, err = session.GetVolumeProfileByName(RFSProfile)
-	if err != nil {
+	} else if _, err = session.GetVolumeProfileByName(RFSProfile); err != nil {
 		icDriver.logger.Warn("RFS Profile is not accessible, please open support ticket on VPC for allowlisting. Restart of VPC FILE CSI Driver is required post allowlisting")
 	} else {
 		icDriver.rfsEnabled = true
 		icDriver.logger.Info("RFS profile is supported")
 	}
 
-	// Initialize tunnel manager gRPC client only for node servers (not controllers)
-	// Initialize stunnel manager for node server (works with stunnel sidecar)
-	if os.Getenv("IS_NODE_SERVER") == "true" {
+	// Initialize stunnel manager only for node servers and controllers of sub directory volumes (works with stunnel sidecar)
+	if icDriver.mode.RunsNode() || icDriver.cs.SubDirectories != nil {
 		// Create simple stunnel manager with hardcoded defaults
-		stunnelMgr, err := rfseit.NewStunnelManager(icDriver.logger)
+		config := icDriver.config.Get()
+		stunnelMgr, err := rfseit.NewStunnelManagerWithSettings(config.OSType, config.ClusterEnv, icDriver.logger)
 		if err != nil {
 			// Enhanced error logging with troubleshooting guidance
 			if icDriver.rfsEnabled {
@@ -149,7 +296,7 @@ func (icDriver *IBMCSIDriver) SetupIBMCSIDriver(provider cloudProvider.CloudProv
 					zap.Error(err),
 					zap.Bool("rfsEnabled", true),
 					zap.String("impact", "All RFS EIT profile mounts will fail at mount time"),
-					zap.String("action", "Check: 1) OS_TYPE env var is set correctly, 2) CLUSTER_ENV is set, 3) CA bundle file exists, 4) Restart node server pod to retry"))
+					zap.String("action", "Check: 1) OS_TYPE env var or osType config is set correctly, 2) CLUSTER_ENV or clusterEnv config is set, 3) CA bundle file exists, 4) Restart node server pod to retry"))
 			} else {
 				// RFS not enabled - only log warning
 				icDriver.logger.Warn("Failed to create stunnel manager - RFS EIT mounts will not work",
@@ -159,7 +306,21 @@ func (icDriver *IBMCSIDriver) SetupIBMCSIDriver(provider cloudProvider.CloudProv
 			}
 			icDriver.ns.StunnelMgr = nil
 		} else {
-			icDriver.ns.StunnelMgr = stunnelMgr
+			if icDriver.mode.RunsNode() {
+				icDriver.ns.StunnelMgr = stunnelMgr
+			}
+			if icDriver.cs.SubDirectories != nil {
+				// parent shares with stunnel encryption in transit are mounted through the stunnel sidecar of the controller
+				icDriver.cs.SubDirectories.tunnels = stunnelMgr
+			}
+			driverMetrics.SetActiveTunnelsFunc(stunnelMgr.ActiveTunnelCount)
+			icDriver.config.Subscribe(func(old, new driverconfig.Config) {
+				if old.ClusterEnv != new.ClusterEnv {
+					if err := stunnelMgr.SetClusterEnv(new.ClusterEnv); err != nil {
+						icDriver.logger.Warn("Failed to apply cluster environment to stunnel manager", zap.Error(err))
+					}
+				}
+			})
 			icDriver.logger.Info("Successfully initialized stunnel manager for node server with hardcoded defaults",
 				zap.String("servicesDir", rfseit.DefaultServicesDir),
 				zap.Int("basePort", rfseit.InitialPort),
@@ -168,7 +329,7 @@ func (icDriver *IBMCSIDriver) SetupIBMCSIDriver(provider cloudProvider.CloudProv
 				zap.String("note", "Works with stunnel sidecar container"))
 		}
 	} else {
-		icDriver.logger.Info("Skipping stunnel manager initialization (running as controller)")
+		icDriver.logger.Info("Skipping stunnel manager initialization", zap.String("mode", string(icDriver.mode)))
 	}
 
 	return nil
@@ -238,20 +399,28 @@ func NewIdentityServer(icDriver *IBMCSIDriver) *CSIIdentityServer {
 
 // NewNodeServer ...
 func NewNodeServer(icDriver *IBMCSIDriver, mounter mountManager.Mounter, statsUtil StatsUtils, nodeMetadata nodeMetadata.NodeMetadata) *CSINodeServer {
+	mountHealth := NewMountHealthChecker(icDriver.mountHealthTimeout, icDriver.logger)
 	return &CSINodeServer{
-		Driver:     icDriver,
-		Mounter:    mounter,
-		Stats:      statsUtil,
-		Metadata:   nodeMetadata,
-		StunnelMgr: nil, // Will be initialized in SetupIBMCSIDriver if IS_NODE_SERVER=true
+		Driver:          icDriver,
+		Mounter:         mounter,
+		Stats:           statsUtil,
+		Metadata:        nodeMetadata,
+		StunnelMgr:      nil, // Will be initialized in SetupIBMCSIDriver in node mode
+		MountHealth:     mountHealth,
+		VolumeStats:     NewVolumeStatsCache(icDriver.volumeStatsConfig, mountHealth, icDriver.logger),
+		remountReadOnly: bindRemountReadOnly,
 	}
 }
 
 // NewControllerServer ...
 func NewControllerServer(icDriver *IBMCSIDriver, provider cloudProvider.CloudProviderInterface) *CSIControllerServer {
 	return &CSIControllerServer{
-		Driver:      icDriver,
-		CSIProvider: provider,
+		Driver:         icDriver,
+		CSIProvider:    provider,
+		Breaker:        NewCircuitBreaker(icDriver.circuitBreakerConfig, icDriver.logger),
+		Sessions:       NewSessionCache(provider, icDriver.sessionCacheTTL, icDriver.logger),
+		Accounts:       NewAccountSessions(provider, icDriver.accountSessionOpener, icDriver.sessionCacheTTL, icDriver.logger),
+		AccessorShares: NewAccessorShareClient(provider.GetConfig()),
 	}
 }
 
@@ -261,11 +430,35 @@ func (icDriver *IBMCSIDriver) Run(endpoint string) {
 	icDriver.logger.Info("CSI Driver Name", zap.Reflect("Name", icDriver.name))
 
 	//Start the nonblocking GRPC
-	s := NewNonBlockingGRPCServer(icDriver.logger)
-	// TODO(#34): Only start specific servers based on a flag.
-	// In the future have this only run specific combinations of servers depending on which version this is.
-	// The schema for that was in util. basically it was just s.start but with some nil servers.
-
-	s.Start(endpoint, icDriver.ids, icDriver.cs, icDriver.ns)
+	var interceptors []grpc.UnaryServerInterceptor
+	if icDriver.events != nil {
+		interceptors = append(interceptors, icDriver.events.UnaryServerInterceptor)
+	}
+	if icDriver.audit != nil {
+		interceptors = append(interceptors, icDriver.audit.UnaryServerInterceptor)
+	}
+	s := NewNonBlockingGRPCServer(icDriver.logger, interceptors...)
+	s.SetGracefulShutdown(icDriver.shutdownDrainTimeout, append([]func(){icDriver.flushStunnelReload}, icDriver.shutdownHooks...)...)
+	s.SetTLSConfig(icDriver.tlsConfig)
+	s.SetNodeSidecars(icDriver.mode.RunsNode(), icDriver.config.Get().SidecarGroupID)
+	var cs csi.ControllerServer
+	if icDriver.mode.RunsController() {
+		cs = icDriver.cs
+	}
+	var ns csi.NodeServer
+	if icDriver.mode.RunsNode() {
+		ns = icDriver.ns
+	}
+	s.Start(endpoint, icDriver.ids, cs, ns)
 	s.Wait()
 }
+
+// flushStunnelReload sends a still debounced SIGHUP so stunnel picks up the configs written by the drained RPCs
+func (icDriver *IBMCSIDriver) flushStunnelReload() {
+	if icDriver.ns == nil || icDriver.ns.StunnelMgr == nil {
+		return
+	}
+	if err := icDriver.ns.StunnelMgr.FlushPendingSIGHUP("shutdown"); err != nil {
+		icDriver.logger.Warn("Failed to flush pending stunnel reload on shutdown", zap.Error(err))
+	}
+}
@@ -38,6 +38,7 @@ func initIBMCSIDriver(t *testing.T, fakeActions ...testingexec.FakeCommandAction
 	logger, teardown := cloudProvider.GetTestLogger(t)
 	defer teardown()
 	icDriver := GetIBMCSIDriver()
+	icDriver.SetMode(ModeAll)
 	// Create fake provider and mounter
 	provider, _ := cloudProvider.NewFakeIBMCloudStorageProvider("", logger)
 	var mounter mountManager.Mounter
@@ -23,9 +23,9 @@ import (
 	"context"
 
 	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
-	"github.com/IBM/ibm-csi-common/pkg/utils"
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // CSIIdentityServer ...
@@ -36,8 +36,8 @@ type CSIIdentityServer struct {
 
 // GetPluginInfo ...
 func (csiIdentity *CSIIdentityServer) GetPluginInfo(ctx context.Context, req *csi.GetPluginInfoRequest) (*csi.GetPluginInfoResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSIIdentityServer-GetPluginInfo...", zap.Reflect("Request", req))
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSIIdentityServer-GetPluginInfo...", zap.Reflect("Request", redactSecrets(req)))
 
 	if csiIdentity.Driver == nil {
 		return nil, commonError.GetCSIError(ctxLogger, commonError.DriverNotConfigured, requestID, nil)
@@ -51,18 +51,25 @@ func (csiIdentity *CSIIdentityServer) GetPluginInfo(ctx context.Context, req *cs
 
 // GetPluginCapabilities ...
 func (csiIdentity *CSIIdentityServer) GetPluginCapabilities(ctx context.Context, req *csi.GetPluginCapabilitiesRequest) (*csi.GetPluginCapabilitiesResponse, error) {
-	ctxLogger, _ := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSIIdentityServer-GetPluginCapabilities...", zap.Reflect("Request", req))
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSIIdentityServer-GetPluginCapabilities...", zap.Reflect("Request", redactSecrets(req)))
 
-	return &csi.GetPluginCapabilitiesResponse{
-		Capabilities: []*csi.PluginCapability{
-			{
-				Type: &csi.PluginCapability_Service_{
-					Service: &csi.PluginCapability_Service{
-						Type: csi.PluginCapability_Service_CONTROLLER_SERVICE,
-					},
+	if csiIdentity.Driver == nil {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.DriverNotConfigured, requestID, nil)
+	}
+
+	var capabilities []*csi.PluginCapability
+	if csiIdentity.Driver.mode.RunsController() {
+		capabilities = append(capabilities, &csi.PluginCapability{
+			Type: &csi.PluginCapability_Service_{
+				Service: &csi.PluginCapability_Service{
+					Type: csi.PluginCapability_Service_CONTROLLER_SERVICE,
 				},
 			},
+		})
+	}
+	return &csi.GetPluginCapabilitiesResponse{
+		Capabilities: append(capabilities, []*csi.PluginCapability{
 			{
 				Type: &csi.PluginCapability_Service_{
 					Service: &csi.PluginCapability_Service{
@@ -77,13 +84,24 @@ func (csiIdentity *CSIIdentityServer) GetPluginCapabilities(ctx context.Context,
 					},
 				},
 			}, */
-		},
+		}...),
 	}, nil
 }
 
 // Probe ...
 func (csiIdentity *CSIIdentityServer) Probe(ctx context.Context, req *csi.ProbeRequest) (*csi.ProbeResponse, error) {
-	ctxLogger, _ := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSIIdentityServer-Probe...", zap.Reflect("Request", req))
-	return &csi.ProbeResponse{}, nil
+	ctxLogger, _ := getContextLogger(ctx, false)
+	ctxLogger.Info("CSIIdentityServer-Probe...", zap.Reflect("Request", redactSecrets(req)))
+
+	// The livenessprobe sidecar restarts the plugin when Probe is not ready, so
+	// only the liveness checks count. Failures that a restart does not fix, e.g.
+	// an open VPC circuit breaker or a stopped stunnel, only affect /readyz and
+	// are not run here.
+	if csiIdentity.Driver != nil && csiIdentity.Driver.health != nil {
+		if status := csiIdentity.Driver.health.Liveness(ctx); !status.Alive {
+			ctxLogger.Warn("Liveness checks failed, reporting not ready", zap.Reflect("Checks", status.Checks))
+			return &csi.ProbeResponse{Ready: wrapperspb.Bool(false)}, nil
+		}
+	}
+	return &csi.ProbeResponse{Ready: wrapperspb.Bool(true)}, nil
 }
@@ -78,6 +78,12 @@ func TestGetPluginCapabilities(t *testing.T) {
 			t.Fatalf("Unknown capability: %v", capability.GetService().GetType())
 		}
 	}
+
+	// set driver as nil
+	icDriver.ids.Driver = nil
+	resp, err = icDriver.ids.GetPluginCapabilities(context.Background(), &csi.GetPluginCapabilitiesRequest{})
+	assert.NotNil(t, err)
+	assert.Nil(t, resp)
 }
 
 func TestProbe(t *testing.T) {
@@ -0,0 +1,168 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/IBM/ibm-csi-common/pkg/utils"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RequestIDMetadataKey incoming gRPC metadata carrying the request ID of the CO, it is reused in the driver logs
+const RequestIDMetadataKey = "x-request-id"
+
+// defaultRPCTimeout deadline of RPCs that arrive without one and have no entry in defaultRPCTimeouts
+const defaultRPCTimeout = 2 * time.Minute
+
+// defaultRPCTimeouts deadlines of RPCs that arrive without one, the controller RPCs wait for the VPC share and access point to become stable
+var defaultRPCTimeouts = map[string]time.Duration{
+	"CreateVolume":           5 * time.Minute,
+	"DeleteVolume":           5 * time.Minute,
+	"ControllerExpandVolume": 5 * time.Minute,
+	"CreateSnapshot":         5 * time.Minute,
+	"DeleteSnapshot":         5 * time.Minute,
+	"NodeStageVolume":        3 * time.Minute,
+	"NodeUnstageVolume":      3 * time.Minute,
+	"NodePublishVolume":      3 * time.Minute,
+	"NodeUnpublishVolume":    3 * time.Minute,
+	"NodeGetVolumeStats":     time.Minute,
+	"Probe":                  30 * time.Second,
+}
+
+type requestIDContextKey struct{}
+
+// propagateRequestID puts the request ID of the CO, or a new one, into the context so the access log and the handler log the same ID
+func propagateRequestID(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDMetadataKey); len(values) > 0 {
+			requestID = strings.TrimSpace(values[0])
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	return handler(context.WithValue(ctx, requestIDContextKey{}, requestID), req)
+}
+
+// requestIDFromContext ...
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// getContextLogger same as utils.GetContextLogger but keeps the request ID set by propagateRequestID
+func getContextLogger(ctx context.Context, isDebug bool) (*zap.Logger, string) {
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		return utils.GetContextLoggerWithRequestID(ctx, isDebug, &requestID)
+	}
+	return utils.GetContextLogger(ctx, isDebug)
+}
+
+// applyDefaultDeadline bounds RPCs the CO sent without a deadline
+func applyDefaultDeadline(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if _, ok := ctx.Deadline(); ok {
+		return handler(ctx, req)
+	}
+	timeout, ok := defaultRPCTimeouts[rpcName(info.FullMethod)]
+	if !ok {
+		timeout = defaultRPCTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return handler(ctx, req)
+}
+
+// logGRPC access log of every RPC with its latency and gRPC code, the request is only logged at debug level and without secrets
+func (s *nonBlockingGRPCServer) logGRPC(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	logger := s.logger.With(zap.String("method", info.FullMethod), zap.String("RequestID", requestIDFromContext(ctx)))
+	logger.Debug("GRPC request", zap.Reflect("Request", redactSecrets(req)))
+
+	resp, err := handler(ctx, req)
+	fields := []zap.Field{zap.Duration("latency", time.Since(start)), zap.String("code", status.Code(err).String())}
+	if err != nil {
+		logger.Error("GRPC call failed", append(fields, zap.Error(err))...)
+	} else {
+		logger.Info("GRPC call completed", fields...)
+	}
+	return resp, err
+}
+
+// recoverPanic turns a panic of the handler into an Internal error instead of crashing the driver
+func (s *nonBlockingGRPCServer) recoverPanic(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Recovered from panic in GRPC handler", zap.String("method", info.FullMethod),
+				zap.String("RequestID", requestIDFromContext(ctx)), zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+			resp, err = nil, status.Errorf(codes.Internal, "internal error while handling %s, RequestID: %s", rpcName(info.FullMethod), requestIDFromContext(ctx))
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// redactSecrets returns a copy of a CSI request with the values of its csi_secret fields masked, for logging
+func redactSecrets(req interface{}) interface{} {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return req
+	}
+	redacted := proto.Clone(msg)
+	redactMessage(redacted.ProtoReflect())
+	return redacted
+}
+
+// redactMessage ...
+func redactMessage(msg protoreflect.Message) {
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case proto.GetExtension(fd.Options(), csi.E_CsiSecret).(bool):
+			if fd.IsMap() {
+				secrets := value.Map()
+				secrets.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+					secrets.Set(key, protoreflect.ValueOfString(redactedValue))
+					return true
+				})
+			} else if fd.Kind() == protoreflect.StringKind {
+				msg.Set(fd, protoreflect.ValueOfString(redactedValue))
+			}
+		case fd.IsList() && fd.Kind() == protoreflect.MessageKind:
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				redactMessage(list.Get(i).Message())
+			}
+		case !fd.IsMap() && !fd.IsList() && fd.Kind() == protoreflect.MessageKind:
+			redactMessage(value.Message())
+		}
+		return true
+	})
+}
@@ -0,0 +1,216 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestPropagateRequestID(t *testing.T) {
+	testcases := []struct {
+		testCaseName      string
+		ctx               context.Context
+		expectedRequestID string
+	}{
+		{
+			testCaseName:      "Request ID from the CO",
+			ctx:               metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDMetadataKey, " co-request-1 ")),
+			expectedRequestID: "co-request-1",
+		},
+		{
+			testCaseName: "Generated request ID",
+			ctx:          context.Background(),
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			var requestID, loggerRequestID string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				requestID = requestIDFromContext(ctx)
+				_, loggerRequestID = getContextLogger(ctx, false)
+				return nil, nil
+			}
+			_, err := propagateRequestID(testcase.ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Controller/CreateVolume"}, handler)
+			assert.Nil(t, err)
+			assert.NotEmpty(t, requestID)
+			if testcase.expectedRequestID != "" {
+				assert.Equal(t, testcase.expectedRequestID, requestID)
+			}
+			// utils.GetContextLoggerWithRequestID appends a space to the returned ID
+			assert.Equal(t, requestID+" ", loggerRequestID)
+		})
+	}
+}
+
+func TestApplyDefaultDeadline(t *testing.T) {
+	callerCtx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	testcases := []struct {
+		testCaseName     string
+		ctx              context.Context
+		method           string
+		expectedDeadline time.Duration
+	}{
+		{
+			testCaseName:     "Per method deadline",
+			ctx:              context.Background(),
+			method:           "/csi.v1.Controller/CreateVolume",
+			expectedDeadline: defaultRPCTimeouts["CreateVolume"],
+		},
+		{
+			testCaseName:     "Default deadline",
+			ctx:              context.Background(),
+			method:           "/csi.v1.Node/NodeGetInfo",
+			expectedDeadline: defaultRPCTimeout,
+		},
+		{
+			testCaseName:     "Deadline of the CO is kept",
+			ctx:              callerCtx,
+			method:           "/csi.v1.Controller/CreateVolume",
+			expectedDeadline: time.Hour,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			var remaining time.Duration
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				deadline, ok := ctx.Deadline()
+				assert.True(t, ok)
+				remaining = time.Until(deadline)
+				return nil, nil
+			}
+			_, err := applyDefaultDeadline(testcase.ctx, nil, &grpc.UnaryServerInfo{FullMethod: testcase.method}, handler)
+			assert.Nil(t, err)
+			assert.InDelta(t, testcase.expectedDeadline, remaining, float64(time.Second))
+		})
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+	s := NewNonBlockingGRPCServer(logger).(*nonBlockingGRPCServer)
+	info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Node/NodePublishVolume"}
+
+	testcases := []struct {
+		testCaseName string
+		handler      grpc.UnaryHandler
+		expectedCode codes.Code
+	}{
+		{
+			testCaseName: "Handler panics",
+			handler: func(ctx context.Context, req interface{}) (interface{}, error) {
+				var volume *csi.Volume
+				return volume.VolumeId, nil
+			},
+			expectedCode: codes.Internal,
+		},
+		{
+			testCaseName: "Handler error is kept",
+			handler: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, status.Error(codes.NotFound, "volume not found")
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			testCaseName: "Handler succeeds",
+			handler: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return &csi.NodePublishVolumeResponse{}, nil
+			},
+			expectedCode: codes.OK,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			_, err := s.recoverPanic(context.Background(), nil, info, testcase.handler)
+			assert.Equal(t, testcase.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	req := &csi.CreateVolumeRequest{
+		Name:       "pvc-1",
+		Parameters: map[string]string{"profile": "dp2"},
+		Secrets:    map[string]string{"iam_api_key": "api-key-value"},
+	}
+
+	redacted := redactSecrets(req).(*csi.CreateVolumeRequest)
+	assert.Equal(t, map[string]string{"iam_api_key": redactedValue}, redacted.GetSecrets())
+	assert.Equal(t, req.GetParameters(), redacted.GetParameters())
+	// the request served to the handler is untouched
+	assert.Equal(t, "api-key-value", req.GetSecrets()["iam_api_key"])
+
+	stage := &csi.NodePublishVolumeRequest{VolumeId: "vol-1", Secrets: map[string]string{"key": "value"}}
+	assert.Equal(t, map[string]string{"key": redactedValue}, redactSecrets(stage).(*csi.NodePublishVolumeRequest).GetSecrets())
+
+	assert.Nil(t, redactSecrets(nil))
+	assert.Equal(t, "not a message", redactSecrets("not a message"))
+}
+
+func TestInterceptorChain(t *testing.T) {
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+
+	// extra interceptors, like the audit log, see the error recovered from a panic
+	var extraErr error
+	extra := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		extraErr = err
+		return resp, err
+	}
+	s := NewNonBlockingGRPCServer(logger, extra).(*nonBlockingGRPCServer)
+
+	var handlerRequestID string
+	info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Controller/DeleteVolume"}
+	var handler grpc.UnaryHandler = func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerRequestID = requestIDFromContext(ctx)
+		panic(errors.New("nil provider session"))
+	}
+	chain := s.unaryInterceptors()
+	for i := len(chain) - 1; i >= 0; i-- {
+		interceptor, next := chain[i], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDMetadataKey, "co-request-2"))
+	_, err := handler(ctx, &csi.DeleteVolumeRequest{VolumeId: "vol-1"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, err.Error(), "co-request-2")
+	assert.Equal(t, "co-request-2", handlerRequestID)
+	assert.Equal(t, codes.Internal, status.Code(extraErr))
+}
@@ -0,0 +1,224 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
+	"google.golang.org/grpc/codes"
+)
+
+// Message codes owned by this driver. They extend the common message table
+// from ibm-csi-common so that they can be returned through commonError.GetCSIError.
+const (
+	// BackendCircuitOpen ...
+	BackendCircuitOpen = "BackendCircuitOpen"
+
+	// StunnelSetupFailed ...
+	StunnelSetupFailed = "StunnelSetupFailed"
+
+	// VolumeStillPublished ...
+	VolumeStillPublished = "VolumeStillPublished"
+
+	// AccessorShareFailed ...
+	AccessorShareFailed = "AccessorShareFailed"
+
+	// VolumeAccountMismatch ...
+	VolumeAccountMismatch = "VolumeAccountMismatch"
+
+	// PublishReadOnlyMismatch ...
+	PublishReadOnlyMismatch = "PublishReadOnlyMismatch"
+
+	// PublishedVolumeMismatch ...
+	PublishedVolumeMismatch = "PublishedVolumeMismatch"
+
+	// MountOptionsNotAllowed ...
+	MountOptionsNotAllowed = "MountOptionsNotAllowed"
+
+	// InvalidVolumeMountGroup ...
+	InvalidVolumeMountGroup = "InvalidVolumeMountGroup"
+
+	// VolumeMountGroupFailed ...
+	VolumeMountGroupFailed = "VolumeMountGroupFailed"
+
+	// VolumeMountGroupConflict ...
+	VolumeMountGroupConflict = "VolumeMountGroupConflict"
+
+	// SubDirectoryVolumesDisabled ...
+	SubDirectoryVolumesDisabled = "SubDirectoryVolumesDisabled"
+
+	// SubDirectoryFailed ...
+	SubDirectoryFailed = "SubDirectoryFailed"
+
+	// SubDirectorySnapshotNotSupported ...
+	SubDirectorySnapshotNotSupported = "SubDirectorySnapshotNotSupported"
+
+	// MountAccessDenied ...
+	MountAccessDenied = "MountAccessDenied"
+
+	// MountConnectionTimedOut ...
+	MountConnectionTimedOut = "MountConnectionTimedOut"
+
+	// MountHelperTimedOut ...
+	MountHelperTimedOut = "MountHelperTimedOut"
+
+	// MountProtocolNotSupported ...
+	MountProtocolNotSupported = "MountProtocolNotSupported"
+
+	// MountStaleFileHandle ...
+	MountStaleFileHandle = "MountStaleFileHandle"
+
+	// MountReadOnlyFailed ...
+	MountReadOnlyFailed = "MountReadOnlyFailed"
+)
+
+// driverMessages ...
+var driverMessages = map[string]commonError.Message{
+	BackendCircuitOpen: {
+		Code:        BackendCircuitOpen,
+		Description: "VPC backend calls are temporarily suspended after %d consecutive failures, next retry allowed in %s",
+		Type:        codes.Unavailable,
+		Action:      "The VPC regional API appears to be degraded. The request will be retried automatically; check the IBM Cloud status page if the problem persists.",
+	},
+	StunnelSetupFailed: {
+		Code:        StunnelSetupFailed,
+		Description: "Failed to set up the encryption in transit tunnel for volume '%s'",
+		Type:        codes.Internal,
+		Action:      "Check that the stunnel sidecar of the ibm-vpc-file-csi-node pod on this node is running and review its logs. Restart the node server pod if the issue persists.",
+	},
+	VolumeStillPublished: {
+		Code:        VolumeStillPublished,
+		Description: "Volume '%s' cannot be unstaged, it is still published at '%s'",
+		Type:        codes.FailedPrecondition,
+		Action:      "The volume is unstaged after the pods using it on this node are deleted. Check the pods of the node that still mount the volume.",
+	},
+	AccessorShareFailed: {
+		Code:        AccessorShareFailed,
+		Description: "Failed to create an accessor share of origin share '%s'",
+		Type:        codes.Internal,
+		Action:      "Check that the origin share exists, that its account allows accessor bindings from this account and that 'g2_riaas_endpoint_url' is set in the storage-secret-store secret. Review the backend error for details.",
+	},
+	VolumeAccountMismatch: {
+		Code:        VolumeAccountMismatch,
+		Description: "'%s' belongs to %s, the request carries the credentials of %s",
+		Type:        codes.FailedPrecondition,
+		Action:      "Volumes of another account are expanded and snapshotted with the secret they were provisioned with. Set csi.storage.k8s.io/controller-expand-secret-name and csi.storage.k8s.io/controller-expand-secret-namespace in the storage class, and csi.storage.k8s.io/snapshotter-secret-name and csi.storage.k8s.io/snapshotter-secret-namespace in the volume snapshot class, to the provisioner secret. PVs provisioned before need spec.csi.controllerExpandSecretRef set to that secret.",
+	},
+	PublishReadOnlyMismatch: {
+		Code:        PublishReadOnlyMismatch,
+		Description: "Volume '%s' is already published at '%s' with read-only %t, the request asks for read-only %t",
+		Type:        codes.AlreadyExists,
+		Action:      "Check that the pod volume and the PV access modes agree on read-only access. Delete the pod so that the volume is published again with the requested setting.",
+	},
+	PublishedVolumeMismatch: {
+		Code:        PublishedVolumeMismatch,
+		Description: "Target path '%s' is already mounted from '%s', volume '%s' is mounted from '%s'",
+		Type:        codes.AlreadyExists,
+		Action:      "Another share is mounted at the pod target path. Check the mounts of the node and delete the pod so that the volume is published again.",
+	},
+	MountOptionsNotAllowed: {
+		Code:        MountOptionsNotAllowed,
+		Description: "The mount options of volume '%s' are not allowed",
+		Type:        codes.InvalidArgument,
+		Action:      "Fix the mountOptions of the storage class or PV. The driver defaults to nfsvers=4.1, hard and sec=sys, and sets the port of encryption in transit tunnels itself.",
+	},
+	InvalidVolumeMountGroup: {
+		Code:        InvalidVolumeMountGroup,
+		Description: "Volume mount group '%s' of volume '%s' is not a numeric group ID",
+		Type:        codes.InvalidArgument,
+		Action:      "Set a numeric fsGroup in the pod securityContext.",
+	},
+	VolumeMountGroupFailed: {
+		Code:        VolumeMountGroupFailed,
+		Description: "Failed to apply volume mount group '%d' to target path '%s'",
+		Type:        codes.Internal,
+		Action:      "The share root is changed to the fsGroup of the pod, this needs root access to the share. Check that the share is not mounted with root squashing, or create the volume with the uid and gid storage class parameters and run the pod without fsGroup.",
+	},
+	VolumeMountGroupConflict: {
+		Code:        VolumeMountGroupConflict,
+		Description: "Failed to apply volume mount group '%d' to target path '%s', the share root belongs to group '%d'",
+		Type:        codes.FailedPrecondition,
+		Action:      "The share root is given to the fsGroup of the first pod that mounts the volume and is not taken over by other groups. Run all pods of the volume with the same fsGroup, or run the pod without fsGroup and with the group of the share as supplemental group.",
+	},
+	SubDirectoryVolumesDisabled: {
+		Code:        SubDirectoryVolumesDisabled,
+		Description: "Sub directory volumes are not enabled on the controller",
+		Type:        codes.FailedPrecondition,
+		Action:      "Start the controller with --subdirectory-volumes, it needs to mount the parent share, or remove parentVolumeHandle and parentShareName from the storage class.",
+	},
+	SubDirectoryFailed: {
+		Code:        SubDirectoryFailed,
+		Description: "Failed to %s directory '%s' in parent share '%s'",
+		Type:        codes.Internal,
+		Action:      "Check that the controller can mount the parent share: the share target must be reachable from the controller node and the controller container must be allowed to mount. Parent shares with stunnel encryption in transit also need the stunnel sidecar in the controller pod.",
+	},
+	SubDirectorySnapshotNotSupported: {
+		Code:        SubDirectorySnapshotNotSupported,
+		Description: "Snapshots of sub directory volumes are not supported, volume '%s' is directory '%s' of parent share '%s'",
+		Type:        codes.InvalidArgument,
+		Action:      "VPC snapshots are taken of whole shares. Take the snapshot of the parent share, or use a storage class without parentVolumeHandle and parentShareName for volumes that need snapshots.",
+	},
+	MountAccessDenied: {
+		Code:        MountAccessDenied,
+		Description: "Failed to mount target, access denied by the NFS server.",
+		Type:        codes.PermissionDenied,
+		Action:      "The share target does not allow this worker node. Check that the share has a mount target in the VPC of the cluster and, for security group access, that the worker node is in a security group of the share target.",
+	},
+	MountConnectionTimedOut: {
+		Code:        MountConnectionTimedOut,
+		Description: "Failed to mount target, the NFS server could not be reached.",
+		Type:        codes.Unavailable,
+		Action:      "Check that the security groups of the share target and the worker node and the network ACL of the subnet allow TCP port 2049 between them.",
+	},
+	MountHelperTimedOut: {
+		Code:        MountHelperTimedOut,
+		Description: "Failed to mount target, the mount helper did not complete the encryption in transit mount in time.",
+		Type:        codes.Unavailable,
+		Action:      "Check that the mount helper utility on the worker node is running and its logs for the IPsec connection to the share target. The mount is retried.",
+	},
+	MountProtocolNotSupported: {
+		Code:        MountProtocolNotSupported,
+		Description: "Failed to mount target, the NFS version or transport protocol is not supported by the NFS server.",
+		Type:        codes.InvalidArgument,
+		Action:      "VPC file shares support NFS 4.1 over TCP. Remove vers, nfsvers and proto from the mountOptions of the storage class or PV, or set nfsvers=4.1.",
+	},
+	MountStaleFileHandle: {
+		Code:        MountStaleFileHandle,
+		Description: "Failed to mount target, stale NFS file handle.",
+		Type:        codes.FailedPrecondition,
+		Action:      "The share or its mount target was replaced while it was mounted on the node. Delete the pods using the volume on this node so that the stale mounts are removed, the next mount uses the current share.",
+	},
+	MountReadOnlyFailed: {
+		Code:        MountReadOnlyFailed,
+		Description: "Failed to make the encryption in transit mount at '%s' read-only, the share was unmounted again.",
+		Type:        codes.Internal,
+		Action:      "The mount is retried. If the failure persists, check the node server logs for the remount error.",
+	},
+}
+
+// registerDriverMessages adds the driver owned messages to the common message table.
+// Must be called after commonError.InitMessages.
+func registerDriverMessages() {
+	if commonError.MessagesEn == nil {
+		commonError.MessagesEn = commonError.InitMessages()
+	}
+	for code, msg := range driverMessages {
+		commonError.MessagesEn[code] = msg
+	}
+}
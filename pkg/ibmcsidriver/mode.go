@@ -0,0 +1,66 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"fmt"
+	"os"
+)
+
+// DriverMode selects the CSI services and background subsystems a driver instance runs
+type DriverMode string
+
+const (
+	// ModeController runs the identity and controller services, used by the csi-controller deployment
+	ModeController DriverMode = "controller"
+	// ModeNode runs the identity and node services and the stunnel manager, used by the csi-node daemonset
+	ModeNode DriverMode = "node"
+	// ModeAll runs every service, for local testing and csi-sanity
+	ModeAll DriverMode = "all"
+)
+
+// ParseDriverMode ... an empty mode falls back to the deprecated IS_NODE_SERVER environment variable
+func ParseDriverMode(mode string) (DriverMode, error) {
+	switch DriverMode(mode) {
+	case ModeController, ModeNode, ModeAll:
+		return DriverMode(mode), nil
+	case "":
+		return legacyDriverMode(), nil
+	}
+	return "", fmt.Errorf("invalid driver mode '%s', must be one of %s, %s or %s", mode, ModeController, ModeNode, ModeAll)
+}
+
+// legacyDriverMode derives the mode of deployments that do not pass --mode yet
+func legacyDriverMode() DriverMode {
+	if os.Getenv("IS_NODE_SERVER") == "true" {
+		return ModeNode
+	}
+	return ModeController
+}
+
+// RunsController ...
+func (m DriverMode) RunsController() bool {
+	return m == ModeController || m == ModeAll
+}
+
+// RunsNode ...
+func (m DriverMode) RunsNode() bool {
+	return m == ModeNode || m == ModeAll
+}
@@ -0,0 +1,174 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"testing"
+
+	mountManager "github.com/IBM/ibm-csi-common/pkg/mountmanager"
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	nodeMetadata "github.com/IBM/ibmcloud-volume-file-vpc/pkg/metadata"
+	nodeInfo "github.com/IBM/ibmcloud-volume-file-vpc/pkg/metadata/fake"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDriverMode(t *testing.T) {
+	testcases := []struct {
+		testCaseName       string
+		mode               string
+		isNodeServer       string
+		expectedMode       DriverMode
+		expectedController bool
+		expectedNode       bool
+		expectErr          bool
+	}{
+		{
+			testCaseName:       "Controller",
+			mode:               "controller",
+			isNodeServer:       "true",
+			expectedMode:       ModeController,
+			expectedController: true,
+		},
+		{
+			testCaseName: "Node",
+			mode:         "node",
+			expectedMode: ModeNode,
+			expectedNode: true,
+		},
+		{
+			testCaseName:       "All",
+			mode:               "all",
+			expectedMode:       ModeAll,
+			expectedController: true,
+			expectedNode:       true,
+		},
+		{
+			testCaseName: "Legacy node server",
+			isNodeServer: "true",
+			expectedMode: ModeNode,
+			expectedNode: true,
+		},
+		{
+			testCaseName:       "Legacy controller",
+			expectedMode:       ModeController,
+			expectedController: true,
+		},
+		{
+			testCaseName: "Invalid mode",
+			mode:         "Node",
+			expectErr:    true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			t.Setenv("IS_NODE_SERVER", testcase.isNodeServer)
+			mode, err := ParseDriverMode(testcase.mode)
+			assert.Equal(t, testcase.expectErr, err != nil)
+			assert.Equal(t, testcase.expectedMode, mode)
+			assert.Equal(t, testcase.expectedController, mode.RunsController())
+			assert.Equal(t, testcase.expectedNode, mode.RunsNode())
+		})
+	}
+}
+
+func TestSetupIBMCSIDriverModes(t *testing.T) {
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+
+	fakeNodeData := nodeMetadata.FakeNodeMetadata{}
+	fakeNodeInfo := nodeInfo.FakeNodeInfo{}
+	fakeNodeData.GetRegionReturns("testregion")
+	fakeNodeData.GetZoneReturns("testzone")
+	fakeNodeData.GetWorkerIDReturns("testworker")
+	fakeNodeInfo.NewNodeMetadataReturns(&fakeNodeData, nil)
+
+	testcases := []struct {
+		testCaseName                string
+		mode                        DriverMode
+		provider                    cloudProvider.CloudProviderInterface
+		expectErr                   bool
+		expectedControllerCaps      bool
+		expectedNodeCaps            bool
+		expectedControllerPluginCap bool
+	}{
+		{
+			testCaseName:                "Controller mode",
+			mode:                        ModeController,
+			expectedControllerCaps:      true,
+			expectedControllerPluginCap: true,
+		},
+		{
+			testCaseName:     "Node mode",
+			mode:             ModeNode,
+			expectedNodeCaps: true,
+		},
+		{
+			testCaseName:                "All mode",
+			mode:                        ModeAll,
+			expectedControllerCaps:      true,
+			expectedNodeCaps:            true,
+			expectedControllerPluginCap: true,
+		},
+		{
+			testCaseName: "Controller mode without VPC config",
+			mode:         ModeController,
+			provider:     &countingProvider{},
+			expectErr:    true,
+		},
+		{
+			testCaseName:     "Node mode does not need VPC config",
+			mode:             ModeNode,
+			provider:         &countingProvider{},
+			expectedNodeCaps: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			provider := testcase.provider
+			if provider == nil {
+				provider, _ = cloudProvider.NewFakeIBMCloudStorageProvider("", logger)
+			}
+			icDriver := GetIBMCSIDriver()
+			icDriver.SetMode(testcase.mode)
+			err := icDriver.SetupIBMCSIDriver(provider, mountManager.NewFakeNodeMounter(), &MockStatUtils{}, &fakeNodeData, &fakeNodeInfo, logger, "mydriver", "test-vendor-version-1.1.2")
+			assert.Equal(t, testcase.expectErr, err != nil)
+			if testcase.expectErr {
+				return
+			}
+			assert.Equal(t, testcase.mode, icDriver.Mode())
+			assert.Equal(t, testcase.expectedControllerCaps, len(icDriver.cscap) > 0)
+			assert.Equal(t, testcase.expectedNodeCaps, len(icDriver.nscap) > 0)
+
+			resp, err := icDriver.ids.GetPluginCapabilities(context.Background(), &csi.GetPluginCapabilitiesRequest{})
+			assert.Nil(t, err)
+			hasControllerService := false
+			for _, capability := range resp.GetCapabilities() {
+				if capability.GetService().GetType() == csi.PluginCapability_Service_CONTROLLER_SERVICE {
+					hasControllerService = true
+				}
+			}
+			assert.Equal(t, testcase.expectedControllerPluginCap, hasControllerService)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultMountHealthTimeout time a mount may take to answer a statfs before it is reported unresponsive
+const DefaultMountHealthTimeout = 5 * time.Second
+
+// Reasons of abnormal volume conditions, also used as metric labels
+const (
+	mountStale        = "StaleFileHandle"
+	mountIOError      = "IOError"
+	mountDisconnected = "NotConnected"
+	mountUnresponsive = "Unresponsive"
+)
+
+// MountHealthChecker probes NFS mounts in a separate goroutine so that a hung server can not block the caller.
+// A probe that does not return within the timeout keeps running in the background, further checks of the
+// same path report it unresponsive until it returns instead of starting another probe.
+type MountHealthChecker struct {
+	logger  *zap.Logger
+	timeout time.Duration
+	probe   func(path string) error
+
+	mu       sync.Mutex
+	inflight map[string]*mountProbe
+}
+
+// mountProbe statfs of one path, err is set before done is closed
+type mountProbe struct {
+	done chan struct{}
+	err  error
+}
+
+// NewMountHealthChecker returns a checker that probes mounts with statfs, which needs an answer of the NFS server
+func NewMountHealthChecker(timeout time.Duration, logger *zap.Logger) *MountHealthChecker {
+	return &MountHealthChecker{
+		logger:   logger,
+		timeout:  timeout,
+		probe:    statfs,
+		inflight: map[string]*mountProbe{},
+	}
+}
+
+func statfs(path string) error {
+	var st unix.Statfs_t
+	return unix.Statfs(path, &st)
+}
+
+// healthyVolumeCondition ...
+func healthyVolumeCondition() *csi.VolumeCondition {
+	return &csi.VolumeCondition{Abnormal: false, Message: "volume is healthy"}
+}
+
+// mountCondition returns the abnormal condition of the mount at path that is broken for reason
+func mountCondition(path, reason string, timeout time.Duration, err error) *csi.VolumeCondition {
+	if reason == mountUnresponsive {
+		return &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("%s: the NFS mount at %s did not answer within %s", reason, path, timeout)}
+	}
+	return &csi.VolumeCondition{Abnormal: true, Message: fmt.Sprintf("%s: the NFS mount at %s failed: %v", reason, path, err)}
+}
+
+// probeReason probes path and returns the reason the mount is broken, "" when it is not
+func (mc *MountHealthChecker) probeReason(ctx context.Context, path string) (string, error) {
+	timeout := time.NewTimer(mc.timeout)
+	defer timeout.Stop()
+
+	var err error
+	probe := mc.start(path)
+	select {
+	case <-probe.done:
+		err = probe.err
+	case <-timeout.C:
+		err = context.DeadlineExceeded
+	case <-ctx.Done():
+		err = context.DeadlineExceeded
+	}
+	return mountConditionReason(err), err
+}
+
+// forget drops a probe of path that is still running, e.g. once the hung mount was replaced, so that the next
+// check probes the new mount
+func (mc *MountHealthChecker) forget(path string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	delete(mc.inflight, path)
+}
+
+// start returns the probe of path, starting one unless it is still running
+func (mc *MountHealthChecker) start(path string) *mountProbe {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if probe, ok := mc.inflight[path]; ok {
+		return probe
+	}
+	probe := &mountProbe{done: make(chan struct{})}
+	mc.inflight[path] = probe
+	go func() {
+		probe.err = mc.probe(path)
+		mc.mu.Lock()
+		if mc.inflight[path] == probe {
+			delete(mc.inflight, path)
+		}
+		mc.mu.Unlock()
+		close(probe.done)
+	}()
+	return probe
+}
+
+// mountConditionReason classifies the probe error, errors that do not mean a broken mount return ""
+func mountConditionReason(err error) string {
+	switch {
+	case err == nil, os.IsNotExist(err):
+		return ""
+	case errors.Is(err, unix.ESTALE):
+		return mountStale
+	case errors.Is(err, unix.EIO):
+		return mountIOError
+	case errors.Is(err, unix.ENOTCONN):
+		return mountDisconnected
+	case errors.Is(err, context.DeadlineExceeded):
+		return mountUnresponsive
+	}
+	return ""
+}
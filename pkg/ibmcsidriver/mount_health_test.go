@@ -0,0 +1,124 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestMountHealthProbeReason(t *testing.T) {
+	testCases := []struct {
+		name      string
+		probeErr  error
+		expReason string
+	}{
+		{name: "Healthy mount", probeErr: nil},
+		{name: "Path does not exist", probeErr: &os.PathError{Op: "statfs", Path: "/x", Err: unix.ENOENT}},
+		{name: "Permission denied", probeErr: unix.EACCES},
+		{name: "Stale file handle", probeErr: &os.PathError{Op: "statfs", Path: "/x", Err: unix.ESTALE}, expReason: mountStale},
+		{name: "IO error", probeErr: fmt.Errorf("statfs: %w", unix.EIO), expReason: mountIOError},
+		{name: "Transport not connected", probeErr: unix.ENOTCONN, expReason: mountDisconnected},
+	}
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewMountHealthChecker(time.Second, logger)
+			checker.probe = func(string) error { return tc.probeErr }
+			reason, _ := checker.probeReason(context.Background(), "/x")
+			assert.Equal(t, tc.expReason, reason)
+		})
+	}
+}
+
+func TestMountHealthProbeHungMount(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	checker := NewMountHealthChecker(20*time.Millisecond, logger)
+	release := make(chan struct{})
+	var probes int32
+	checker.probe = func(string) error {
+		atomic.AddInt32(&probes, 1)
+		<-release
+		return nil
+	}
+
+	reason, _ := checker.probeReason(context.Background(), "/hung")
+	assert.Equal(t, mountUnresponsive, reason)
+	// the hung probe is reused instead of starting another goroutine per request
+	reason, _ = checker.probeReason(context.Background(), "/hung")
+	assert.Equal(t, mountUnresponsive, reason)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&probes))
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		checker.mu.Lock()
+		defer checker.mu.Unlock()
+		return len(checker.inflight) == 0
+	}, time.Second, 5*time.Millisecond)
+	reason, _ = checker.probeReason(context.Background(), "/hung")
+	assert.Equal(t, "", reason)
+}
+
+func TestMountCondition(t *testing.T) {
+	condition := mountCondition("/x", mountUnresponsive, time.Second, context.DeadlineExceeded)
+	assert.True(t, condition.Abnormal)
+	assert.Contains(t, condition.Message, "did not answer within 1s")
+
+	condition = mountCondition("/x", mountStale, time.Second, unix.ESTALE)
+	assert.True(t, condition.Abnormal)
+	assert.Contains(t, condition.Message, mountStale)
+	assert.Contains(t, condition.Message, unix.ESTALE.Error())
+}
+
+func TestNodeGetVolumeStatsAbnormalMount(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	icDriver.ns.Stats = &failingStatsUtils{err: unix.ESTALE}
+
+	resp, err := icDriver.ns.NodeGetVolumeStats(context.Background(), &csi.NodeGetVolumeStatsRequest{
+		VolumeId:   defaultVolumeID,
+		VolumePath: notBlockDevice,
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, resp.VolumeCondition) {
+		assert.True(t, resp.VolumeCondition.Abnormal)
+		assert.Contains(t, resp.VolumeCondition.Message, mountStale)
+	}
+	assert.Len(t, resp.Usage, 2)
+}
+
+// failingStatsUtils fails the FSInfo calls with err
+type failingStatsUtils struct {
+	MockStatUtils
+	err error
+}
+
+func (su *failingStatsUtils) FSInfo(path string) (int64, int64, int64, int64, int64, int64, error) {
+	return 0, 0, 0, 0, 0, 0, su.err
+}
@@ -0,0 +1,134 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mountOptionAliases options with several names, they are compared by their canonical name
+var mountOptionAliases = map[string]string{
+	"vers": "nfsvers",
+}
+
+// mountOptionFlagGroups flags that exclude each other are compared as values of one key
+var mountOptionFlagGroups = map[string]string{
+	"hard": "hard|soft",
+	"soft": "hard|soft",
+	"ro":   "ro|rw",
+	"rw":   "ro|rw",
+}
+
+var (
+	// nfsMountDefaults driver defaults of plain NFS mounts of dp2 and rfs shares
+	nfsMountDefaults = []string{"nfsvers=4.1", "hard", "sec=sys"}
+
+	// stunnelMountDefaults driver defaults of rfs shares mounted through the stunnel tunnel, which only
+	// forwards a single TCP port
+	stunnelMountDefaults = []string{"nfsvers=4.1", "proto=tcp", "hard", "sec=sys"}
+)
+
+// mountOption ...
+type mountOption struct {
+	raw   string
+	key   string
+	value string
+}
+
+func parseMountOption(option string) mountOption {
+	key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+	if canonical, ok := mountOptionAliases[key]; ok {
+		key = canonical
+	}
+	if group, ok := mountOptionFlagGroups[key]; ok {
+		key, value = group, key
+	}
+	return mountOption{raw: option, key: key, value: value}
+}
+
+// effectiveMountOptions merges the requested mount options of a share with the driver defaults of its profile
+// and encryption in transit mode, defaults are only added for options the request does not set. ro wins over
+// rw, other options that conflict with each other or can not work with the mount type are rejected.
+func effectiveMountOptions(profile string, eitEnabled bool, requested []string) ([]string, error) {
+	stunnel := profile == RFSProfile && eitEnabled
+
+	options := map[string]mountOption{}
+	effective := make([]string, 0, len(requested)+len(stunnelMountDefaults))
+	for _, raw := range requested {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		option := parseMountOption(raw)
+		if previous, ok := options[option.key]; ok {
+			// the driver adds ro to read-only mounts, it overrides an rw of the storage class
+			if option.key == mountOptionFlagGroups[readOnlyMountOption] {
+				if option.value == readOnlyMountOption && previous.value != readOnlyMountOption {
+					for i := range effective {
+						if effective[i] == previous.raw {
+							effective[i] = raw
+						}
+					}
+					options[option.key] = option
+				}
+				continue
+			}
+			if previous.value != option.value {
+				return nil, fmt.Errorf("conflicting mount options '%s' and '%s'", previous.raw, option.raw)
+			}
+			continue
+		}
+		if err := checkMountOption(option, eitEnabled, stunnel); err != nil {
+			return nil, err
+		}
+		options[option.key] = option
+		effective = append(effective, raw)
+	}
+
+	defaults := nfsMountDefaults
+	if stunnel {
+		defaults = stunnelMountDefaults
+	}
+	for _, raw := range defaults {
+		if _, ok := options[parseMountOption(raw).key]; !ok {
+			effective = append(effective, raw)
+		}
+	}
+	return effective, nil
+}
+
+// checkMountOption rejects options that break the mount of the share
+func checkMountOption(option mountOption, eitEnabled, stunnel bool) error {
+	switch option.key {
+	case "nfsvers":
+		if eitEnabled && !strings.HasPrefix(option.value, "4") {
+			return fmt.Errorf("mount option '%s' is not supported with encryption in transit, which requires NFS version 4", option.raw)
+		}
+	case "port":
+		if stunnel {
+			return fmt.Errorf("mount option '%s' is not allowed with encryption in transit, the driver sets the port of the stunnel tunnel", option.raw)
+		}
+	case "proto":
+		if stunnel && option.value != "tcp" {
+			return fmt.Errorf("mount option '%s' is not supported with encryption in transit, the stunnel tunnel only forwards TCP", option.raw)
+		}
+	}
+	return nil
+}
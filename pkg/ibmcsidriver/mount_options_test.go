@@ -0,0 +1,139 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveMountOptions(t *testing.T) {
+	testCases := []struct {
+		testCaseName string
+		profile      string
+		eitEnabled   bool
+		requested    []string
+		expOptions   []string
+		expErr       string
+	}{
+		{
+			testCaseName: "dp2 without options gets the defaults",
+			profile:      DP2Profile,
+			expOptions:   []string{"nfsvers=4.1", "hard", "sec=sys"},
+		},
+		{
+			testCaseName: "Storage class options are kept",
+			profile:      DP2Profile,
+			requested:    []string{"hard", "nfsvers=4.1", "sec=sys"},
+			expOptions:   []string{"hard", "nfsvers=4.1", "sec=sys"},
+		},
+		{
+			testCaseName: "Requested options override the defaults",
+			profile:      DP2Profile,
+			requested:    []string{"vers=4.2", "soft", "timeo=600"},
+			expOptions:   []string{"vers=4.2", "soft", "timeo=600", "sec=sys"},
+		},
+		{
+			testCaseName: "Duplicate options are dropped",
+			profile:      DP2Profile,
+			requested:    []string{"nfsvers=4.1", "vers=4.1", "hard", "hard"},
+			expOptions:   []string{"nfsvers=4.1", "hard", "sec=sys"},
+		},
+		{
+			testCaseName: "NFS version 3 without encryption in transit",
+			profile:      DP2Profile,
+			requested:    []string{"nfsvers=3"},
+			expOptions:   []string{"nfsvers=3", "hard", "sec=sys"},
+		},
+		{
+			testCaseName: "rfs with encryption in transit gets the stunnel defaults",
+			profile:      RFSProfile,
+			eitEnabled:   true,
+			requested:    []string{"vers=4.1"},
+			expOptions:   []string{"vers=4.1", "proto=tcp", "hard", "sec=sys"},
+		},
+		{
+			testCaseName: "Conflicting NFS versions",
+			profile:      DP2Profile,
+			requested:    []string{"nfsvers=4.1", "vers=4.2"},
+			expErr:       "conflicting mount options 'nfsvers=4.1' and 'vers=4.2'",
+		},
+		{
+			testCaseName: "Hard and soft",
+			profile:      DP2Profile,
+			requested:    []string{"hard", "soft"},
+			expErr:       "conflicting mount options 'hard' and 'soft'",
+		},
+		{
+			testCaseName: "Read-only overrides read-write",
+			profile:      DP2Profile,
+			requested:    []string{"rw", "timeo=600", "ro"},
+			expOptions:   []string{"ro", "timeo=600", "nfsvers=4.1", "hard", "sec=sys"},
+		},
+		{
+			testCaseName: "Read-write does not override read-only",
+			profile:      DP2Profile,
+			requested:    []string{"ro", "rw"},
+			expOptions:   []string{"ro", "nfsvers=4.1", "hard", "sec=sys"},
+		},
+		{
+			testCaseName: "Port under stunnel",
+			profile:      RFSProfile,
+			eitEnabled:   true,
+			requested:    []string{"port=2049"},
+			expErr:       "mount option 'port=2049' is not allowed with encryption in transit",
+		},
+		{
+			testCaseName: "UDP under stunnel",
+			profile:      RFSProfile,
+			eitEnabled:   true,
+			requested:    []string{"proto=udp"},
+			expErr:       "mount option 'proto=udp' is not supported with encryption in transit",
+		},
+		{
+			testCaseName: "NFS version 3 with encryption in transit",
+			profile:      DP2Profile,
+			eitEnabled:   true,
+			requested:    []string{"nfsvers=3"},
+			expErr:       "mount option 'nfsvers=3' is not supported with encryption in transit",
+		},
+		{
+			testCaseName: "Port without stunnel",
+			profile:      RFSProfile,
+			requested:    []string{"port=2049"},
+			expOptions:   []string{"port=2049", "nfsvers=4.1", "hard", "sec=sys"},
+		},
+	}
+
+	for _, testcase := range testCases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			options, err := effectiveMountOptions(testcase.profile, testcase.eitEnabled, testcase.requested)
+			if testcase.expErr != "" {
+				if assert.NotNil(t, err) {
+					assert.Contains(t, err.Error(), testcase.expErr)
+				}
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, testcase.expOptions, options)
+		})
+	}
+}
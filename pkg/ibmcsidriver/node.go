@@ -20,11 +20,15 @@
 package ibmcsidriver
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 
 	"time"
 
@@ -35,8 +39,10 @@ import (
 	"github.com/IBM/ibm-csi-common/pkg/mountmanager"
 	"github.com/IBM/ibm-csi-common/pkg/utils"
 	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/rfseit"
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/tracing"
 	nodeMetadata "github.com/IBM/ibmcloud-volume-file-vpc/pkg/metadata"
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"golang.org/x/sys/unix"
 	"k8s.io/kubernetes/pkg/volume/util/fs"
@@ -50,6 +56,14 @@ type CSINodeServer struct {
 	Metadata   nodeMetadata.NodeMetadata
 	Stats      StatsUtils
 	StunnelMgr *rfseit.StunnelManager
+	// MountHealth detects stale and hung NFS mounts for the stale mount reconciler and NodeGetVolumeStats
+	MountHealth *MountHealthChecker
+	// VolumeStats runs the timed NodeGetVolumeStats statfs and caches the usage per share
+	VolumeStats *VolumeStatsCache
+	// remountReadOnly makes the mount at a path read-only, the IPsec mount helper takes no mount options
+	remountReadOnly func(path string) error
+	// metadataMu serialises the lazy initialisation of Metadata
+	metadataMu sync.Mutex
 	// TODO: Only lock mutually exclusive calls and make locking more fine grained
 	mutex utils.LockStore
 	csi.UnimplementedNodeServer
@@ -81,6 +95,8 @@ const (
 	eitFsType = "ibmshare"
 	// file system type for NFS version 4 (required for stunnel)
 	nfs4FsType = "nfs4"
+	// mount option of read-only mounts
+	readOnlyMountOption = "ro"
 )
 
 // NFSSource represents a parsed NFS source with server and export path
@@ -214,8 +230,8 @@ var _ csi.NodeServer = &CSINodeServer{}
 
 // NodePublishVolume ...
 func (csiNS *CSINodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSINodeServer-NodePublishVolume...", zap.Reflect("Request", req))
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSINodeServer-NodePublishVolume...", zap.Reflect("Request", redactSecrets(req)))
 	defer metrics.UpdateDurationFromStart(ctxLogger, "NodePublishVolume", time.Now())
 
 	volumeID := req.GetVolumeId()
@@ -223,8 +239,9 @@ func (csiNS *CSINodeServer) NodePublishVolume(ctx context.Context, req *csi.Node
 		return nil, commonError.GetCSIError(ctxLogger, commonError.EmptyVolumeID, requestID, nil)
 	}
 
-	source := req.GetVolumeContext()[NFSServerPath]
-	if len(source) == 0 {
+	// The share is mounted at the staging path by NodeStageVolume, without it the share is mounted directly at the target path
+	stagingPath := req.GetStagingTargetPath()
+	if len(stagingPath) == 0 && len(req.GetVolumeContext()[NFSServerPath]) == 0 {
 		return nil, commonError.GetCSIError(ctxLogger, commonError.NoStagingTargetPath, requestID, nil)
 	}
 
@@ -244,6 +261,13 @@ func (csiNS *CSINodeServer) NodePublishVolume(ctx context.Context, req *csi.Node
 		return nil, commonError.GetCSIError(ctxLogger, commonError.VolumeCapabilitiesNotSupported, requestID, nil)
 	}
 
+	readOnly := req.GetReadonly() || isReadOnlyAccessMode(volumeCapability)
+
+	mountGroup, err := parseVolumeMountGroup(volumeCapability)
+	if err != nil {
+		return nil, commonError.GetCSIError(ctxLogger, InvalidVolumeMountGroup, requestID, err, volumeCapability.GetMount().GetVolumeMountGroup(), volumeID)
+	}
+
 	// Check if targetPath is already mounted. If it already moounted return OK
 	notMounted, err := csiNS.Mounter.IsLikelyNotMountPoint(target)
 	if err != nil && !os.IsNotExist(err) {
@@ -253,19 +277,189 @@ func (csiNS *CSINodeServer) NodePublishVolume(ctx context.Context, req *csi.Node
 	}
 	// Its OK if IsLikelyNotMountPoint returns PathNotExists error
 	if !notMounted {
-		// The  target Path is already mounted, Retrun OK
-		/* TODO
-		1) Target Path MUST be the vol referenced by vol ID
-		2) Check volume capability matches for ALREADY_EXISTS
-		3) Readonly MUST match
-		*/
+		// The target path is already mounted, it is reused when it is the mount this request would create
+		if err := csiNS.checkPublishedMount(ctxLogger, requestID, volumeID, req.GetVolumeContext(), stagingPath, target, readOnly); err != nil {
+			return nil, err
+		}
+		// a previous publish may have failed after the mount, the group is applied again
+		if err := csiNS.setVolumeMountGroup(ctxLogger, requestID, target, mountGroup, readOnly); err != nil {
+			return nil, err
+		}
 		ctxLogger.Warn("target Path is already mounted")
 		return &csi.NodePublishVolumeResponse{}, nil
 	}
-	mnt := volumeCapability.GetMount()
-	options := mnt.MountFlags
-	// Get volume context
-	volumeContext := req.GetVolumeContext()
+
+	//Lets try to put lock at targetPath level. If we are processing same target path lets wait for other to finish.
+	//This will not hold other volumes and target path processing.
+	csiNS.mutex.Lock(target)
+	defer csiNS.mutex.Unlock(target)
+
+	var mountErr error
+	if len(stagingPath) != 0 {
+		mountErr = csiNS.bindStagedShare(ctx, ctxLogger, requestID, volumeID, stagingPath, target, readOnly)
+	} else {
+		options := volumeCapability.GetMount().GetMountFlags()
+		if readOnly {
+			options = append(options, readOnlyMountOption)
+		}
+		mountErr = csiNS.mountShare(ctx, ctxLogger, requestID, volumeID, req.GetVolumeContext(), options, target)
+	}
+
+	if mountErr == nil {
+		if mountErr = csiNS.setVolumeMountGroup(ctxLogger, requestID, target, mountGroup, readOnly); mountErr != nil {
+			// without the group the pod cannot write, unmount so that the publish is retried from scratch
+			if err := mount.CleanupMountPoint(target, csiNS.Mounter, false /* bind mount */); err != nil {
+				ctxLogger.Warn("Failed to unmount target path after volume mount group failure", zap.String("targetPath", target), zap.Error(err))
+			}
+		}
+	}
+
+	var nodePublishResponse *csi.NodePublishVolumeResponse
+	if mountErr == nil {
+		nodePublishResponse = &csi.NodePublishVolumeResponse{}
+	}
+	ctxLogger.Info("CSINodeServer-NodePublishVolume response...", zap.Reflect("Response", nodePublishResponse), zap.Error(mountErr))
+	return nodePublishResponse, mountErr
+}
+
+// bindStagedShare bind mounts the share staged at stagingPath into the pod target path
+func (csiNS *CSINodeServer) bindStagedShare(ctx context.Context, ctxLogger *zap.Logger, requestID, volumeID, stagingPath, target string, readOnly bool) error {
+	// A bind mount of a staging path that is not mounted would give the pod an empty node directory
+	notMounted, err := csiNS.Mounter.IsLikelyNotMountPoint(stagingPath)
+	if err != nil && !os.IsNotExist(err) {
+		return commonError.GetCSIError(ctxLogger, commonError.MountPointValidateError, requestID, err, stagingPath)
+	}
+	if notMounted {
+		return commonError.GetCSIError(ctxLogger, commonError.VolumePathNotMounted, requestID, err, stagingPath)
+	}
+
+	_, mountSpan := tracing.StartSpan(ctx, "processMount", requestID, attribute.String("csi.volume_id", volumeID))
+	options := []string{"bind"}
+	if readOnly {
+		// a read-only publish of a writable staged share, the mounter remounts the bind mount read-only
+		options = append(options, readOnlyMountOption)
+	}
+	_, err = csiNS.processMount(ctxLogger, requestID, stagingPath, target, "", "", options)
+	tracing.EndSpan(mountSpan, err)
+	return err
+}
+
+// setVolumeMountGroup applies the volume mount group of a writable publish to the share mounted at target
+func (csiNS *CSINodeServer) setVolumeMountGroup(ctxLogger *zap.Logger, requestID, target string, gid int, readOnly bool) error {
+	// the kubelet does not apply fsGroup to read-only volumes either
+	if gid == noVolumeMountGroup || readOnly {
+		return nil
+	}
+	ctxLogger.Info("Applying volume mount group", zap.String("targetPath", target), zap.Int("gid", gid))
+	if err := applyVolumeMountGroup(target, gid); err != nil {
+		var conflict *volumeMountGroupConflict
+		if errors.As(err, &conflict) {
+			return commonError.GetCSIError(ctxLogger, VolumeMountGroupConflict, requestID, err, gid, target, conflict.gid)
+		}
+		return commonError.GetCSIError(ctxLogger, VolumeMountGroupFailed, requestID, err, gid, target)
+	}
+	return nil
+}
+
+// isReadOnlyAccessMode reports whether the access mode of the capability only allows reads
+func isReadOnlyAccessMode(volumeCapability *csi.VolumeCapability) bool {
+	switch volumeCapability.GetAccessMode().GetMode() {
+	case csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY, csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY:
+		return true
+	}
+	return false
+}
+
+// checkPublishedMount verifies against the node mount table that the mount at target is the share of the
+// volume with the requested read-only setting. The expected source is the nfsServerPath of the volume context,
+// the local stunnel endpoint and tunnel port for RFS EIT shares or, without volume context, the staged share.
+func (csiNS *CSINodeServer) checkPublishedMount(ctxLogger *zap.Logger, requestID, volumeID string, volumeContext map[string]string, stagingPath, target string, readOnly bool) error {
+	mountPoints, err := csiNS.Mounter.List()
+	if err != nil {
+		return commonError.GetCSIError(ctxLogger, commonError.MountPointValidateError, requestID, err, target)
+	}
+	published := findMountPoint(mountPoints, target)
+	if published == nil {
+		ctxLogger.Warn("Target path is not in the mount table, the existing mount is not verified", zap.String("targetPath", target))
+		return nil
+	}
+
+	expected, port := csiNS.expectedMountSource(volumeContext)
+	if expected == "" && len(stagingPath) != 0 {
+		if staged := findMountPoint(mountPoints, stagingPath); staged != nil {
+			expected = staged.Device
+		}
+	}
+	if expected != "" && strings.TrimRight(published.Device, "/") != strings.TrimRight(expected, "/") {
+		return commonError.GetCSIError(ctxLogger, PublishedVolumeMismatch, requestID, nil, target, published.Device, volumeID, expected)
+	}
+	if mountedPort := mountOptionValue(published.Opts, "port"); port != 0 && mountedPort != "" && mountedPort != strconv.Itoa(port) {
+		return commonError.GetCSIError(ctxLogger, PublishedVolumeMismatch, requestID, nil, target, published.Device+" port="+mountedPort, volumeID, fmt.Sprintf("%s port=%d", expected, port))
+	}
+
+	if mountedReadOnly := hasMountOption(published.Opts, readOnlyMountOption); mountedReadOnly != readOnly {
+		return commonError.GetCSIError(ctxLogger, PublishReadOnlyMismatch, requestID, nil, volumeID, target, mountedReadOnly, readOnly)
+	}
+	return nil
+}
+
+// expectedMountSource returns the mount source of the share of the volume context and, for RFS EIT shares, the
+// port of its stunnel tunnel. The source is empty when the volume context does not tell.
+func (csiNS *CSINodeServer) expectedMountSource(volumeContext map[string]string) (string, int) {
+	source := volumeContext[NFSServerPath]
+	if source == "" {
+		return "", 0
+	}
+	if volumeContext[ProfileLabel] != RFSProfile || volumeContext[IsEITEnabled] != TrueStr {
+		return source, 0
+	}
+	nfsSource, err := splitNFSSource(source)
+	if err != nil {
+		return "", 0
+	}
+	port := 0
+	if csiNS.StunnelMgr != nil {
+		port, _ = csiNS.StunnelMgr.GetTunnelPort(volumeContext[FileShareIDLabel])
+	}
+	return fmt.Sprintf("%s:%s", stunnelLocalAddress, nfsSource.ExportPath), port
+}
+
+// findMountPoint returns the topmost mount at path
+func findMountPoint(mountPoints []mount.MountPoint, path string) *mount.MountPoint {
+	var found *mount.MountPoint
+	for i := range mountPoints {
+		if mountPoints[i].Path == path {
+			found = &mountPoints[i]
+		}
+	}
+	return found
+}
+
+// mountOptionValue returns the value of a key=value mount option, "" when it is not set
+func mountOptionValue(options []string, key string) string {
+	for _, opt := range options {
+		if value, ok := strings.CutPrefix(opt, key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// hasMountOption ...
+func hasMountOption(options []string, option string) bool {
+	for _, opt := range options {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// mountShare mounts the NFS share of the volume context at target, through the IPsec mount helper or a stunnel
+// tunnel when encryption in transit is enabled. The IPsec mount helper takes no mount options, read-only
+// publishes of those shares are enforced by the staged bind mount.
+func (csiNS *CSINodeServer) mountShare(ctx context.Context, ctxLogger *zap.Logger, requestID, volumeID string, volumeContext map[string]string, options []string, target string) error {
+	source := volumeContext[NFSServerPath]
 
 	// Get profile name from volume context (optional)
 	profileName := volumeContext[ProfileLabel]
@@ -286,13 +480,19 @@ func (csiNS *CSINodeServer) NodePublishVolume(ctx context.Context, req *csi.Node
 		}
 	}
 
-	var nodePublishResponse *csi.NodePublishVolumeResponse
-	var mountErr error
-
-	//Lets try to put lock at targetPath level. If we are processing same target path lets wait for other to finish.
-	//This will not hold other volumes and target path processing.
-	csiNS.mutex.Lock(target)
-	defer csiNS.mutex.Unlock(target)
+	var err error
+	readOnly := false
+	if fsType == eitFsType {
+		// The IPsec mount helper takes no mount options, the option policy and its defaults do not apply.
+		// A read-only mount is made read-only once the helper mounted it.
+		readOnly = hasMountOption(options, readOnlyMountOption)
+		if len(options) != 0 {
+			ctxLogger.Info("Mount options do not apply to the IPsec mount helper, ignoring them", zap.String("volumeID", volumeID), zap.Strings("options", options), zap.Bool("readOnly", readOnly))
+		}
+		options = nil
+	} else if options, err = effectiveMountOptions(profileName, isEITEnabled == TrueStr, options); err != nil {
+		return commonError.GetCSIError(ctxLogger, MountOptionsNotAllowed, requestID, err, volumeID)
+	}
 
 	// Handle RFS profile with Stunnel encryption
 	mountSource := source
@@ -311,7 +511,7 @@ func (csiNS *CSINodeServer) NodePublishVolume(ctx context.Context, req *csi.Node
 				zap.String("volumeID", volumeID),
 				zap.String("profileName", profileName),
 				zap.Error(err))
-			return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+			return commonError.GetCSIError(ctxLogger, StunnelSetupFailed, requestID, err, volumeID)
 		}
 
 		// Parse the NFS server and export path from source
@@ -321,39 +521,72 @@ func (csiNS *CSINodeServer) NodePublishVolume(ctx context.Context, req *csi.Node
 			ctxLogger.Error("Failed to parse NFS source",
 				zap.String("source", source),
 				zap.Error(err))
-			return nil, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, err)
+			return commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, err)
 		}
 		nfsServer := nfsSource.Server
 		exportPath = nfsSource.ExportPath
 
 		// Ensure tunnel config exists for this volume (stunnel auto-loads it)
+		_, tunnelSpan := tracing.StartSpan(ctx, "EnsureTunnel", requestID, attribute.String("csi.volume_id", volumeID))
 		tunnelPort, err := csiNS.StunnelMgr.EnsureTunnel(fileShareID, nfsServer, requestID)
+		tracing.EndSpan(tunnelSpan, err)
 		if err != nil {
 			ctxLogger.Error("Failed to create tunnel config for volume",
 				zap.String("volumeID", volumeID),
 				zap.Error(err))
-			return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+			return commonError.GetCSIError(ctxLogger, StunnelSetupFailed, requestID, err, volumeID)
 		}
 
 		// Update mount source to use local tunnel endpoint with export path
 		// Format: 127.0.0.1:/<export_path>
-		mountSource = fmt.Sprintf("127.0.0.1:%s", exportPath)
+		mountSource = fmt.Sprintf("%s:%s", stunnelLocalAddress, exportPath)
 
-		// Append port to existing mount options (storage class provides vers, proto, etc.)
-		// Storage class must include mount options like: vers=4.1,proto=tcp
+		// The mount option policy rejects a port from the storage class and defaults vers and proto for the tunnel
 		options = append(options, fmt.Sprintf("port=%d", tunnelPort))
 	}
 
-	nodePublishResponse, mountErr = csiNS.processMount(ctxLogger, requestID, mountSource, target, fsType, transitEncryption, options)
+	if fsType != eitFsType {
+		ctxLogger.Info("Effective mount options", zap.String("volumeID", volumeID), zap.Strings("options", options))
+	}
+	_, mountSpan := tracing.StartSpan(ctx, "processMount", requestID, attribute.String("csi.volume_id", volumeID))
+	_, err = csiNS.processMount(ctxLogger, requestID, mountSource, target, fsType, transitEncryption, options)
+	if err == nil && readOnly {
+		err = csiNS.remountEITReadOnly(ctxLogger, requestID, volumeID, target)
+	}
+	tracing.EndSpan(mountSpan, err)
+	return err
+}
 
-	ctxLogger.Info("CSINodeServer-NodePublishVolume response...", zap.Reflect("Response", nodePublishResponse), zap.Error(mountErr))
-	return nodePublishResponse, mountErr
+// remountEITReadOnly makes the IPsec mount at target read-only, it is unmounted again when that fails
+// so that a retry does not find a writable mount
+func (csiNS *CSINodeServer) remountEITReadOnly(ctxLogger *zap.Logger, requestID, volumeID, target string) error {
+	err := csiNS.remountReadOnly(target)
+	if err == nil {
+		ctxLogger.Info("Remounted IPsec mount read-only", zap.String("volumeID", volumeID), zap.String("targetPath", target))
+		return nil
+	}
+	if cleanupErr := mount.CleanupMountPoint(target, csiNS.Mounter, false /* bind mount */); cleanupErr != nil {
+		ctxLogger.Warn("Failed to unmount target path after read-only remount failure", zap.String("targetPath", target), zap.Error(cleanupErr))
+	}
+	return commonError.GetCSIError(ctxLogger, MountReadOnlyFailed, requestID, err, target)
+}
+
+// bindRemountReadOnly sets the read-only flag on the mount at path only, unlike a remount of the NFS
+// superblock the other mounts of the share on the node stay writable. The other per mount flags are kept.
+func bindRemountReadOnly(path string) error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return err
+	}
+	// the ST_ flags of statfs have the values of the MS_ mount flags
+	kept := uintptr(st.Flags) & (unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC | unix.MS_NOATIME | unix.MS_NODIRATIME | unix.MS_RELATIME)
+	return unix.Mount("", path, "", unix.MS_REMOUNT|unix.MS_BIND|unix.MS_RDONLY|kept, "")
 }
 
 // NodeUnpublishVolume ...
 func (csiNS *CSINodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSINodeServer-NodeUnpublishVolume...", zap.Reflect("Request", req))
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSINodeServer-NodeUnpublishVolume...", zap.Reflect("Request", redactSecrets(req)))
 	defer metrics.UpdateDurationFromStart(ctxLogger, "NodeUnpublishVolume", time.Now())
 
 	// Validate Arguments
@@ -371,69 +604,179 @@ func (csiNS *CSINodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.No
 	csiNS.mutex.Lock(targetPath)
 	defer csiNS.mutex.Unlock(targetPath)
 
+	// The tunnel of RFS EIT volumes is shared by all pods of the node and is removed in NodeUnstageVolume
 	ctxLogger.Info("Unmounting target path", zap.String("targetPath", targetPath))
 	err := mount.CleanupMountPoint(targetPath, csiNS.Mounter, false /* bind mount */)
 	if err != nil {
 		return nil, commonError.GetCSIError(ctxLogger, commonError.UnmountFailed, requestID, err, targetPath)
 	}
 
-	// Clean up tunnel config if it exists for this volume
-	// Note: We only remove the tunnel after successful unmount to avoid disrupting active mounts
-	if csiNS.StunnelMgr != nil {
-		// Extract the share ID from the volume ID (format: shareID#targetID)
-		fileShareID := getTokens(volID)
-		if len(fileShareID) > 0 {
-			shareID := fileShareID[0]
-
-			ctxLogger.Info("Checking for tunnel config cleanup",
-				zap.String("volumeID", volID),
-				zap.String("shareID", shareID))
-
-			// RemoveTunnel is idempotent and handles race conditions internally
-			// It will return nil if tunnel doesn't exist or was already removed
-			if err := csiNS.StunnelMgr.RemoveTunnel(shareID, requestID); err != nil {
-				ctxLogger.Error("Failed to remove tunnel config after unmount, will trigger retry",
-					zap.String("shareID", shareID),
-					zap.Error(err))
-				// Return error to trigger Kubernetes retry
-				// The rollback logic in RemoveTunnel ensures port maps stay consistent
-				// K8s will retry NodeUnpublishVolume, which will:
-				// 1. Try to unmount again (will succeed as already unmounted or be idempotent)
-				// 2. Retry tunnel cleanup until it succeeds
-				return nil, commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
-			}
-			ctxLogger.Info("Tunnel config removed successfully", zap.String("shareID", shareID))
-		} else {
-			ctxLogger.Error("Invalid volume ID format, cannot extract share ID",
-				zap.String("volumeID", volID))
-			// Don't fail unmount - volume is already unmounted
-			// Just log the error and continue
-		}
-	}
-
 	nodeUnpublishVolumeResponse := &csi.NodeUnpublishVolumeResponse{}
 	ctxLogger.Info("Successfully unmounted target path", zap.String("targetPath", targetPath))
 	return nodeUnpublishVolumeResponse, nil
 }
 
-// NodeStageVolume ...
+// NodeStageVolume mounts the share once per node at the staging path, NodePublishVolume bind mounts it into the pods
 func (csiNS *CSINodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSINodeServer-NodeStageVolume", zap.Reflect("Request", req))
-	return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnsupported, requestID, nil, "NodeStageVolume")
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSINodeServer-NodeStageVolume", zap.Reflect("Request", redactSecrets(req)))
+	defer metrics.UpdateDurationFromStart(ctxLogger, "NodeStageVolume", time.Now())
+
+	volumeID := req.GetVolumeId()
+	if len(volumeID) == 0 {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.EmptyVolumeID, requestID, nil)
+	}
+
+	stagingPath := req.GetStagingTargetPath()
+	if len(stagingPath) == 0 {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.NoStagingTargetPath, requestID, nil)
+	}
+
+	volumeCapability := req.GetVolumeCapability()
+	if volumeCapability == nil {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.NoVolumeCapabilities, requestID, nil)
+	}
+
+	// Validate volume capabilities, are all capabilities supported by driver or not
+	if !areVolumeCapabilitiesSupported([]*csi.VolumeCapability{volumeCapability}, csiNS.Driver.vcap) {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.VolumeCapabilitiesNotSupported, requestID, nil)
+	}
+
+	if len(req.GetVolumeContext()[NFSServerPath]) == 0 {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, fmt.Errorf("volume context does not contain '%s'", NFSServerPath))
+	}
+
+	csiNS.mutex.Lock(stagingPath)
+	defer csiNS.mutex.Unlock(stagingPath)
+
+	// The share is staged once per node, further stage calls for the volume are no-ops
+	notMounted, err := csiNS.Mounter.IsLikelyNotMountPoint(stagingPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.MountPointValidateError, requestID, err, stagingPath)
+	}
+	if !notMounted {
+		ctxLogger.Info("Staging path is already mounted", zap.String("stagingPath", stagingPath))
+		return &csi.NodeStageVolumeResponse{}, nil
+	}
+
+	// Shares with a read-only access mode are staged read-only, the bind mounts of the pods then can not write either
+	options := volumeCapability.GetMount().GetMountFlags()
+	if isReadOnlyAccessMode(volumeCapability) {
+		options = append(options, readOnlyMountOption)
+	}
+	if err := csiNS.mountShare(ctx, ctxLogger, requestID, volumeID, req.GetVolumeContext(), options, stagingPath); err != nil {
+		return nil, err
+	}
+	ctxLogger.Info("Successfully staged volume", zap.String("stagingPath", stagingPath))
+	return &csi.NodeStageVolumeResponse{}, nil
 }
 
-// NodeUnstageVolume ...
+// NodeUnstageVolume unmounts the staging path once no pod target path is bind mounted from it and removes the
+// stunnel tunnel of RFS EIT volumes
 func (csiNS *CSINodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSINodeServer-NodeUnstageVolume", zap.Reflect("Request", req))
-	return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnsupported, requestID, nil, "NodeUnstageVolume")
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSINodeServer-NodeUnstageVolume", zap.Reflect("Request", redactSecrets(req)))
+	defer metrics.UpdateDurationFromStart(ctxLogger, "NodeUnstageVolume", time.Now())
+
+	volumeID := req.GetVolumeId()
+	if len(volumeID) == 0 {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.EmptyVolumeID, requestID, nil)
+	}
+
+	stagingPath := req.GetStagingTargetPath()
+	if len(stagingPath) == 0 {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.NoStagingTargetPath, requestID, nil)
+	}
+
+	csiNS.mutex.Lock(stagingPath)
+	defer csiNS.mutex.Unlock(stagingPath)
+
+	notMounted, err := csiNS.Mounter.IsLikelyNotMountPoint(stagingPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.MountPointValidateError, requestID, err, stagingPath)
+	}
+	if !notMounted {
+		// Bind mounts of the staging path share its mount, unmounting it under a running pod is refused. The
+		// refs also list other mounts of the same share, e.g. the staging paths of further PVs, those are ignored
+		refs, err := csiNS.Mounter.GetMountRefs(stagingPath)
+		if err != nil {
+			return nil, commonError.GetCSIError(ctxLogger, commonError.MountPointValidateError, requestID, err, stagingPath)
+		}
+		if podRefs := podVolumeMounts(refs); len(podRefs) != 0 {
+			return nil, commonError.GetCSIError(ctxLogger, VolumeStillPublished, requestID, nil, volumeID, strings.Join(podRefs, ", "))
+		}
+	}
+
+	ctxLogger.Info("Unmounting staging path", zap.String("stagingPath", stagingPath))
+	if err := mount.CleanupMountPoint(stagingPath, csiNS.Mounter, false /* bind mount */); err != nil {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.UnmountFailed, requestID, err, stagingPath)
+	}
+
+	// Clean up tunnel config if it exists for this volume
+	// Note: We only remove the tunnel after successful unmount to avoid disrupting active mounts
+	if err := csiNS.removeTunnel(ctxLogger, requestID, volumeID); err != nil {
+		return nil, err
+	}
+
+	ctxLogger.Info("Successfully unstaged volume", zap.String("stagingPath", stagingPath))
+	return &csi.NodeUnstageVolumeResponse{}, nil
+}
+
+// podVolumeMounts returns the paths of refs that are CSI volume mounts of a pod,
+// <kubelet dir>/pods/<pod uid>/volumes/kubernetes.io~csi/<pv name>/mount
+func podVolumeMounts(refs []string) []string {
+	var podRefs []string
+	for _, ref := range refs {
+		parts := strings.Split(filepath.Clean(ref), string(filepath.Separator))
+		n := len(parts)
+		if n >= 6 && parts[n-6] == "pods" && parts[n-4] == "volumes" && parts[n-3] == "kubernetes.io~csi" && parts[n-1] == "mount" {
+			podRefs = append(podRefs, ref)
+		}
+	}
+	return podRefs
+}
+
+// removeTunnel removes the stunnel tunnel of the share of volID once it has no mounts left
+func (csiNS *CSINodeServer) removeTunnel(ctxLogger *zap.Logger, requestID, volID string) error {
+	if csiNS.StunnelMgr == nil {
+		return nil
+	}
+	// Extract the share ID from the volume ID (format: shareID#targetID)
+	fileShareID := getTokens(volID)
+	if len(fileShareID) == 0 {
+		ctxLogger.Error("Invalid volume ID format, cannot extract share ID",
+			zap.String("volumeID", volID))
+		// Don't fail unmount - volume is already unmounted
+		// Just log the error and continue
+		return nil
+	}
+	shareID := fileShareID[0]
+
+	ctxLogger.Info("Checking for tunnel config cleanup",
+		zap.String("volumeID", volID),
+		zap.String("shareID", shareID))
+
+	// RemoveTunnel is idempotent and handles race conditions internally
+	// It will return nil if tunnel doesn't exist or was already removed
+	if err := csiNS.StunnelMgr.RemoveTunnel(shareID, requestID); err != nil {
+		ctxLogger.Error("Failed to remove tunnel config after unmount, will trigger retry",
+			zap.String("shareID", shareID),
+			zap.Error(err))
+		// Return error to trigger Kubernetes retry
+		// The rollback logic in RemoveTunnel ensures port maps stay consistent
+		// K8s will retry NodeUnstageVolume, which will:
+		// 1. Try to unmount again (will succeed as already unmounted or be idempotent)
+		// 2. Retry tunnel cleanup until it succeeds
+		return commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, err)
+	}
+	ctxLogger.Info("Tunnel config removed successfully", zap.String("shareID", shareID))
+	return nil
 }
 
 // NodeGetCapabilities ...
 func (csiNS *CSINodeServer) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetCapabilitiesRequest) (*csi.NodeGetCapabilitiesResponse, error) {
-	ctxLogger, _ := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSINodeServer-NodeGetCapabilities... ", zap.Reflect("Request", req))
+	ctxLogger, _ := getContextLogger(ctx, false)
+	ctxLogger.Info("CSINodeServer-NodeGetCapabilities... ", zap.Reflect("Request", redactSecrets(req)))
 
 	return &csi.NodeGetCapabilitiesResponse{
 		Capabilities: csiNS.Driver.nscap,
@@ -442,45 +785,54 @@ func (csiNS *CSINodeServer) NodeGetCapabilities(ctx context.Context, req *csi.No
 
 // NodeGetInfo ...
 func (csiNS *CSINodeServer) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoRequest) (*csi.NodeGetInfoResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSINodeServer-NodeGetInfo... ", zap.Reflect("Request", req))
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSINodeServer-NodeGetInfo... ", zap.Reflect("Request", redactSecrets(req)))
 
 	// Check if node metadata service initialized properly
-	if csiNS.Metadata == nil { //nolint
-		nodeName := os.Getenv("KUBE_NODE_NAME")
-
-		nodeInfo := nodeMetadata.NodeInfoManager{
-			NodeName: nodeName,
-		}
-
-		metadata, err := nodeInfo.NewNodeMetadata(ctxLogger)
-		if err != nil {
-			ctxLogger.Error("Failed to initialize node metadata", zap.Error(err))
-			return nil, commonError.GetCSIError(ctxLogger, commonError.NodeMetadataInitFailed, requestID, err)
-		}
-		csiNS.Metadata = metadata
+	metadata, err := csiNS.getNodeMetadata(ctxLogger)
+	if err != nil {
+		ctxLogger.Error("Failed to initialize node metadata", zap.Error(err))
+		return nil, commonError.GetCSIError(ctxLogger, commonError.NodeMetadataInitFailed, requestID, err)
 	}
 
 	top := &csi.Topology{
 		Segments: map[string]string{
-			utils.NodeRegionLabel: csiNS.Metadata.GetRegion(),
-			utils.NodeZoneLabel:   csiNS.Metadata.GetZone(),
+			utils.NodeRegionLabel: metadata.GetRegion(),
+			utils.NodeZoneLabel:   metadata.GetZone(),
 		},
 	}
 
 	resp := &csi.NodeGetInfoResponse{
-		NodeId:             csiNS.Metadata.GetWorkerID(),
+		NodeId:             metadata.GetWorkerID(),
 		AccessibleTopology: top,
 	}
 	ctxLogger.Info("NodeGetInfoResponse", zap.Reflect("NodeGetInfoResponse", resp))
 	return resp, nil
 }
 
+// getNodeMetadata returns the node metadata, reading it from the node labels on first use
+func (csiNS *CSINodeServer) getNodeMetadata(ctxLogger *zap.Logger) (nodeMetadata.NodeMetadata, error) {
+	csiNS.metadataMu.Lock()
+	defer csiNS.metadataMu.Unlock()
+	if csiNS.Metadata == nil { //nolint
+		nodeInfo := nodeMetadata.NodeInfoManager{
+			NodeName: csiNS.Driver.config.Get().NodeName,
+		}
+
+		metadata, err := nodeInfo.NewNodeMetadata(ctxLogger)
+		if err != nil {
+			return nil, err
+		}
+		csiNS.Metadata = metadata
+	}
+	return csiNS.Metadata, nil
+}
+
 // NodeGetVolumeStats ...
 func (csiNS *CSINodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
 	var resp *csi.NodeGetVolumeStatsResponse
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSINodeServer-NodeGetVolumeStats... ", zap.Reflect("Request", req))
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSINodeServer-NodeGetVolumeStats... ", zap.Reflect("Request", redactSecrets(req)))
 	defer metrics.UpdateDurationFromStart(ctxLogger, "NodeGetVolumeStats", time.Now())
 	if req == nil || req.VolumeId == "" { //nolint
 		return nil, commonError.GetCSIError(ctxLogger, commonError.EmptyVolumeID, requestID, nil)
@@ -491,41 +843,56 @@ func (csiNS *CSINodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.Nod
 	}
 
 	volumePath := req.VolumePath
-	// Return if path does not exist
-	if csiNS.Stats.IsDevicePathNotExist(volumePath) {
-		return nil, commonError.GetCSIError(ctxLogger, commonError.DevicePathNotExists, requestID, nil, volumePath, req.VolumeId)
-	}
-
-	// else get the file system stats
-	available, capacity, usage, inodes, inodesFree, inodesUsed, err := csiNS.Stats.FSInfo(volumePath)
+	// The usage comes from a timed statfs of the path or the cached usage of the share, which is
+	// served after a timed probe of the mount at path. Any stat of the path outside of them would
+	// block on a hung NFS server
+	stats, condition, err := csiNS.VolumeStats.Get(ctx, ctxLogger, volumeStatsKey(req.VolumeId), volumePath, func() (volumeStats, error) {
+		var stats volumeStats
+		if csiNS.Stats.IsDevicePathNotExist(volumePath) {
+			return stats, os.ErrNotExist
+		}
+		var err error
+		stats.available, stats.capacity, stats.used, stats.inodes, stats.inodesFree, stats.inodesUsed, err = csiNS.Stats.FSInfo(volumePath)
+		return stats, err
+	})
 	if err != nil {
+		// Return if path does not exist
+		if os.IsNotExist(err) {
+			return nil, commonError.GetCSIError(ctxLogger, commonError.DevicePathNotExists, requestID, nil, volumePath, req.VolumeId)
+		}
 		return nil, commonError.GetCSIError(ctxLogger, commonError.GetFSInfoFailed, requestID, err)
 	}
-	resp = &csi.NodeGetVolumeStatsResponse{
+	resp = volumeStatsResponse(stats, condition)
+
+	ctxLogger.Info("Response for Volume stats", zap.Reflect("Response", resp))
+	return resp, nil
+}
+
+// volumeStatsResponse ...
+func volumeStatsResponse(stats volumeStats, condition *csi.VolumeCondition) *csi.NodeGetVolumeStatsResponse {
+	return &csi.NodeGetVolumeStatsResponse{
 		Usage: []*csi.VolumeUsage{
 			{
-				Available: available,
-				Total:     capacity,
-				Used:      usage,
+				Available: stats.available,
+				Total:     stats.capacity,
+				Used:      stats.used,
 				Unit:      csi.VolumeUsage_BYTES,
 			},
 			{
-				Available: inodesFree,
-				Total:     inodes,
-				Used:      inodesUsed,
+				Available: stats.inodesFree,
+				Total:     stats.inodes,
+				Used:      stats.inodesUsed,
 				Unit:      csi.VolumeUsage_INODES,
 			},
 		},
+		VolumeCondition: condition,
 	}
-
-	ctxLogger.Info("Response for Volume stats", zap.Reflect("Response", resp))
-	return resp, nil
 }
 
 // NodeExpandVolume ...
 func (csiNS *CSINodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
-	ctxLogger, requestID := utils.GetContextLogger(ctx, false)
-	ctxLogger.Info("CSINodeServer-NodeExpandVolume", zap.Reflect("Request", req))
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("CSINodeServer-NodeExpandVolume", zap.Reflect("Request", redactSecrets(req)))
 	return nil, commonError.GetCSIError(ctxLogger, commonError.MethodUnsupported, requestID, nil, "NodeExpandVolume")
 }
 
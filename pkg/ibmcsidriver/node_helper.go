@@ -24,6 +24,7 @@ import (
 	"regexp"
 
 	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	"go.uber.org/zap"
 )
@@ -67,17 +68,15 @@ func (csiNS *CSINodeServer) processMount(ctxLogger *zap.Logger, requestID, mount
 				return nil, commonError.GetCSIError(ctxLogger, commonError.UnmountFailed, requestID, err, targetPath)
 			}
 		}
-		errorCode := commonError.MountingTargetFailed
 		errRemovePath := os.Remove(targetPath)
 		if errRemovePath != nil {
 			ctxLogger.Warn("processMount: Remove targetPath failed", zap.String("targetPath", targetPath), zap.Error(errRemovePath))
 		}
-		if fsType == eitFsType {
-			errorCode = checkMountResponse(err)
-			if errorCode != commonError.UnresponsiveMountHelperContainerUtility {
-				ctxLogger.Error("Mount backend output: ", zap.String("Response:", errResponse))
-			}
+		errorCode := checkMountResponse(err, fsType)
+		if fsType == eitFsType && errorCode != commonError.UnresponsiveMountHelperContainerUtility {
+			ctxLogger.Error("Mount backend output: ", zap.String("Response:", errResponse))
 		}
+		driverMetrics.MountFailures.WithLabelValues(errorCode).Inc()
 		return nil, commonError.GetCSIError(ctxLogger, errorCode, requestID, err)
 	}
 
@@ -85,19 +84,53 @@ func (csiNS *CSINodeServer) processMount(ctxLogger *zap.Logger, requestID, mount
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
-// checkMountResponse checks for known errors while mounting and return appropriate user error codes.
-func checkMountResponse(err error) string {
+// mountErrorPath the mount paths a mount error rule applies to
+type mountErrorPath int
+
+const (
+	// anyMountPath ...
+	anyMountPath mountErrorPath = iota
+	// eitMountPath mounts through the IPsec mount helper container (DP2 EIT)
+	eitMountPath
+)
+
+// stunnelLocalAddress the stunnel client listens on, RFS EIT shares are mounted from <stunnelLocalAddress>:/<export path>
+const stunnelLocalAddress = "127.0.0.1"
+
+// mountErrorRule maps mount output matching regex on path to a driver or common error code. The error code is
+// the CSI error message with the remediation and the reason label of the mount failures metric.
+type mountErrorRule struct {
+	path      mountErrorPath
+	regex     *regexp.Regexp
+	errorCode string
+}
+
+// mountErrorRules are checked in order, the first match classifies the mount error
+var mountErrorRules = []mountErrorRule{
+	{anyMountPath, regexp.MustCompile(`(?i)access denied by server`), MountAccessDenied},
+	{anyMountPath, regexp.MustCompile(`(?i)stale (nfs )?file handle`), MountStaleFileHandle},
+	{anyMountPath, regexp.MustCompile(`(?i)protocol not supported|requested NFS version or transport protocol is not supported|program not registered`), MountProtocolNotSupported},
+	{anyMountPath, regexp.MustCompile(`(?i)connection timed out|no route to host|network is unreachable`), MountConnectionTimedOut},
+	// the mount helper gave up on the IPsec mount, which is not necessarily a network problem
+	{eitMountPath, regexp.MustCompile(`(?i)timed out`), MountHelperTimedOut},
+	{eitMountPath, regexp.MustCompile(`connect: no such file\b`), commonError.UnresponsiveMountHelperContainerUtility},
+	{eitMountPath, regexp.MustCompile(`exit status 1\b`), commonError.MetadataServiceNotEnabled},
+}
+
+// checkMountResponse checks for known errors while mounting with fsType and return appropriate user error codes.
+// TLS failures of the stunnel tunnel are not classified, stunnel only logs them and the NFS client sees a reset
+// or closed connection.
+func checkMountResponse(err error, fsType string) string {
 	errorString := err.Error()
 
-	errorMap := map[string]string{
-		`exit status 1\b`:         commonError.MetadataServiceNotEnabled,
-		`connect: no such file\b`: commonError.UnresponsiveMountHelperContainerUtility,
+	path := anyMountPath
+	if fsType == eitFsType {
+		path = eitMountPath
 	}
 
-	for code, message := range errorMap {
-		regex := regexp.MustCompile(code)
-		if regex.MatchString(errorString) {
-			return message
+	for _, rule := range mountErrorRules {
+		if (rule.path == anyMountPath || rule.path == path) && rule.regex.MatchString(errorString) {
+			return rule.errorCode
 		}
 	}
 
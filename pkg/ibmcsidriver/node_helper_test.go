@@ -83,10 +83,17 @@ func TestProcessMount_MountFailure(t *testing.T) {
 		{
 			name:            "Non-EIT mount failure: MountingTargetFailed when Remove succeeds",
 			fsType:          "nfs",
-			mountErr:        errors.New("exit status 32: Protocol not supported"),
+			mountErr:        errors.New("exit status 32: mount system call failed"),
 			expectedErrCode: commonError.MountingTargetFailed,
 			targetInTempDir: true,
 		},
+		{
+			name:            "Non-EIT mount failure: Protocol not supported -> MountProtocolNotSupported",
+			fsType:          "nfs",
+			mountErr:        errors.New("exit status 32: Protocol not supported"),
+			expectedErrCode: MountProtocolNotSupported,
+			targetInTempDir: true,
+		},
 		{
 			name:            "Non-EIT mount failure: CreateMountTargetFailed when Remove fails",
 			fsType:          "nfs",
@@ -170,31 +177,108 @@ func TestProcessMount_MountFailure(t *testing.T) {
 }
 
 func TestCheckMountResponse(t *testing.T) {
+	const stunnelSource = stunnelLocalAddress + ":/share123"
 	tests := []struct {
 		name     string
 		err      error
+		fsType   string
 		expected string
 	}{
 		{
 			name:     "Test MetadataServiceNotEnabled",
 			err:      errors.New("exit status 1"),
+			fsType:   eitFsType,
 			expected: commonError.MetadataServiceNotEnabled,
 		},
 		{
 			name:     "Test UnresponsiveMountHelperContainerUtility",
 			err:      errors.New("connect: no such file"),
+			fsType:   eitFsType,
 			expected: commonError.UnresponsiveMountHelperContainerUtility,
 		},
 		{
 			name:     "Test Default",
 			err:      errors.New("some other error"),
+			fsType:   eitFsType,
+			expected: commonError.MountingTargetFailed,
+		},
+		{
+			name:     "Mount helper errors only apply to EIT mounts",
+			err:      errors.New("exit status 1"),
+			fsType:   defaultFsType,
+			expected: commonError.MountingTargetFailed,
+		},
+		{
+			name:     "Access denied by server",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs: access denied by server while mounting 10.0.0.1:/share123"),
+			fsType:   defaultFsType,
+			expected: MountAccessDenied,
+		},
+		{
+			name:     "Connection timed out",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs: Connection timed out"),
+			fsType:   defaultFsType,
+			expected: MountConnectionTimedOut,
+		},
+		{
+			name:     "No route to host",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs: No route to host"),
+			fsType:   nfs4FsType,
+			expected: MountConnectionTimedOut,
+		},
+		{
+			name:     "Mount helper timed out",
+			err:      errors.New("mount request timed out"),
+			fsType:   eitFsType,
+			expected: MountHelperTimedOut,
+		},
+		{
+			name:     "Timed out without the mount helper",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs: timed out waiting for lock"),
+			fsType:   defaultFsType,
 			expected: commonError.MountingTargetFailed,
 		},
+		{
+			name:     "Connection timed out through the mount helper",
+			err:      errors.New("mount failed: connection timed out"),
+			fsType:   eitFsType,
+			expected: MountConnectionTimedOut,
+		},
+		{
+			name:     "Protocol not supported",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs: Protocol not supported"),
+			fsType:   defaultFsType,
+			expected: MountProtocolNotSupported,
+		},
+		{
+			name:     "NFS version not supported",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs: requested NFS version or transport protocol is not supported"),
+			fsType:   defaultFsType,
+			expected: MountProtocolNotSupported,
+		},
+		{
+			name:     "Stale file handle",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs: Stale file handle"),
+			fsType:   defaultFsType,
+			expected: MountStaleFileHandle,
+		},
+		{
+			name:     "Connection reset through the tunnel",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs4: Connection reset by peer"),
+			fsType:   nfs4FsType,
+			expected: commonError.MountingTargetFailed,
+		},
+		{
+			name:     "Access denied through the tunnel",
+			err:      errors.New("mount failed: exit status 32\nOutput: mount.nfs4: access denied by server while mounting " + stunnelSource),
+			fsType:   nfs4FsType,
+			expected: MountAccessDenied,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			result := checkMountResponse(tc.err)
+			result := checkMountResponse(tc.err, tc.fsType)
 			assert.Equal(t, tc.expected, result)
 		})
 	}
@@ -27,6 +27,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"syscall"
 	"testing"
 	"unsafe"
 
@@ -38,6 +39,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	mount "k8s.io/mount-utils"
 	//"k8s.io/utils/exec"
 	//testingexec "k8s.io/utils/exec/testing"
 )
@@ -77,6 +79,7 @@ func (su *MockStatUtils) IsDevicePathNotExist(devicePath string) bool {
 }
 
 func TestNodePublishVolume(t *testing.T) {
+	stagingPath := t.TempDir()
 	testCases := []struct {
 		name          string
 		req           *csi.NodePublishVolumeRequest
@@ -88,7 +91,7 @@ func TestNodePublishVolume(t *testing.T) {
 			req: &csi.NodePublishVolumeRequest{
 				VolumeId:          defaultVolumeID,
 				TargetPath:        defaultTargetPath,
-				StagingTargetPath: defaultSourcePath,
+				StagingTargetPath: stagingPath,
 				Readonly:          false,
 				VolumeCapability:  stdVolCap[0],
 				VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz"},
@@ -100,7 +103,7 @@ func TestNodePublishVolume(t *testing.T) {
 			req: &csi.NodePublishVolumeRequest{
 				VolumeId:          defaultVolumeID,
 				TargetPath:        defaultTargetPath,
-				StagingTargetPath: defaultSourcePath,
+				StagingTargetPath: stagingPath,
 				Readonly:          false,
 				VolumeCapability:  stdVolCap[0],
 				VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz", IsEITEnabled: "true"},
@@ -108,12 +111,45 @@ func TestNodePublishVolume(t *testing.T) {
 			expErrCode: codes.OK,
 		},
 		{
-			name: "RFS profile with EIT enabled but no stunnel manager",
+			name: "Staging target path not mounted",
 			req: &csi.NodePublishVolumeRequest{
 				VolumeId:          defaultVolumeID,
 				TargetPath:        defaultTargetPath,
-				StagingTargetPath: defaultSourcePath,
+				StagingTargetPath: "/staging-not-mounted",
 				Readonly:          false,
+				VolumeCapability:  stdVolCap[0],
+				VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz"},
+			},
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name: "Valid request without staging target path",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:         defaultVolumeID,
+				TargetPath:       defaultTargetPath,
+				Readonly:         false,
+				VolumeCapability: stdVolCap[0],
+				VolumeContext:    map[string]string{NFSServerPath: "c:/abc/xyz"},
+			},
+			expErrCode: codes.OK,
+		},
+		{
+			name: "Valid request with transit encryption enabled and without staging target path",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:         defaultVolumeID,
+				TargetPath:       defaultTargetPath,
+				Readonly:         false,
+				VolumeCapability: stdVolCap[0],
+				VolumeContext:    map[string]string{NFSServerPath: "c:/abc/xyz", IsEITEnabled: "true"},
+			},
+			expErrCode: codes.OK,
+		},
+		{
+			name: "RFS profile with EIT enabled but no stunnel manager",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   defaultVolumeID,
+				TargetPath: defaultTargetPath,
+				Readonly:   false,
 				VolumeCapability: &csi.VolumeCapability{
 					AccessType: &csi.VolumeCapability_Mount{
 						Mount: &csi.VolumeCapability_MountVolume{
@@ -137,11 +173,10 @@ func TestNodePublishVolume(t *testing.T) {
 		{
 			name: "Valid request with DP2 profile and EIT enabled",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:          defaultVolumeID,
-				TargetPath:        defaultTargetPath,
-				StagingTargetPath: defaultSourcePath,
-				Readonly:          false,
-				VolumeCapability:  stdVolCap[0],
+				VolumeId:         defaultVolumeID,
+				TargetPath:       defaultTargetPath,
+				Readonly:         false,
+				VolumeCapability: stdVolCap[0],
 				VolumeContext: map[string]string{
 					NFSServerPath: "10.240.0.5:/share456",
 					IsEITEnabled:  "true",
@@ -150,14 +185,37 @@ func TestNodePublishVolume(t *testing.T) {
 			},
 			expErrCode: codes.OK, // Should succeed with IPSEC
 		},
+		{
+			name: "DP2 profile with EIT enabled ignores mount options",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   defaultVolumeID,
+				TargetPath: defaultTargetPath,
+				Readonly:   false,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessType: &csi.VolumeCapability_Mount{
+						Mount: &csi.VolumeCapability_MountVolume{
+							MountFlags: []string{"nfsvers=3"}, // the IPsec mount helper takes no options
+						},
+					},
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+					},
+				},
+				VolumeContext: map[string]string{
+					NFSServerPath: "10.240.0.5:/share456",
+					IsEITEnabled:  "true",
+					ProfileLabel:  "dp2",
+				},
+			},
+			expErrCode: codes.OK,
+		},
 		{
 			name: "Valid request with RFS profile but EIT disabled",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:          defaultVolumeID,
-				TargetPath:        defaultTargetPath,
-				StagingTargetPath: defaultSourcePath,
-				Readonly:          false,
-				VolumeCapability:  stdVolCap[0],
+				VolumeId:         defaultVolumeID,
+				TargetPath:       defaultTargetPath,
+				Readonly:         false,
+				VolumeCapability: stdVolCap[0],
 				VolumeContext: map[string]string{
 					NFSServerPath: "10.240.0.5:/share789",
 					IsEITEnabled:  "false",
@@ -224,6 +282,9 @@ func TestNodePublishVolume(t *testing.T) {
 	}
 
 	icDriver := initIBMCSIDriver(t)
+	// NodeStageVolume mounted the share at the staging path, publish bind mounts it
+	fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+	fakeMounter.MountPoints = append(fakeMounter.MountPoints, mount.MountPoint{Device: "c:/abc/xyz", Path: stagingPath, Type: defaultFsType})
 
 	for _, tc := range testCases {
 		t.Logf("Test case: %s", tc.name)
@@ -290,10 +351,9 @@ func TestNodePublishVolume_RFSWithStunnel(t *testing.T) {
 		{
 			name: "RFS profile with EIT enabled and stunnel manager configured",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:          "test-volume-rfs-001",
-				TargetPath:        defaultTargetPath,
-				StagingTargetPath: defaultSourcePath,
-				Readonly:          false,
+				VolumeId:   "test-volume-rfs-001",
+				TargetPath: defaultTargetPath,
+				Readonly:   false,
 				VolumeCapability: &csi.VolumeCapability{
 					AccessType: &csi.VolumeCapability_Mount{
 						Mount: &csi.VolumeCapability_MountVolume{
@@ -317,10 +377,9 @@ func TestNodePublishVolume_RFSWithStunnel(t *testing.T) {
 		{
 			name: "RFS profile with different volume",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:          "test-volume-rfs-002",
-				TargetPath:        "/mnt/test2",
-				StagingTargetPath: defaultSourcePath,
-				Readonly:          false,
+				VolumeId:   "test-volume-rfs-002",
+				TargetPath: "/mnt/test2",
+				Readonly:   false,
 				VolumeCapability: &csi.VolumeCapability{
 					AccessType: &csi.VolumeCapability_Mount{
 						Mount: &csi.VolumeCapability_MountVolume{
@@ -344,10 +403,9 @@ func TestNodePublishVolume_RFSWithStunnel(t *testing.T) {
 		{
 			name: "RFS profile with missing mount options",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:          "test-volume-rfs-003",
-				TargetPath:        "/mnt/test3",
-				StagingTargetPath: defaultSourcePath,
-				Readonly:          false,
+				VolumeId:   "test-volume-rfs-003",
+				TargetPath: "/mnt/test3",
+				Readonly:   false,
 				VolumeCapability: &csi.VolumeCapability{
 					AccessType: &csi.VolumeCapability_Mount{
 						Mount: &csi.VolumeCapability_MountVolume{
@@ -371,10 +429,9 @@ func TestNodePublishVolume_RFSWithStunnel(t *testing.T) {
 		{
 			name: "RFS profile with fsType 'nfs' instead of 'nfs4' (auto-corrected)",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:          "test-volume-rfs-005",
-				TargetPath:        "/mnt/test5",
-				StagingTargetPath: defaultSourcePath,
-				Readonly:          false,
+				VolumeId:   "test-volume-rfs-005",
+				TargetPath: "/mnt/test5",
+				Readonly:   false,
 				VolumeCapability: &csi.VolumeCapability{
 					AccessType: &csi.VolumeCapability_Mount{
 						Mount: &csi.VolumeCapability_MountVolume{
@@ -398,10 +455,9 @@ func TestNodePublishVolume_RFSWithStunnel(t *testing.T) {
 		{
 			name: "RFS profile with empty fsType (defaults to nfs4)",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:          "test-volume-rfs-006",
-				TargetPath:        "/mnt/test6",
-				StagingTargetPath: defaultSourcePath,
-				Readonly:          false,
+				VolumeId:   "test-volume-rfs-006",
+				TargetPath: "/mnt/test6",
+				Readonly:   false,
 				VolumeCapability: &csi.VolumeCapability{
 					AccessType: &csi.VolumeCapability_Mount{
 						Mount: &csi.VolumeCapability_MountVolume{
@@ -422,6 +478,48 @@ func TestNodePublishVolume_RFSWithStunnel(t *testing.T) {
 			},
 			expErrCode: codes.OK, // Empty fsType defaults to nfs4 and should succeed
 		},
+		{
+			name: "RFS profile with port mount option",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   "test-volume-rfs-007",
+				TargetPath: "/mnt/test7",
+				Readonly:   false,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessType: &csi.VolumeCapability_Mount{
+						Mount: &csi.VolumeCapability_MountVolume{
+							FsType:     "nfs4",
+							MountFlags: []string{"vers=4.1", "port=2049"},
+						},
+					},
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+					},
+				},
+				VolumeContext: map[string]string{
+					NFSServerPath:    "10.240.0.11:/share333",
+					IsEITEnabled:     "true",
+					ProfileLabel:     "rfs",
+					FileShareIDLabel: "share-rfs-007",
+				},
+			},
+			expErrCode: codes.InvalidArgument, // The port is set by the driver for the tunnel
+		},
+		{
+			name: "RFS sub directory volume of a parent share",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:         "share-rfs-001#target-rfs-001#pvc-sub",
+				TargetPath:       "/mnt/test8",
+				VolumeCapability: stdVolCap[0],
+				VolumeContext: map[string]string{
+					NFSServerPath:     "10.240.0.5:/share123/pvc-sub",
+					IsEITEnabled:      "true",
+					ProfileLabel:      "rfs",
+					FileShareIDLabel:  "share-rfs-001",
+					SubDirectoryLabel: "pvc-sub",
+				},
+			},
+			expErrCode: codes.OK, // The sub directory is mounted through the tunnel of the parent share
+		},
 	}
 
 	for _, tc := range testCases {
@@ -461,6 +559,17 @@ func TestNodePublishVolume_RFSWithStunnel(t *testing.T) {
 		t.Fatalf("Expected different ports for different volumes, got same port: %d", port1)
 	}
 	t.Logf("Successfully allocated different ports: %d and %d", port1, port2)
+
+	fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+	subDirectoryMounted := false
+	for _, mp := range fakeMounter.MountPoints {
+		if mp.Path == "/mnt/test8" && mp.Device == "127.0.0.1:/share123/pvc-sub" && hasMountOption(mp.Opts, fmt.Sprintf("port=%d", port1)) {
+			subDirectoryMounted = true
+		}
+	}
+	if !subDirectoryMounted {
+		t.Fatalf("Expected the sub directory to be mounted through the tunnel of the parent share, got: %v", fakeMounter.MountPoints)
+	}
 }
 
 func TestNodeUnpublishVolume(t *testing.T) {
@@ -713,6 +822,7 @@ func TestNodeGetVolumeStats(t *testing.T) {
 						Unit:      2,
 					},
 				},
+				VolumeCondition: &csi.VolumeCondition{Abnormal: false, Message: "volume is healthy"},
 			},
 			expErrCode: codes.OK,
 			expError:   "",
@@ -780,22 +890,78 @@ func TestNodeExpandVolume(t *testing.T) {
 }
 
 func TestNodeStageVolume(t *testing.T) {
+	stagingPath := t.TempDir()
+	stagedPath := t.TempDir()
 	testCases := []struct {
 		name       string
 		req        *csi.NodeStageVolumeRequest
 		expErrCode codes.Code
 	}{
-
 		{
-			name:       "Unsupported operation",
-			req:        &csi.NodeStageVolumeRequest{},
-			expErrCode: codes.Unimplemented,
+			name: "Valid request",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          defaultVolumeID,
+				StagingTargetPath: stagingPath,
+				VolumeCapability:  stdVolCap[0],
+				VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz"},
+			},
+			expErrCode: codes.OK,
+		},
+		{
+			name: "Already staged",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          defaultVolumeID,
+				StagingTargetPath: stagedPath,
+				VolumeCapability:  stdVolCap[0],
+				VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz"},
+			},
+			expErrCode: codes.OK,
+		},
+		{
+			name: "Empty volume ID",
+			req: &csi.NodeStageVolumeRequest{
+				StagingTargetPath: stagingPath,
+				VolumeCapability:  stdVolCap[0],
+				VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz"},
+			},
+			expErrCode: codes.InvalidArgument,
+		},
+		{
+			name: "Empty staging target path",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:         defaultVolumeID,
+				VolumeCapability: stdVolCap[0],
+				VolumeContext:    map[string]string{NFSServerPath: "c:/abc/xyz"},
+			},
+			expErrCode: codes.InvalidArgument,
+		},
+		{
+			name: "Not supported volume capabilities",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          defaultVolumeID,
+				StagingTargetPath: stagingPath,
+				VolumeCapability:  stdVolCapNotSupported[0],
+				VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz"},
+			},
+			expErrCode: codes.InvalidArgument,
+		},
+		{
+			name: "Missing NFS server path",
+			req: &csi.NodeStageVolumeRequest{
+				VolumeId:          defaultVolumeID,
+				StagingTargetPath: stagingPath,
+				VolumeCapability:  stdVolCap[0],
+			},
+			expErrCode: codes.InvalidArgument,
 		},
 	}
 
 	icDriver := initIBMCSIDriver(t)
+	fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+	fakeMounter.MountPoints = append(fakeMounter.MountPoints, mount.MountPoint{Device: "c:/abc/xyz", Path: stagedPath, Type: defaultFsType})
 	for _, tc := range testCases {
 		t.Logf("Test case: %s", tc.name)
+		mountCount := len(fakeMounter.GetLog())
 		_, err := icDriver.ns.NodeStageVolume(context.Background(), tc.req)
 		if err != nil {
 			serverError, ok := status.FromError(err)
@@ -810,26 +976,468 @@ func TestNodeStageVolume(t *testing.T) {
 		if tc.expErrCode != codes.OK {
 			t.Fatalf("Expected error: %v, got no error", tc.expErrCode)
 		}
+		notMounted, err := fakeMounter.IsLikelyNotMountPoint(tc.req.StagingTargetPath)
+		if err != nil || notMounted {
+			t.Fatalf("Expected staging path %s to be mounted, err: %v", tc.req.StagingTargetPath, err)
+		}
+		if tc.req.StagingTargetPath == stagedPath && len(fakeMounter.GetLog()) != mountCount {
+			t.Fatalf("Expected no mount for a staged volume, got: %v", fakeMounter.GetLog()[mountCount:])
+		}
 	}
 }
 
-func TestNodeUnstageVolume(t *testing.T) {
+func TestNodePublishVolumeStaged(t *testing.T) {
+	stagingPath := t.TempDir()
+	targetPath := filepath.Join(t.TempDir(), "target")
+	icDriver := initIBMCSIDriver(t)
+	fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+
+	req := &csi.NodePublishVolumeRequest{
+		VolumeId:          defaultVolumeID,
+		TargetPath:        targetPath,
+		StagingTargetPath: stagingPath,
+		VolumeCapability:  stdVolCap[0],
+		VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz"},
+	}
+
+	// the staging path is not mounted yet
+	_, err := icDriver.ns.NodePublishVolume(context.Background(), req)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected error code: %v, got: %v", codes.FailedPrecondition, err)
+	}
+
+	_, err = icDriver.ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          defaultVolumeID,
+		StagingTargetPath: stagingPath,
+		VolumeCapability:  stdVolCap[0],
+		VolumeContext:     map[string]string{NFSServerPath: "c:/abc/xyz"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to stage volume: %v", err)
+	}
+	_, err = icDriver.ns.NodePublishVolume(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to publish staged volume: %v", err)
+	}
+
+	var bindMount *mount.MountPoint
+	for i := range fakeMounter.MountPoints {
+		if fakeMounter.MountPoints[i].Path == targetPath {
+			bindMount = &fakeMounter.MountPoints[i]
+		}
+	}
+	if bindMount == nil || bindMount.Device != "c:/abc/xyz" {
+		t.Fatalf("Expected %s to be a bind mount of the staged share, got: %v", targetPath, bindMount)
+	}
+}
+
+func TestNodePublishVolumeReadOnly(t *testing.T) {
+	readerOnlyCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY},
+	}
+	readWriteCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{MountFlags: []string{"rw"}}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+	}
 	testCases := []struct {
 		name       string
-		req        *csi.NodeUnstageVolumeRequest
-		expErrCode codes.Code
+		readOnly   bool
+		volumeCap  *csi.VolumeCapability
+		staged     bool
+		expOptions []string
+	}{
+		{name: "Writable publish", volumeCap: stdVolCap[0], expOptions: []string{"nfsvers=4.1", "hard", "sec=sys"}},
+		{name: "Read-only publish", readOnly: true, volumeCap: stdVolCap[0], expOptions: []string{"ro", "nfsvers=4.1", "hard", "sec=sys"}},
+		{name: "Reader only access mode", volumeCap: readerOnlyCap, expOptions: []string{"ro", "nfsvers=4.1", "hard", "sec=sys"}},
+		{name: "Read-only publish overrides rw of the storage class", readOnly: true, volumeCap: readWriteCap, expOptions: []string{"ro", "nfsvers=4.1", "hard", "sec=sys"}},
+		{name: "Read-only publish of a staged share", readOnly: true, volumeCap: stdVolCap[0], staged: true, expOptions: []string{"bind", "ro"}},
+		{name: "Writable publish of a staged share", volumeCap: stdVolCap[0], staged: true, expOptions: []string{"bind"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targetPath := filepath.Join(t.TempDir(), "target")
+			icDriver := initIBMCSIDriver(t)
+			fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+			req := &csi.NodePublishVolumeRequest{
+				VolumeId:         defaultVolumeID,
+				TargetPath:       targetPath,
+				Readonly:         tc.readOnly,
+				VolumeCapability: tc.volumeCap,
+				VolumeContext:    map[string]string{NFSServerPath: "c:/abc/xyz"},
+			}
+			if tc.staged {
+				req.StagingTargetPath = t.TempDir()
+				fakeMounter.MountPoints = append(fakeMounter.MountPoints, mount.MountPoint{Device: "c:/abc/xyz", Path: req.StagingTargetPath, Type: "nfs"})
+			}
+
+			if _, err := icDriver.ns.NodePublishVolume(context.Background(), req); err != nil {
+				t.Fatalf("Failed to publish volume: %v", err)
+			}
+			var options []string
+			for _, mp := range fakeMounter.MountPoints {
+				if mp.Path == targetPath {
+					options = mp.Opts
+				}
+			}
+			if strings.Join(options, ",") != strings.Join(tc.expOptions, ",") {
+				t.Fatalf("Expected mount options: %v, got: %v", tc.expOptions, options)
+			}
+		})
+	}
+}
+
+func TestNodePublishVolumeReadOnlyEIT(t *testing.T) {
+	readerOnlyCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+		AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY},
+	}
+	eitContext := map[string]string{NFSServerPath: "10.240.0.5:/share456", IsEITEnabled: TrueStr, ProfileLabel: DP2Profile}
+	testCases := []struct {
+		name        string
+		readOnly    bool
+		volumeCap   *csi.VolumeCapability
+		stage       bool
+		remountErr  error
+		expRemount  bool
+		expErrCode  codes.Code
+		expErrCause string
+	}{
+		{name: "Writable publish", volumeCap: stdVolCap[0]},
+		{name: "Read-only publish", readOnly: true, volumeCap: stdVolCap[0], expRemount: true},
+		{name: "Reader only access mode", volumeCap: readerOnlyCap, expRemount: true},
+		{name: "Reader only access mode staged", volumeCap: readerOnlyCap, stage: true, expRemount: true},
+		{name: "Read-only remount fails", readOnly: true, volumeCap: stdVolCap[0], remountErr: errors.New("permission denied"), expRemount: true, expErrCode: codes.Internal, expErrCause: MountReadOnlyFailed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targetPath := filepath.Join(t.TempDir(), "target")
+			icDriver := initIBMCSIDriver(t)
+			var remounted []string
+			icDriver.ns.remountReadOnly = func(path string) error {
+				remounted = append(remounted, path)
+				return tc.remountErr
+			}
+
+			var err error
+			if tc.stage {
+				_, err = icDriver.ns.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+					VolumeId:          defaultVolumeID,
+					StagingTargetPath: targetPath,
+					VolumeCapability:  tc.volumeCap,
+					VolumeContext:     eitContext,
+				})
+			} else {
+				_, err = icDriver.ns.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+					VolumeId:         defaultVolumeID,
+					TargetPath:       targetPath,
+					Readonly:         tc.readOnly,
+					VolumeCapability: tc.volumeCap,
+					VolumeContext:    eitContext,
+				})
+			}
+			assert.Equal(t, tc.expErrCode, status.Code(err), "%v", err)
+			if tc.expErrCause != "" {
+				assert.Contains(t, err.Error(), tc.expErrCause)
+			}
+			if tc.expRemount {
+				assert.Equal(t, []string{targetPath}, remounted)
+			} else {
+				assert.Empty(t, remounted)
+			}
+		})
+	}
+}
+
+func TestNodePublishVolumeReadOnlyMismatch(t *testing.T) {
+	targetPath := t.TempDir()
+	icDriver := initIBMCSIDriver(t)
+	req := &csi.NodePublishVolumeRequest{
+		VolumeId:         defaultVolumeID,
+		TargetPath:       targetPath,
+		VolumeCapability: stdVolCap[0],
+		VolumeContext:    map[string]string{NFSServerPath: "c:/abc/xyz"},
+	}
+	if _, err := icDriver.ns.NodePublishVolume(context.Background(), req); err != nil {
+		t.Fatalf("Failed to publish volume: %v", err)
+	}
+
+	// the same publish again is idempotent
+	if _, err := icDriver.ns.NodePublishVolume(context.Background(), req); err != nil {
+		t.Fatalf("Expected republish to succeed, got: %v", err)
+	}
+
+	req.Readonly = true
+	_, err := icDriver.ns.NodePublishVolume(context.Background(), req)
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("Expected error code: %v, got: %v", codes.AlreadyExists, err)
+	}
+}
+
+func TestNodePublishVolumeMountGroup(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the group of a directory needs root")
+	}
+	mountGroupCap := func(group string) *csi.VolumeCapability {
+		return &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{VolumeMountGroup: group}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+		}
+	}
+	testCases := []struct {
+		name          string
+		volumeCap     *csi.VolumeCapability
+		readOnly      bool
+		staged        bool
+		missingTarget bool
+		rootGID       int
+		expErrCode    codes.Code
+		expGID        int
+	}{
+		{name: "Mount group of a direct publish", volumeCap: mountGroupCap("2000"), expGID: 2000},
+		{name: "Mount group of a staged share", volumeCap: mountGroupCap("3000"), staged: true, expGID: 3000},
+		{name: "Read-only publish keeps the group", volumeCap: mountGroupCap("2000"), readOnly: true, expGID: 0},
+		{name: "Group name", volumeCap: mountGroupCap("users"), expErrCode: codes.InvalidArgument, expGID: 0},
+		{name: "Group cannot be applied", volumeCap: mountGroupCap("2000"), missingTarget: true, expErrCode: codes.Internal},
+		{name: "Share root of another group", volumeCap: mountGroupCap("2000"), rootGID: 3000, expErrCode: codes.FailedPrecondition},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targetPath := t.TempDir()
+			if tc.missingTarget {
+				// MakeDir of the fake mounter does not create the target path
+				targetPath = filepath.Join(targetPath, "target")
+			} else if err := os.Chown(targetPath, -1, tc.rootGID); err != nil {
+				t.Fatalf("Failed to reset target path group: %v", err)
+			}
+			icDriver := initIBMCSIDriver(t)
+			fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+			req := &csi.NodePublishVolumeRequest{
+				VolumeId:         defaultVolumeID,
+				TargetPath:       targetPath,
+				Readonly:         tc.readOnly,
+				VolumeCapability: tc.volumeCap,
+				VolumeContext:    map[string]string{NFSServerPath: "c:/abc/xyz"},
+			}
+			if tc.staged {
+				req.StagingTargetPath = t.TempDir()
+				fakeMounter.MountPoints = append(fakeMounter.MountPoints, mount.MountPoint{Device: "c:/abc/xyz", Path: req.StagingTargetPath, Type: "nfs"})
+			}
+
+			_, err := icDriver.ns.NodePublishVolume(context.Background(), req)
+			if status.Code(err) != tc.expErrCode {
+				t.Fatalf("Expected error code: %v, got: %v", tc.expErrCode, err)
+			}
+			// the failed publish of a share root of another group unmounted and removed the target path
+			if tc.missingTarget || tc.rootGID != 0 {
+				return
+			}
+			info, err := os.Stat(targetPath)
+			if err != nil {
+				t.Fatalf("Failed to stat target path: %v", err)
+			}
+			if gid := int(info.Sys().(*syscall.Stat_t).Gid); gid != tc.expGID {
+				t.Fatalf("Expected target path group: %d, got: %d", tc.expGID, gid)
+			}
+			if setgid := info.Mode()&os.ModeSetgid != 0; setgid != (tc.expGID != 0) {
+				t.Fatalf("Unexpected setgid bit on target path: %v", info.Mode())
+			}
+		})
+	}
+}
+
+func TestNodePublishVolumeAlreadyMounted(t *testing.T) {
+	tempDir := t.TempDir()
+	servicesDir := filepath.Join(tempDir, "services")
+	if err := os.MkdirAll(servicesDir, 0755); err != nil {
+		t.Fatalf("Failed to create services dir: %v", err)
+	}
+	caFile := filepath.Join(tempDir, "ca-bundle.crt")
+	if err := os.WriteFile(caFile, []byte("mock CA cert"), 0644); err != nil {
+		t.Fatalf("Failed to create mock CA file: %v", err)
+	}
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+	stunnelMgr, err := rfseit.NewStunnelManagerForTesting(servicesDir, caFile, logger)
+	if err != nil {
+		t.Fatalf("Failed to create stunnel manager: %v", err)
+	}
+	stunnelMgrValue := reflect.ValueOf(stunnelMgr).Elem().FieldByName("stunnelStarted")
+	reflect.NewAt(stunnelMgrValue.Type(), unsafe.Pointer(stunnelMgrValue.UnsafeAddr())).Elem().SetBool(true)
+	tunnelPort, err := stunnelMgr.EnsureTunnel("share-rfs-001", "10.240.0.5", "test-request")
+	if err != nil {
+		t.Fatalf("Failed to create tunnel: %v", err)
+	}
+
+	rfsContext := map[string]string{
+		NFSServerPath:    "10.240.0.5:/share123",
+		IsEITEnabled:     "true",
+		ProfileLabel:     "rfs",
+		FileShareIDLabel: "share-rfs-001",
+	}
+	testCases := []struct {
+		name          string
+		mounted       mount.MountPoint
+		volumeContext map[string]string
+		staged        bool
+		expErrCode    codes.Code
 	}{
 		{
-			name:       "Unsupported Operation",
-			req:        &csi.NodeUnstageVolumeRequest{},
-			expErrCode: codes.Unimplemented,
+			name:          "Same share",
+			mounted:       mount.MountPoint{Device: "c:/abc/xyz", Type: "nfs"},
+			volumeContext: map[string]string{NFSServerPath: "c:/abc/xyz"},
+			expErrCode:    codes.OK,
+		},
+		{
+			name:          "Other share",
+			mounted:       mount.MountPoint{Device: "d:/other", Type: "nfs"},
+			volumeContext: map[string]string{NFSServerPath: "c:/abc/xyz"},
+			expErrCode:    codes.AlreadyExists,
+		},
+		{
+			name:          "Staged share without volume context",
+			mounted:       mount.MountPoint{Device: "c:/abc/xyz", Type: "nfs", Opts: []string{"bind"}},
+			volumeContext: map[string]string{},
+			staged:        true,
+			expErrCode:    codes.OK,
+		},
+		{
+			name:          "Other share than the staged one",
+			mounted:       mount.MountPoint{Device: "d:/other", Type: "nfs", Opts: []string{"bind"}},
+			volumeContext: map[string]string{},
+			staged:        true,
+			expErrCode:    codes.AlreadyExists,
+		},
+		{
+			name:          "RFS EIT share through its tunnel",
+			mounted:       mount.MountPoint{Device: "127.0.0.1:/share123", Type: "nfs4", Opts: []string{fmt.Sprintf("port=%d", tunnelPort)}},
+			volumeContext: rfsContext,
+			expErrCode:    codes.OK,
+		},
+		{
+			name:          "RFS EIT share through another tunnel port",
+			mounted:       mount.MountPoint{Device: "127.0.0.1:/share123", Type: "nfs4", Opts: []string{fmt.Sprintf("port=%d", tunnelPort+1)}},
+			volumeContext: rfsContext,
+			expErrCode:    codes.AlreadyExists,
+		},
+		{
+			name:          "RFS EIT share mounted without the tunnel",
+			mounted:       mount.MountPoint{Device: "10.240.0.5:/share123", Type: "nfs4"},
+			volumeContext: rfsContext,
+			expErrCode:    codes.AlreadyExists,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targetPath := t.TempDir()
+			icDriver := initIBMCSIDriver(t)
+			icDriver.ns.StunnelMgr = stunnelMgr
+			fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+			req := &csi.NodePublishVolumeRequest{
+				VolumeId:         defaultVolumeID,
+				TargetPath:       targetPath,
+				VolumeCapability: stdVolCap[0],
+				VolumeContext:    tc.volumeContext,
+			}
+			if tc.staged {
+				req.StagingTargetPath = t.TempDir()
+				fakeMounter.MountPoints = append(fakeMounter.MountPoints, mount.MountPoint{Device: "c:/abc/xyz", Path: req.StagingTargetPath, Type: "nfs"})
+			}
+			tc.mounted.Path = targetPath
+			fakeMounter.MountPoints = append(fakeMounter.MountPoints, tc.mounted)
+
+			_, err := icDriver.ns.NodePublishVolume(context.Background(), req)
+			if status.Code(err) != tc.expErrCode {
+				t.Fatalf("Expected error code: %v, got: %v", tc.expErrCode, err)
+			}
+			// an existing mount is never replaced
+			if len(fakeMounter.GetLog()) != 0 {
+				t.Fatalf("Expected no mount actions, got: %v", fakeMounter.GetLog())
+			}
+		})
+	}
+}
+
+func TestNodeUnstageVolume(t *testing.T) {
+	testCases := []struct {
+		name        string
+		req         func(stagingPath string) *csi.NodeUnstageVolumeRequest
+		staged      bool
+		published   bool
+		shared      bool
+		expErrCode  codes.Code
+		expUnstaged bool
+	}{
+		{
+			name: "Staged volume",
+			req: func(stagingPath string) *csi.NodeUnstageVolumeRequest {
+				return &csi.NodeUnstageVolumeRequest{VolumeId: defaultVolumeID, StagingTargetPath: stagingPath}
+			},
+			staged:      true,
+			expErrCode:  codes.OK,
+			expUnstaged: true,
+		},
+		{
+			name: "Volume still published",
+			req: func(stagingPath string) *csi.NodeUnstageVolumeRequest {
+				return &csi.NodeUnstageVolumeRequest{VolumeId: defaultVolumeID, StagingTargetPath: stagingPath}
+			},
+			staged:     true,
+			published:  true,
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name: "Share also staged for another volume",
+			req: func(stagingPath string) *csi.NodeUnstageVolumeRequest {
+				return &csi.NodeUnstageVolumeRequest{VolumeId: defaultVolumeID, StagingTargetPath: stagingPath}
+			},
+			staged:      true,
+			shared:      true,
+			expErrCode:  codes.OK,
+			expUnstaged: true,
+		},
+		{
+			name: "Not staged",
+			req: func(stagingPath string) *csi.NodeUnstageVolumeRequest {
+				return &csi.NodeUnstageVolumeRequest{VolumeId: defaultVolumeID, StagingTargetPath: stagingPath}
+			},
+			expErrCode:  codes.OK,
+			expUnstaged: true,
+		},
+		{
+			name: "Empty volume ID",
+			req: func(stagingPath string) *csi.NodeUnstageVolumeRequest {
+				return &csi.NodeUnstageVolumeRequest{StagingTargetPath: stagingPath}
+			},
+			expErrCode: codes.InvalidArgument,
+		},
+		{
+			name: "Empty staging target path",
+			req: func(_ string) *csi.NodeUnstageVolumeRequest {
+				return &csi.NodeUnstageVolumeRequest{VolumeId: defaultVolumeID}
+			},
+			expErrCode: codes.InvalidArgument,
 		},
 	}
 
-	icDriver := initIBMCSIDriver(t)
 	for _, tc := range testCases {
 		t.Logf("Test case: %s", tc.name)
-		_, err := icDriver.ns.NodeUnstageVolume(context.Background(), tc.req)
+		icDriver := initIBMCSIDriver(t)
+		fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+		stagingPath := t.TempDir()
+		if tc.staged {
+			fakeMounter.MountPoints = append(fakeMounter.MountPoints, mount.MountPoint{Device: "c:/abc/xyz", Path: stagingPath, Type: defaultFsType})
+		}
+		if tc.published {
+			podPath := filepath.Join(t.TempDir(), "pods", "pod-uid", "volumes", "kubernetes.io~csi", "pv-name", "mount")
+			fakeMounter.MountPoints = append(fakeMounter.MountPoints, mount.MountPoint{Device: "c:/abc/xyz", Path: podPath, Type: defaultFsType})
+		}
+		if tc.shared {
+			otherStagingPath := filepath.Join(t.TempDir(), "plugins", "kubernetes.io", "csi", "vpc.file.csi.ibm.io", "other", "globalmount")
+			fakeMounter.MountPoints = append(fakeMounter.MountPoints, mount.MountPoint{Device: "c:/abc/xyz", Path: otherStagingPath, Type: defaultFsType})
+		}
+
+		_, err := icDriver.ns.NodeUnstageVolume(context.Background(), tc.req(stagingPath))
 		if err != nil {
 			serverError, ok := status.FromError(err)
 			if !ok {
@@ -838,11 +1446,19 @@ func TestNodeUnstageVolume(t *testing.T) {
 			if serverError.Code() != tc.expErrCode {
 				t.Fatalf("Expected error code: %v, got: %v. err : %v", tc.expErrCode, serverError.Code(), err)
 			}
+			if tc.staged {
+				if notMounted, _ := fakeMounter.IsLikelyNotMountPoint(stagingPath); notMounted {
+					t.Fatalf("Expected staging path to stay mounted")
+				}
+			}
 			continue
 		}
 		if tc.expErrCode != codes.OK {
 			t.Fatalf("Expected error: %v, got no error", tc.expErrCode)
 		}
+		if _, err := os.Stat(stagingPath); tc.expUnstaged && !os.IsNotExist(err) {
+			t.Fatalf("Expected staging path to be removed, err: %v", err)
+		}
 	}
 }
 
@@ -0,0 +1,318 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/tracing"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"go.uber.org/zap"
+)
+
+// getProviderSession returns the VPC provider session for a controller RPC,
+// reusing the cached session when there is one. The session is wrapped so that
+// every backend call is timed, traced as a child span of the RPC and reported
+// to the circuit breaker, and a rejected token drops the cached session. Errors are returned as CSI errors, errCode is used
+// when the session itself cannot be created.
+func (csiCS *CSIControllerServer) getProviderSession(ctx context.Context, ctxLogger *zap.Logger, requestID string, errCode string) (provider.Session, error) {
+	return csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, errCode, accountTarget{})
+}
+
+// getAccountProviderSession is getProviderSession for the account of target. Sessions of other
+// accounts are cached separately and are not guarded by the circuit breaker: their failures are
+// caused by the credentials or quota of that account rather than by the VPC backend, and must not
+// fail fast the requests of the cluster account or of other accounts.
+func (csiCS *CSIControllerServer) getAccountProviderSession(ctx context.Context, ctxLogger *zap.Logger, requestID string, errCode string, target accountTarget) (provider.Session, error) {
+	tracing.SetRequestID(ctx, requestID)
+	if target.crossAccount() {
+		session, sessions, err := csiCS.Accounts.Get(ctx, ctxLogger, target)
+		if err != nil {
+			return nil, commonError.GetCSIError(ctxLogger, errCode, requestID, err)
+		}
+		return &instrumentedSession{Session: session, ctx: ctx, requestID: requestID, sessions: sessions}, nil
+	}
+
+	if allowed, retryIn := csiCS.Breaker.Allow(); !allowed {
+		return nil, commonError.GetCSIError(ctxLogger, BackendCircuitOpen, requestID, nil, csiCS.Breaker.FailureThreshold(), retryIn.Round(time.Second).String())
+	}
+	var session provider.Session
+	var err error
+	if csiCS.Sessions != nil {
+		session, err = csiCS.Sessions.Get(ctx, ctxLogger)
+	} else {
+		session, err = csiCS.CSIProvider.GetProviderSession(ctx, ctxLogger)
+	}
+	if err != nil {
+		csiCS.Breaker.RecordResult(err)
+		return nil, commonError.GetCSIError(ctxLogger, errCode, requestID, err)
+	}
+	return &instrumentedSession{Session: session, ctx: ctx, requestID: requestID, breaker: csiCS.Breaker, sessions: csiCS.Sessions}, nil
+}
+
+// instrumentedSession decorates provider.Session and reports the outcome of
+// every backend call. breaker is nil for the sessions of other accounts.
+type instrumentedSession struct {
+	provider.Session
+	ctx       context.Context
+	requestID string
+	breaker   *CircuitBreaker
+	sessions  *SessionCache
+}
+
+// begin marks the start of a backend call, the returned func must be called with its result
+func (s *instrumentedSession) begin(operation string) func(error) {
+	start := time.Now()
+	_, span := tracing.StartSpan(s.ctx, "provider."+operation, s.requestID)
+	return func(err error) {
+		tracing.EndSpan(span, err)
+		driverMetrics.ProviderCallDuration.WithLabelValues(operation, driverMetrics.Result(err)).Observe(time.Since(start).Seconds())
+		s.breaker.RecordResult(err)
+		s.sessions.RecordResult(err)
+	}
+}
+
+// GetVolumeProfileByName ...
+func (s *instrumentedSession) GetVolumeProfileByName(name string) (*provider.Profile, error) {
+	done := s.begin("GetVolumeProfileByName")
+	profile, err := s.Session.GetVolumeProfileByName(name)
+	done(err)
+	return profile, err
+}
+
+// CreateVolume ...
+func (s *instrumentedSession) CreateVolume(volumeRequest provider.Volume) (*provider.Volume, error) {
+	done := s.begin("CreateVolume")
+	volume, err := s.Session.CreateVolume(volumeRequest)
+	done(err)
+	return volume, err
+}
+
+// CreateVolumeFromSnapshot ...
+func (s *instrumentedSession) CreateVolumeFromSnapshot(snapshot provider.Snapshot, tags map[string]string) (*provider.Volume, error) {
+	done := s.begin("CreateVolumeFromSnapshot")
+	volume, err := s.Session.CreateVolumeFromSnapshot(snapshot, tags)
+	done(err)
+	return volume, err
+}
+
+// UpdateVolume ...
+func (s *instrumentedSession) UpdateVolume(volume provider.Volume) error {
+	done := s.begin("UpdateVolume")
+	err := s.Session.UpdateVolume(volume)
+	done(err)
+	return err
+}
+
+// DeleteVolume ...
+func (s *instrumentedSession) DeleteVolume(volume *provider.Volume) error {
+	done := s.begin("DeleteVolume")
+	err := s.Session.DeleteVolume(volume)
+	done(err)
+	return err
+}
+
+// GetVolume ...
+func (s *instrumentedSession) GetVolume(id string) (*provider.Volume, error) {
+	done := s.begin("GetVolume")
+	volume, err := s.Session.GetVolume(id)
+	done(err)
+	return volume, err
+}
+
+// GetVolumeByName ...
+func (s *instrumentedSession) GetVolumeByName(name string) (*provider.Volume, error) {
+	done := s.begin("GetVolumeByName")
+	volume, err := s.Session.GetVolumeByName(name)
+	done(err)
+	return volume, err
+}
+
+// ListVolumes ...
+func (s *instrumentedSession) ListVolumes(limit int, start string, tags map[string]string) (*provider.VolumeList, error) {
+	done := s.begin("ListVolumes")
+	volumes, err := s.Session.ListVolumes(limit, start, tags)
+	done(err)
+	return volumes, err
+}
+
+// GetVolumeByRequestID ...
+func (s *instrumentedSession) GetVolumeByRequestID(requestID string) (*provider.Volume, error) {
+	done := s.begin("GetVolumeByRequestID")
+	volume, err := s.Session.GetVolumeByRequestID(requestID)
+	done(err)
+	return volume, err
+}
+
+// AuthorizeVolume ...
+func (s *instrumentedSession) AuthorizeVolume(volumeAuthorization provider.VolumeAuthorization) error {
+	done := s.begin("AuthorizeVolume")
+	err := s.Session.AuthorizeVolume(volumeAuthorization)
+	done(err)
+	return err
+}
+
+// ExpandVolume ...
+func (s *instrumentedSession) ExpandVolume(expandVolumeRequest provider.ExpandVolumeRequest) (int64, error) {
+	done := s.begin("ExpandVolume")
+	size, err := s.Session.ExpandVolume(expandVolumeRequest)
+	done(err)
+	return size, err
+}
+
+// AttachVolume ...
+func (s *instrumentedSession) AttachVolume(attachRequest provider.VolumeAttachmentRequest) (*provider.VolumeAttachmentResponse, error) {
+	done := s.begin("AttachVolume")
+	response, err := s.Session.AttachVolume(attachRequest)
+	done(err)
+	return response, err
+}
+
+// DetachVolume ...
+func (s *instrumentedSession) DetachVolume(detachRequest provider.VolumeAttachmentRequest) (*http.Response, error) {
+	done := s.begin("DetachVolume")
+	response, err := s.Session.DetachVolume(detachRequest)
+	done(err)
+	return response, err
+}
+
+// WaitForAttachVolume ...
+func (s *instrumentedSession) WaitForAttachVolume(attachRequest provider.VolumeAttachmentRequest) (*provider.VolumeAttachmentResponse, error) {
+	done := s.begin("WaitForAttachVolume")
+	response, err := s.Session.WaitForAttachVolume(attachRequest)
+	done(err)
+	return response, err
+}
+
+// WaitForDetachVolume ...
+func (s *instrumentedSession) WaitForDetachVolume(detachRequest provider.VolumeAttachmentRequest) error {
+	done := s.begin("WaitForDetachVolume")
+	err := s.Session.WaitForDetachVolume(detachRequest)
+	done(err)
+	return err
+}
+
+// GetVolumeAttachment ...
+func (s *instrumentedSession) GetVolumeAttachment(attachRequest provider.VolumeAttachmentRequest) (*provider.VolumeAttachmentResponse, error) {
+	done := s.begin("GetVolumeAttachment")
+	response, err := s.Session.GetVolumeAttachment(attachRequest)
+	done(err)
+	return response, err
+}
+
+// CreateSnapshot ...
+func (s *instrumentedSession) CreateSnapshot(sourceVolumeID string, snapshotParameters provider.SnapshotParameters) (*provider.Snapshot, error) {
+	done := s.begin("CreateSnapshot")
+	snapshot, err := s.Session.CreateSnapshot(sourceVolumeID, snapshotParameters)
+	done(err)
+	return snapshot, err
+}
+
+// DeleteSnapshot ...
+func (s *instrumentedSession) DeleteSnapshot(snapshot *provider.Snapshot) error {
+	done := s.begin("DeleteSnapshot")
+	err := s.Session.DeleteSnapshot(snapshot)
+	done(err)
+	return err
+}
+
+// GetSnapshot ...
+func (s *instrumentedSession) GetSnapshot(snapshotID string, sourceVolumeID ...string) (*provider.Snapshot, error) {
+	done := s.begin("GetSnapshot")
+	snapshot, err := s.Session.GetSnapshot(snapshotID, sourceVolumeID...)
+	done(err)
+	return snapshot, err
+}
+
+// GetSnapshotByName ...
+func (s *instrumentedSession) GetSnapshotByName(snapshotName string, scopeID ...string) (*provider.Snapshot, error) {
+	done := s.begin("GetSnapshotByName")
+	snapshot, err := s.Session.GetSnapshotByName(snapshotName, scopeID...)
+	done(err)
+	return snapshot, err
+}
+
+// ListSnapshots ...
+func (s *instrumentedSession) ListSnapshots(limit int, start string, tags map[string]string) (*provider.SnapshotList, error) {
+	done := s.begin("ListSnapshots")
+	snapshots, err := s.Session.ListSnapshots(limit, start, tags)
+	done(err)
+	return snapshots, err
+}
+
+// CreateVolumeAccessPoint ...
+func (s *instrumentedSession) CreateVolumeAccessPoint(accessPointRequest provider.VolumeAccessPointRequest) (*provider.VolumeAccessPointResponse, error) {
+	done := s.begin("CreateVolumeAccessPoint")
+	response, err := s.Session.CreateVolumeAccessPoint(accessPointRequest)
+	done(err)
+	return response, err
+}
+
+// DeleteVolumeAccessPoint ...
+func (s *instrumentedSession) DeleteVolumeAccessPoint(deleteAccessPointRequest provider.VolumeAccessPointRequest) (*http.Response, error) {
+	done := s.begin("DeleteVolumeAccessPoint")
+	response, err := s.Session.DeleteVolumeAccessPoint(deleteAccessPointRequest)
+	done(err)
+	return response, err
+}
+
+// WaitForCreateVolumeAccessPoint ...
+func (s *instrumentedSession) WaitForCreateVolumeAccessPoint(accessPointRequest provider.VolumeAccessPointRequest) (*provider.VolumeAccessPointResponse, error) {
+	done := s.begin("WaitForCreateVolumeAccessPoint")
+	response, err := s.Session.WaitForCreateVolumeAccessPoint(accessPointRequest)
+	done(err)
+	return response, err
+}
+
+// WaitForDeleteVolumeAccessPoint ...
+func (s *instrumentedSession) WaitForDeleteVolumeAccessPoint(deleteAccessPointRequest provider.VolumeAccessPointRequest) error {
+	done := s.begin("WaitForDeleteVolumeAccessPoint")
+	err := s.Session.WaitForDeleteVolumeAccessPoint(deleteAccessPointRequest)
+	done(err)
+	return err
+}
+
+// GetVolumeAccessPoint ...
+func (s *instrumentedSession) GetVolumeAccessPoint(accessPointRequest provider.VolumeAccessPointRequest) (*provider.VolumeAccessPointResponse, error) {
+	done := s.begin("GetVolumeAccessPoint")
+	response, err := s.Session.GetVolumeAccessPoint(accessPointRequest)
+	done(err)
+	return response, err
+}
+
+// GetSubnetForVolumeAccessPoint ...
+func (s *instrumentedSession) GetSubnetForVolumeAccessPoint(subnetRequest provider.SubnetRequest) (string, error) {
+	done := s.begin("GetSubnetForVolumeAccessPoint")
+	subnet, err := s.Session.GetSubnetForVolumeAccessPoint(subnetRequest)
+	done(err)
+	return subnet, err
+}
+
+// GetSecurityGroupForVolumeAccessPoint ...
+func (s *instrumentedSession) GetSecurityGroupForVolumeAccessPoint(securityGroupRequest provider.SecurityGroupRequest) (string, error) {
+	done := s.begin("GetSecurityGroupForVolumeAccessPoint")
+	securityGroup, err := s.Session.GetSecurityGroupForVolumeAccessPoint(securityGroupRequest)
+	done(err)
+	return securityGroup, err
+}
@@ -0,0 +1,72 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/tracing"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider/fake"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProviderSessionTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(previous)
+
+	icDriver := initIBMCSIDriver(t)
+	fakeSession, err := icDriver.cs.CSIProvider.GetProviderSession(context.Background(), icDriver.logger)
+	assert.Nil(t, err)
+	fakeStructSession, ok := fakeSession.(*fake.FakeSession)
+	assert.True(t, ok)
+	fakeStructSession.ListVolumesReturns(&provider.VolumeList{}, nil)
+	// drop the spans of the driver setup
+	exporter.Reset()
+
+	ctx, rpcSpan := tracing.Tracer().Start(context.Background(), "/csi.v1.Controller/ListVolumes")
+	_, err = icDriver.cs.ListVolumes(ctx, &csi.ListVolumesRequest{})
+	assert.Nil(t, err)
+	rpcSpan.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	providerSpan, serverSpan := spans[0], spans[1]
+	assert.Equal(t, "provider.ListVolumes", providerSpan.Name)
+	assert.Equal(t, serverSpan.SpanContext.SpanID(), providerSpan.Parent.SpanID())
+
+	// requestID is set on both the RPC span and the provider call span
+	var requestID string
+	for _, attr := range serverSpan.Attributes {
+		if attr.Key == tracing.RequestIDKey {
+			requestID = attr.Value.AsString()
+		}
+	}
+	assert.NotEmpty(t, requestID)
+	assert.Contains(t, providerSpan.Attributes, tracing.RequestIDKey.String(requestID))
+}
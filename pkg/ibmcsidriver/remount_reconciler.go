@@ -0,0 +1,344 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// StaleMountRemountedReason event reason when a stale mount of the volume was remounted in place
+	StaleMountRemountedReason = "StaleMountRemounted"
+
+	// StaleMountRemountFailedReason event reason when a stale mount of the volume could not be remounted
+	StaleMountRemountFailedReason = "StaleMountRemountFailed"
+
+	// DefaultKubeletDir kubelet root directory as mounted into the node server
+	DefaultKubeletDir = "/var/lib/kubelet"
+
+	// DefaultRemountInterval time between two scans of the node mounts for stale NFS mounts
+	DefaultRemountInterval = time.Minute
+
+	// DefaultRemountRetryInterval minimum time between two remounts of the same volume
+	DefaultRemountRetryInterval = 5 * time.Minute
+
+	// remountPVGetTimeout bounds the PV lookups of a scan that found stale mounts
+	remountPVGetTimeout = 30 * time.Second
+)
+
+// RemountConfig settings of the stale mount reconciler
+type RemountConfig struct {
+	// KubeletDir kubelet root directory, the pod and staging paths of the volumes are below it
+	KubeletDir string
+	// Interval time between two scans of the node mounts
+	Interval time.Duration
+	// RetryInterval minimum time between two remount attempts of the same volume
+	RetryInterval time.Duration
+}
+
+// RemountReconciler repairs NFS mounts of this driver that went stale on the node, e.g. after the share
+// target was recreated, its IP address changed or stunnel was restarted. Each scan probes the staging and
+// pod mounts below the kubelet directory, looks up the current volume context of the stale ones in their
+// PV and replaces them in place: the staging mount is lazily unmounted and mounted again, re-ensuring the
+// stunnel tunnel of RFS EIT volumes, then the pod target paths are bind mounted from it again. Volumes
+// published without staging are remounted directly at the target path. Pods keep their target path, open
+// files on the old mount fail with ESTALE but new opens go to the new mount.
+type RemountReconciler struct {
+	ns         *CSINodeServer
+	client     kubernetes.Interface
+	events     *VolumeEventRecorder
+	driverName string
+	config     RemountConfig
+	logger     *zap.Logger
+
+	// detach lazily unmounts path, a hung NFS mount can not be unmounted normally
+	detach func(path string) error
+	now    func() time.Time
+
+	lastAttempt map[string]time.Time
+	stopOnce    sync.Once
+	stopCh      chan struct{}
+}
+
+// NewRemountReconciler unset config fields take their defaults, the driver must be set up in node mode
+func NewRemountReconciler(icDriver *IBMCSIDriver, client kubernetes.Interface, config RemountConfig, logger *zap.Logger) *RemountReconciler {
+	if config.KubeletDir == "" {
+		config.KubeletDir = DefaultKubeletDir
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultRemountInterval
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = DefaultRemountRetryInterval
+	}
+	return &RemountReconciler{
+		ns:          icDriver.ns,
+		client:      client,
+		events:      icDriver.events,
+		driverName:  icDriver.name,
+		config:      config,
+		logger:      logger,
+		detach:      lazyUnmount,
+		now:         time.Now,
+		lastAttempt: map[string]time.Time{},
+		stopCh:      make(chan struct{}),
+	}
+}
+
+func lazyUnmount(path string) error {
+	return unix.Unmount(path, unix.MNT_DETACH)
+}
+
+// Start scans the node mounts every interval until Stop is called
+func (rr *RemountReconciler) Start() {
+	rr.logger.Info("Starting stale mount reconciler", zap.String("kubeletDir", rr.config.KubeletDir), zap.Duration("interval", rr.config.Interval))
+	ticker := time.NewTicker(rr.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rr.stopCh:
+			return
+		case <-ticker.C:
+			rr.Reconcile(context.Background())
+		}
+	}
+}
+
+// Stop ...
+func (rr *RemountReconciler) Stop() {
+	rr.stopOnce.Do(func() {
+		close(rr.stopCh)
+	})
+}
+
+// volumeMounts mounts of one PV on the node, found by path
+type volumeMounts struct {
+	staging  []string
+	targets  []string
+	readOnly map[string]bool
+	stale    bool
+}
+
+// mountOptions options of the NFS mount replacing the mount at path
+func (vm *volumeMounts) mountOptions(pv *v1.PersistentVolume, path string) []string {
+	options := append([]string{}, pv.Spec.MountOptions...)
+	if vm.readOnly[path] {
+		options = append(options, readOnlyMountOption)
+	}
+	return options
+}
+
+// Reconcile runs one scan and remounts the volumes with stale mounts
+func (rr *RemountReconciler) Reconcile(ctx context.Context) {
+	mountPoints, err := rr.ns.Mounter.List()
+	if err != nil {
+		rr.logger.Warn("Unable to list node mounts", zap.Error(err))
+		return
+	}
+
+	// volumes are keyed by the PV name, or by the hash of the volume handle for current kubelet staging
+	// paths without volume data
+	volumes := map[string]*volumeMounts{}
+	for _, mp := range mountPoints {
+		if !strings.HasPrefix(mp.Type, defaultFsType) {
+			continue
+		}
+		key, staging, ok := rr.volumeKey(mp.Path)
+		if !ok {
+			continue
+		}
+		volume := volumes[key]
+		if volume == nil {
+			volume = &volumeMounts{readOnly: map[string]bool{}}
+			volumes[key] = volume
+		}
+		if staging {
+			volume.staging = append(volume.staging, mp.Path)
+		} else {
+			volume.targets = append(volume.targets, mp.Path)
+		}
+		// read-only publishes stay read-only after the remount
+		volume.readOnly[mp.Path] = hasMountOption(mp.Opts, readOnlyMountOption)
+		if reason, err := rr.ns.MountHealth.probeReason(ctx, mp.Path); reason != "" {
+			rr.logger.Warn("Found stale mount", zap.String("path", mp.Path), zap.String("reason", reason), zap.Error(err))
+			volume.stale = true
+		}
+	}
+
+	stale := map[string]*volumeMounts{}
+	for key, volume := range volumes {
+		if volume.stale {
+			stale[key] = volume
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(stale))
+	for key := range stale {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pvs, err := rr.driverPVs(ctx, keys)
+	if err != nil {
+		rr.logger.Warn("Unable to get PVs of stale mounts", zap.Error(err))
+		return
+	}
+	// the staging and pod mounts of a PV share its key, a stale staging mount makes the bind mounts
+	// of the pods stale too, even if they were not found stale yet
+	for _, key := range keys {
+		pv := pvs[key]
+		if pv == nil {
+			rr.logger.Warn("No PV of this driver found for stale mount, skipping", zap.String("volume", key), zap.Strings("paths", append(stale[key].staging, stale[key].targets...)))
+			continue
+		}
+		rr.remountVolume(ctx, pv, stale[key])
+	}
+}
+
+// volumeKey returns the PV name of a kubelet pod or staging path of this driver, the volume handle hash
+// of a current staging path whose volume data cannot be read
+func (rr *RemountReconciler) volumeKey(path string) (key string, staging bool, ok bool) {
+	rel, err := filepath.Rel(rr.config.KubeletDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false, false
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	switch {
+	// pods/<pod uid>/volumes/kubernetes.io~csi/<pv name>/mount
+	case len(parts) == 6 && parts[0] == "pods" && parts[2] == "volumes" && parts[3] == "kubernetes.io~csi" && parts[5] == "mount":
+		return parts[4], false, true
+	// plugins/kubernetes.io/csi/<driver>/<sha256 of the volume handle>/globalmount
+	case len(parts) == 6 && parts[0] == "plugins" && parts[2] == "csi" && parts[3] == rr.driverName && parts[5] == "globalmount":
+		if name := stagedPVName(filepath.Dir(path), rr.driverName); name != "" {
+			return name, true, true
+		}
+		return parts[4], true, true
+	// plugins/kubernetes.io/csi/pv/<pv name>/globalmount, used by kubelet before 1.24
+	case len(parts) == 6 && parts[0] == "plugins" && parts[2] == "csi" && parts[3] == "pv" && parts[5] == "globalmount":
+		return parts[4], true, true
+	}
+	return "", false, false
+}
+
+// driverPVs returns the PVs of this driver named by keys, the node is only allowed to get PVs by name
+func (rr *RemountReconciler) driverPVs(ctx context.Context, keys []string) (map[string]*v1.PersistentVolume, error) {
+	ctx, cancel := context.WithTimeout(ctx, remountPVGetTimeout)
+	defer cancel()
+	pvs := map[string]*v1.PersistentVolume{}
+	for _, key := range keys {
+		pv, err := rr.client.CoreV1().PersistentVolumes().Get(ctx, key, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != rr.driverName {
+			continue
+		}
+		pvs[key] = pv
+	}
+	return pvs, nil
+}
+
+// remountVolume replaces the mounts of one volume, at most once per retry interval
+func (rr *RemountReconciler) remountVolume(ctx context.Context, pv *v1.PersistentVolume, volume *volumeMounts) {
+	now := rr.now()
+	if last, ok := rr.lastAttempt[pv.Name]; ok && now.Sub(last) < rr.config.RetryInterval {
+		rr.logger.Info("Stale mount was remounted recently, waiting for the retry interval", zap.String("pv", pv.Name), zap.Time("lastAttempt", last))
+		return
+	}
+	rr.lastAttempt[pv.Name] = now
+
+	ctxLogger, requestID := getContextLogger(ctx, false)
+	ctxLogger.Info("Remounting stale mounts", zap.String("pv", pv.Name), zap.Strings("staging", volume.staging), zap.Strings("targets", volume.targets))
+	err := rr.remount(ctx, ctxLogger, requestID, pv, volume)
+	if err != nil {
+		ctxLogger.Error("Failed to remount stale mounts", zap.String("pv", pv.Name), zap.Error(err))
+		driverMetrics.StaleMountRemounts.WithLabelValues("failure").Inc()
+		rr.events.PVEvent(pv, v1.EventTypeWarning, StaleMountRemountFailedReason,
+			fmt.Sprintf("Stale NFS mounts could not be remounted, pods using the volume on this node may need to be restarted: %v", err))
+		return
+	}
+	ctxLogger.Info("Remounted stale mounts", zap.String("pv", pv.Name))
+	driverMetrics.StaleMountRemounts.WithLabelValues("success").Inc()
+	rr.events.PVEvent(pv, v1.EventTypeNormal, StaleMountRemountedReason,
+		fmt.Sprintf("Stale NFS mounts were remounted: %s", strings.Join(append(append([]string{}, volume.staging...), volume.targets...), ", ")))
+}
+
+func (rr *RemountReconciler) remount(ctx context.Context, ctxLogger *zap.Logger, requestID string, pv *v1.PersistentVolume, volume *volumeMounts) error {
+	volumeID := pv.Spec.CSI.VolumeHandle
+	volumeContext := pv.Spec.CSI.VolumeAttributes
+	if volumeContext[NFSServerPath] == "" {
+		return fmt.Errorf("PV %s has no '%s' volume attribute", pv.Name, NFSServerPath)
+	}
+
+	for _, stagingPath := range volume.staging {
+		if err := rr.replace(stagingPath, func() error {
+			return rr.ns.mountShare(ctx, ctxLogger, requestID, volumeID, volumeContext, volume.mountOptions(pv, stagingPath), stagingPath)
+		}); err != nil {
+			return err
+		}
+	}
+	for _, target := range volume.targets {
+		if err := rr.replace(target, func() error {
+			if len(volume.staging) != 0 {
+				return rr.ns.bindStagedShare(ctx, ctxLogger, requestID, volumeID, volume.staging[0], target, volume.readOnly[target])
+			}
+			return rr.ns.mountShare(ctx, ctxLogger, requestID, volumeID, volumeContext, volume.mountOptions(pv, target), target)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replace detaches the mount at path and mounts it again, holding the path lock of the node RPCs
+func (rr *RemountReconciler) replace(path string, mountFn func() error) error {
+	rr.ns.mutex.Lock(path)
+	defer rr.ns.mutex.Unlock(path)
+
+	if err := rr.detach(path); err != nil {
+		// unpublished or unstaged since the scan
+		if errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOENT) {
+			return nil
+		}
+		return fmt.Errorf("unmounting %s: %w", path, err)
+	}
+	rr.ns.MountHealth.forget(path)
+	return mountFn()
+}
@@ -0,0 +1,289 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	mount "k8s.io/mount-utils"
+)
+
+const (
+	remountVolumeHandle = "share-1#target-1"
+	remountPVName       = "pvc-remount"
+)
+
+func remountTestPV(nfsServerPath string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: remountPVName},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{CSI: &v1.CSIPersistentVolumeSource{
+				Driver:           "mydriver",
+				VolumeHandle:     remountVolumeHandle,
+				VolumeAttributes: map[string]string{NFSServerPath: nfsServerPath},
+			}},
+			MountOptions: []string{"vers=4.1"},
+		},
+	}
+}
+
+type remountTest struct {
+	reconciler *RemountReconciler
+	mounter    *mount.FakeMounter
+	recorder   *record.FakeRecorder
+	client     *fake.Clientset
+	staging    string
+	target     string
+}
+
+// newRemountTest returns a reconciler with the staging and pod target path of one volume, the stale paths fail with ESTALE
+func newRemountTest(t *testing.T, stale map[string]bool, objects ...runtime.Object) *remountTest {
+	logger, teardown := GetTestLogger(t)
+	t.Cleanup(teardown)
+
+	icDriver := initIBMCSIDriver(t)
+	kubeletDir := t.TempDir()
+	rt := &remountTest{
+		mounter:  icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter),
+		recorder: record.NewFakeRecorder(10),
+		staging:  filepath.Join(kubeletDir, "plugins", "kubernetes.io", "csi", "mydriver", fmt.Sprintf("%x", sha256.Sum256([]byte(remountVolumeHandle))), "globalmount"),
+		target:   filepath.Join(kubeletDir, "pods", "pod-uid", "volumes", "kubernetes.io~csi", remountPVName, "mount"),
+	}
+	for _, path := range []string{rt.staging, rt.target} {
+		if err := os.MkdirAll(path, 0750); err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+	}
+	writeVolumeData(t, filepath.Dir(rt.staging), "mydriver", remountPVName)
+	icDriver.ns.MountHealth.probe = func(path string) error {
+		if stale[filepath.Base(path)] {
+			return unix.ESTALE
+		}
+		return nil
+	}
+
+	rt.client = fake.NewSimpleClientset(objects...)
+	rt.reconciler = NewRemountReconciler(icDriver, rt.client, RemountConfig{KubeletDir: kubeletDir}, logger)
+	rt.reconciler.events = newVolumeEventRecorder(rt.client, rt.recorder, "mydriver", false, logger)
+	rt.reconciler.detach = rt.mounter.Unmount
+	return rt
+}
+
+// writeVolumeData writes the volume data kubelet stores next to a staging path
+func writeVolumeData(t *testing.T, dir, driverName, pvName string) {
+	data := fmt.Sprintf(`{"driverName":%q,"specVolID":%q,"volumeHandle":%q}`, driverName, pvName, remountVolumeHandle)
+	if err := os.WriteFile(filepath.Join(dir, kubeletVolumeDataFile), []byte(data), 0600); err != nil {
+		t.Fatalf("Failed to write volume data: %v", err)
+	}
+}
+
+func (rt *remountTest) devices() map[string]string {
+	devices := map[string]string{}
+	mountPoints, _ := rt.mounter.List()
+	for _, mp := range mountPoints {
+		devices[mp.Path] = mp.Device
+	}
+	return devices
+}
+
+func (rt *remountTest) events() []string {
+	events := []string{}
+	for {
+		select {
+		case event := <-rt.recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRemountReconcilerStagedVolume(t *testing.T) {
+	rt := newRemountTest(t, map[string]bool{"globalmount": true, "mount": true}, remountTestPV("10.0.0.2:/share"))
+	rt.mounter.MountPoints = []mount.MountPoint{
+		{Device: "10.0.0.1:/share", Path: rt.staging, Type: "nfs4"},
+		{Device: "10.0.0.1:/share", Path: rt.target, Type: "nfs4"},
+		{Device: "/dev/vda1", Path: "/", Type: "ext4"},
+	}
+
+	rt.reconciler.Reconcile(context.Background())
+
+	devices := rt.devices()
+	assert.Equal(t, "10.0.0.2:/share", devices[rt.staging])
+	// the pod target path is bind mounted from the new staging mount
+	assert.Equal(t, "10.0.0.2:/share", devices[rt.target])
+	assert.Equal(t, "/dev/vda1", devices["/"])
+	events := rt.events()
+	if assert.Len(t, events, 1) {
+		assert.Contains(t, events[0], "Normal "+StaleMountRemountedReason)
+	}
+
+	// still stale, the volume is not remounted again before the retry interval
+	rt.reconciler.Reconcile(context.Background())
+	assert.Empty(t, rt.events())
+
+	rt.reconciler.now = func() time.Time { return time.Now().Add(DefaultRemountRetryInterval) }
+	rt.reconciler.Reconcile(context.Background())
+	assert.Len(t, rt.events(), 1)
+}
+
+func TestRemountReconcilerStaleStagingOnly(t *testing.T) {
+	rt := newRemountTest(t, map[string]bool{"globalmount": true}, remountTestPV("10.0.0.2:/share"))
+	rt.mounter.MountPoints = []mount.MountPoint{
+		{Device: "10.0.0.1:/share", Path: rt.staging, Type: "nfs4"},
+		{Device: "10.0.0.1:/share", Path: rt.target, Type: "nfs4"},
+	}
+
+	rt.reconciler.Reconcile(context.Background())
+
+	// the bind mount of the pod shares the stale mount and is replaced as well
+	devices := rt.devices()
+	assert.Equal(t, "10.0.0.2:/share", devices[rt.staging])
+	assert.Equal(t, "10.0.0.2:/share", devices[rt.target])
+	// the PV is read by the name in the volume data of the staging path
+	if assert.Len(t, rt.client.Actions(), 1) {
+		assert.Equal(t, "get", rt.client.Actions()[0].GetVerb())
+	}
+}
+
+func TestRemountReconcilerStagingWithoutVolumeData(t *testing.T) {
+	rt := newRemountTest(t, map[string]bool{"globalmount": true}, remountTestPV("10.0.0.2:/share"))
+	assert.Nil(t, os.Remove(filepath.Join(filepath.Dir(rt.staging), kubeletVolumeDataFile)))
+	rt.mounter.MountPoints = []mount.MountPoint{
+		{Device: "10.0.0.1:/share", Path: rt.staging, Type: "nfs4"},
+	}
+
+	rt.reconciler.Reconcile(context.Background())
+
+	// the PV of a volume handle hash is unknown to a node that only gets PVs by name
+	assert.Equal(t, "10.0.0.1:/share", rt.devices()[rt.staging])
+	assert.Empty(t, rt.events())
+}
+
+func TestRemountReconcilerDirectVolume(t *testing.T) {
+	rt := newRemountTest(t, map[string]bool{"mount": true}, remountTestPV("10.0.0.2:/share"))
+	rt.mounter.MountPoints = []mount.MountPoint{
+		{Device: "10.0.0.1:/share", Path: rt.target, Type: "nfs"},
+	}
+
+	rt.reconciler.Reconcile(context.Background())
+
+	devices := rt.devices()
+	assert.Equal(t, "10.0.0.2:/share", devices[rt.target])
+	assert.NotContains(t, devices, rt.staging)
+}
+
+func TestRemountReconcilerHealthyMounts(t *testing.T) {
+	rt := newRemountTest(t, map[string]bool{}, remountTestPV("10.0.0.2:/share"))
+	rt.mounter.MountPoints = []mount.MountPoint{
+		{Device: "10.0.0.1:/share", Path: rt.staging, Type: "nfs4"},
+		{Device: "10.0.0.1:/share", Path: rt.target, Type: "nfs4"},
+	}
+
+	rt.reconciler.Reconcile(context.Background())
+
+	assert.Equal(t, "10.0.0.1:/share", rt.devices()[rt.staging])
+	assert.Empty(t, rt.events())
+	// PVs are only looked up when a stale mount was found
+	assert.Empty(t, rt.client.Actions())
+}
+
+func TestRemountReconcilerFailures(t *testing.T) {
+	testCases := []struct {
+		name      string
+		pvs       []runtime.Object
+		expEvents []string
+	}{
+		{
+			name:      "PV without NFS server path",
+			pvs:       []runtime.Object{remountTestPV("")},
+			expEvents: []string{"Warning " + StaleMountRemountFailedReason},
+		},
+		{
+			name:      "No PV of the driver",
+			pvs:       []runtime.Object{},
+			expEvents: []string{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := newRemountTest(t, map[string]bool{"mount": true}, tc.pvs...)
+			rt.mounter.MountPoints = []mount.MountPoint{
+				{Device: "10.0.0.1:/share", Path: rt.target, Type: "nfs4"},
+			}
+
+			rt.reconciler.Reconcile(context.Background())
+
+			events := rt.events()
+			assert.Len(t, events, len(tc.expEvents))
+			for i, expEvent := range tc.expEvents {
+				assert.True(t, strings.HasPrefix(events[i], expEvent), events[i])
+			}
+		})
+	}
+}
+
+func TestRemountReconcilerVolumeKey(t *testing.T) {
+	kubeletDir := t.TempDir()
+	stagingDir := filepath.Join(kubeletDir, "plugins", "kubernetes.io", "csi", "mydriver", "def")
+	assert.Nil(t, os.MkdirAll(stagingDir, 0750))
+	writeVolumeData(t, stagingDir, "mydriver", "pv-2")
+	rr := &RemountReconciler{driverName: "mydriver", config: RemountConfig{KubeletDir: "/var/lib/kubelet"}}
+	testCases := []struct {
+		path       string
+		expKey     string
+		expStaging bool
+		expOK      bool
+	}{
+		{path: "/var/lib/kubelet/pods/uid/volumes/kubernetes.io~csi/pv-1/mount", expKey: "pv-1", expOK: true},
+		{path: "/var/lib/kubelet/plugins/kubernetes.io/csi/mydriver/abc/globalmount", expKey: "abc", expStaging: true, expOK: true},
+		{path: "/var/lib/kubelet/plugins/kubernetes.io/csi/pv/pv-1/globalmount", expKey: "pv-1", expStaging: true, expOK: true},
+		{path: "/var/lib/kubelet/plugins/kubernetes.io/csi/otherdriver/abc/globalmount"},
+		{path: "/var/lib/kubelet/pods/uid/volumes/kubernetes.io~nfs/pv-1"},
+		{path: "/mnt/share"},
+	}
+	for _, tc := range testCases {
+		key, staging, ok := rr.volumeKey(tc.path)
+		assert.Equal(t, tc.expKey, key, tc.path)
+		assert.Equal(t, tc.expStaging, staging, tc.path)
+		assert.Equal(t, tc.expOK, ok, tc.path)
+	}
+
+	// the PV name is read from the volume data kubelet writes next to the staging path
+	rr.config.KubeletDir = kubeletDir
+	key, staging, ok := rr.volumeKey(filepath.Join(stagingDir, "globalmount"))
+	assert.Equal(t, "pv-2", key)
+	assert.True(t, staging)
+	assert.True(t, ok)
+}
@@ -0,0 +1,112 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	secretUtils "github.com/IBM/secret-utils-lib/pkg/utils"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SecretWatcher watches the storage-secret-store secret and notifies when the
+// IBM Cloud credentials in it are rotated.
+type SecretWatcher struct {
+	logger    *zap.Logger
+	client    rest.Interface
+	namespace string
+	onRotate  func(reason string)
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// NewSecretWatcher ...
+func NewSecretWatcher(client rest.Interface, namespace string, onRotate func(reason string), log *zap.Logger) *SecretWatcher {
+	return &SecretWatcher{
+		logger:    log,
+		client:    client,
+		namespace: namespace,
+		onRotate:  onRotate,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start runs the informer in the background until Stop is called
+func (sw *SecretWatcher) Start() {
+	watchlist := cache.NewListWatchFromClient(sw.client, "secrets", sw.namespace, fields.Set{"metadata.name": secretUtils.STORAGE_SECRET_STORE_SECRET}.AsSelector())
+	informerOptions := cache.InformerOptions{
+		ListerWatcher: watchlist,
+		ObjectType:    &v1.Secret{},
+		ResyncPeriod:  time.Second * 0,
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: sw.secretUpdated,
+			DeleteFunc: sw.secretDeleted,
+		},
+	}
+	_, controller := cache.NewInformerWithOptions(informerOptions)
+	go controller.Run(sw.stopCh)
+	sw.logger.Info("SecretWatcher started - start watching for credential rotation", zap.String("secret name", secretUtils.STORAGE_SECRET_STORE_SECRET), zap.String("secret namespace", sw.namespace))
+}
+
+// Stop stops the informer, safe to call more than once
+func (sw *SecretWatcher) Stop() {
+	sw.stopOnce.Do(func() {
+		close(sw.stopCh)
+		sw.logger.Info("SecretWatcher stopped", zap.String("secret name", secretUtils.STORAGE_SECRET_STORE_SECRET))
+	})
+}
+
+// secretUpdated - invalidates the provider session when the secret data changes.
+// Resyncs and metadata only updates leave the data untouched and are ignored.
+func (sw *SecretWatcher) secretUpdated(oldObj, newObj interface{}) {
+	newSecret, ok := newObj.(*v1.Secret)
+	if !ok {
+		return
+	}
+	oldSecret, ok := oldObj.(*v1.Secret)
+	if !ok {
+		return
+	}
+	if newSecret.Name != secretUtils.STORAGE_SECRET_STORE_SECRET || reflect.DeepEqual(oldSecret.Data, newSecret.Data) {
+		return
+	}
+	sw.logger.Info("Credentials in secret rotated", zap.String("secret name", newSecret.Name), zap.String("resourceVersion", newSecret.ResourceVersion))
+	sw.onRotate("storage-secret-store updated")
+}
+
+// secretDeleted ...
+func (sw *SecretWatcher) secretDeleted(obj interface{}) {
+	sw.logger.Warn("Secret deleted, cached provider session will be dropped", zap.String("secret name", secretUtils.STORAGE_SECRET_STORE_SECRET))
+	sw.onRotate("storage-secret-store deleted")
+}
+
+// WatchStorageSecretStore invalidates the cached provider session whenever the credentials are rotated
+func WatchStorageSecretStore(client rest.Interface, namespace string, icDriver *IBMCSIDriver, log *zap.Logger) *SecretWatcher {
+	secretWatcher := NewSecretWatcher(client, namespace, icDriver.InvalidateProviderSession, log)
+	secretWatcher.Start()
+	return secretWatcher
+}
@@ -0,0 +1,87 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"testing"
+
+	secretUtils "github.com/IBM/secret-utils-lib/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	restfake "k8s.io/client-go/rest/fake"
+)
+
+func TestWatchStorageSecretStore(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	icDriver := initIBMCSIDriver(t)
+	c := new(restfake.RESTClient)
+	sw := WatchStorageSecretStore(c, "kube-system", icDriver, logger)
+	sw.Stop()
+	sw.Stop()
+}
+
+func TestSecretUpdated(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+
+	newSecret := func(name, apiKey string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Data:       map[string][]byte{secretUtils.SECRET_STORE_FILE: []byte("g2_api_key = \"" + apiKey + "\"")},
+		}
+	}
+
+	testcases := []struct {
+		testCaseName    string
+		oldSecret       *v1.Secret
+		newSecret       *v1.Secret
+		expectedRotated bool
+	}{
+		{
+			testCaseName:    "Resync without data change",
+			oldSecret:       newSecret(secretUtils.STORAGE_SECRET_STORE_SECRET, "key-1"),
+			newSecret:       newSecret(secretUtils.STORAGE_SECRET_STORE_SECRET, "key-1"),
+			expectedRotated: false,
+		},
+		{
+			testCaseName:    "API key rotated",
+			oldSecret:       newSecret(secretUtils.STORAGE_SECRET_STORE_SECRET, "key-1"),
+			newSecret:       newSecret(secretUtils.STORAGE_SECRET_STORE_SECRET, "key-2"),
+			expectedRotated: true,
+		},
+		{
+			testCaseName:    "Different secret",
+			oldSecret:       newSecret("other-secret", "key-1"),
+			newSecret:       newSecret("other-secret", "key-2"),
+			expectedRotated: false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			rotated := false
+			sw := NewSecretWatcher(new(restfake.RESTClient), "kube-system", func(string) { rotated = true }, logger)
+			sw.secretUpdated(testcase.oldSecret, testcase.newSecret)
+			assert.Equal(t, testcase.expectedRotated, rotated)
+		})
+	}
+}
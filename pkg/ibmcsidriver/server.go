@@ -25,15 +25,21 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"path"
+	"regexp"
 	"sync"
 	"syscall"
+	"time"
 
 	"context"
 
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/tracing"
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
-	"github.com/golang/glog"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 // NonBlockingGRPCServer Defines Non blocking GRPC server interfaces
@@ -46,11 +52,23 @@ type NonBlockingGRPCServer interface {
 	Stop()
 	// Stops the service forcefully
 	ForceStop()
+	// Sets how long Shutdown waits for in-flight RPCs and the hooks it runs before the socket is removed
+	SetGracefulShutdown(drainTimeout time.Duration, hooks ...func())
+	// Drains in-flight RPCs, runs the shutdown hooks and removes the socket
+	Shutdown() error
+	// Sets the TLS settings of tcp endpoints
+	SetTLSConfig(config TLSConfig)
+	// Sets whether the unix socket is shared with the node sidecars running as group
+	SetNodeSidecars(enabled bool, group int)
 }
 
-// NewNonBlockingGRPCServer ...
-func NewNonBlockingGRPCServer(logger *zap.Logger) NonBlockingGRPCServer {
-	return &nonBlockingGRPCServer{logger: logger}
+// DefaultShutdownDrainTimeout is how long in-flight RPCs may run after SIGTERM before the server is stopped forcefully.
+// It stays below the default pod terminationGracePeriodSeconds of 30s so the hooks still run before SIGKILL
+const DefaultShutdownDrainTimeout = 25 * time.Second
+
+// NewNonBlockingGRPCServer ... interceptors run after the driver's own logging and metrics interceptors
+func NewNonBlockingGRPCServer(logger *zap.Logger, interceptors ...grpc.UnaryServerInterceptor) NonBlockingGRPCServer {
+	return &nonBlockingGRPCServer{logger: logger, interceptors: interceptors, drainTimeout: DefaultShutdownDrainTimeout}
 }
 
 // Package variable to allow unit tests to override file operations safely
@@ -58,9 +76,17 @@ var defaultSocketPermission socketPermission = &opsSocketPermission{}
 
 // nonBlockingGRPCServer server
 type nonBlockingGRPCServer struct {
-	wg     sync.WaitGroup
-	server *grpc.Server
-	logger *zap.Logger
+	wg           sync.WaitGroup
+	server       *grpc.Server
+	logger       *zap.Logger
+	interceptors []grpc.UnaryServerInterceptor
+
+	drainTimeout  time.Duration
+	shutdownHooks []func()
+	socket        string
+	tlsConfig     TLSConfig
+	nodeSidecars  bool
+	sidecarGroup  int
 }
 
 // Start ...
@@ -85,12 +111,63 @@ func (s *nonBlockingGRPCServer) ForceStop() {
 	s.server.Stop()
 }
 
+// SetGracefulShutdown ... must be called before Start
+func (s *nonBlockingGRPCServer) SetGracefulShutdown(drainTimeout time.Duration, hooks ...func()) {
+	s.drainTimeout = drainTimeout
+	s.shutdownHooks = hooks
+}
+
+// SetTLSConfig ... must be called before Start
+func (s *nonBlockingGRPCServer) SetTLSConfig(config TLSConfig) {
+	s.tlsConfig = config
+}
+
+// SetNodeSidecars ... must be called before Start
+func (s *nonBlockingGRPCServer) SetNodeSidecars(enabled bool, group int) {
+	s.nodeSidecars = enabled
+	s.sidecarGroup = group
+}
+
+// Shutdown stops accepting new RPCs and waits up to the drain timeout for the in-flight ones,
+// falling back to ForceStop. The shutdown hooks run after that and the unix socket is removed last
+func (s *nonBlockingGRPCServer) Shutdown() error {
+	if s.server != nil {
+		s.logger.Info("Draining in-flight GRPC requests", zap.Duration("drainTimeout", s.drainTimeout))
+		drained := make(chan struct{})
+		go func() {
+			s.server.GracefulStop()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+			s.logger.Info("All in-flight GRPC requests completed")
+		case <-time.After(s.drainTimeout):
+			s.logger.Warn("In-flight GRPC requests did not complete within the drain timeout, stopping forcefully", zap.Duration("drainTimeout", s.drainTimeout))
+			s.ForceStop()
+			<-drained
+		}
+	}
+
+	for _, hook := range s.shutdownHooks {
+		hook()
+	}
+
+	if s.socket == "" {
+		return nil
+	}
+	if err := os.Remove(s.socket); err != nil && !os.IsNotExist(err) {
+		s.logger.Error("Failed to remove socket", zap.String("socket", s.socket), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
 // Setup ...
 func (s *nonBlockingGRPCServer) Setup(endpoint string, ids csi.IdentityServer, cs csi.ControllerServer, ns csi.NodeServer) (net.Listener, error) {
 	s.logger.Info("nonBlockingGRPCServer-Setup...", zap.Reflect("Endpoint", endpoint))
 
 	opts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(logGRPC),
+		grpc.ChainUnaryInterceptor(s.unaryInterceptors()...),
 	}
 
 	u, err := url.Parse(endpoint)
@@ -104,12 +181,26 @@ func (s *nonBlockingGRPCServer) Setup(endpoint string, ids csi.IdentityServer, c
 	var addr string
 	if u.Scheme == "unix" {
 		addr = u.Path
+		s.socket = addr
 		if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
 			s.logger.Error("Failed to remove", zap.Reflect("addr", addr), zap.Error(err))
 			return nil, err
 		}
+		if s.tlsConfig.Enabled() {
+			s.logger.Warn("TLS is only used for tcp endpoints, serving the unix socket without TLS", zap.String("socket", addr))
+		}
 	} else if u.Scheme == "tcp" {
 		addr = u.Host
+		if s.tlsConfig.Enabled() {
+			reloader, err := newCertReloader(s.tlsConfig, s.logger)
+			if err != nil {
+				s.logger.Error("Failed to set up TLS", zap.Error(err))
+				return nil, err
+			}
+			opts = append(opts, grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
+			s.logger.Info("Serving GRPC with TLS", zap.String("certFile", s.tlsConfig.CertFile),
+				zap.Bool("verifyClientCert", s.tlsConfig.ClientCAFile != ""), zap.Bool("requireClientCert", s.tlsConfig.RequireClientCert))
+		}
 	} else {
 		msg := "endpoint scheme not supported"
 		s.logger.Error(msg, zap.Reflect("Scheme", u.Scheme))
@@ -128,8 +219,8 @@ func (s *nonBlockingGRPCServer) Setup(endpoint string, ids csi.IdentityServer, c
 
 	// In case of nodeSerer container, setup desired csi socket permissions and user/group.
 	// This is required for running `livenessprobe` container as non-root user/group
-	if os.Getenv("IS_NODE_SERVER") == "true" {
-		if err := setupSidecar(addr, defaultSocketPermission, s.logger); err != nil {
+	if s.nodeSidecars {
+		if err := setupSidecar(addr, s.sidecarGroup, defaultSocketPermission, s.logger); err != nil {
 			s.logger.Error("setupSidecar failed.", zap.Error(err))
 			return nil, err
 		}
@@ -147,10 +238,18 @@ func (s *nonBlockingGRPCServer) Setup(endpoint string, ids csi.IdentityServer, c
 	if ns != nil {
 		csi.RegisterNodeServer(s.server, ns)
 	}
-	go removeCSISocket(addr)
+	go s.shutdownOnSIGTERM()
 	return listener, nil
 }
 
+// unaryInterceptors returns the interceptor chain, outermost first. recoverPanic is innermost
+// so the logging, metrics and extra interceptors see the Internal error of a panicking handler
+func (s *nonBlockingGRPCServer) unaryInterceptors() []grpc.UnaryServerInterceptor {
+	interceptors := []grpc.UnaryServerInterceptor{propagateRequestID, applyDefaultDeadline, tracing.UnaryServerInterceptor, s.logGRPC, recordMetrics}
+	interceptors = append(interceptors, s.interceptors...)
+	return append(interceptors, s.recoverPanic)
+}
+
 // serve ...
 func (s *nonBlockingGRPCServer) serve(endpoint string, ids csi.IdentityServer, cs csi.ControllerServer, ns csi.NodeServer) {
 	s.logger.Info("nonBlockingGRPCServer-serve...", zap.Reflect("Endpoint", endpoint))
@@ -165,27 +264,53 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ids csi.IdentityServer, c
 	}
 }
 
-// logGRPC ...
-func logGRPC(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	glog.V(3).Infof("GRPC call: %s", info.FullMethod)
-	glog.V(5).Infof("GRPC request: %+v", req)
+// csiErrorCodeRegex extracts the CSI message code from errors built by commonError.GetCSIError and GetCSIBackendError
+var csiErrorCodeRegex = regexp.MustCompile(`Code:\s*(\w+)`)
+
+// recordMetrics counts RPCs by method, profile, CSI error code and gRPC status and tracks in-flight RPCs
+func recordMetrics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := path.Base(info.FullMethod)
+	inFlight := driverMetrics.RPCInFlight.WithLabelValues(method)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
 	resp, err := handler(ctx, req)
-	if err != nil {
-		glog.Errorf("GRPC error: %v", err)
-	} else {
-		glog.V(5).Infof("GRPC response: %+v", resp)
-	}
+	driverMetrics.RPCRequests.WithLabelValues(method, requestProfile(req), csiErrorCode(err), status.Code(err).String()).Inc()
 	return resp, err
 }
 
-func removeCSISocket(endPoint string) {
+// requestProfile returns the share profile of the request when it carries one
+func requestProfile(req interface{}) string {
+	switch r := req.(type) {
+	case *csi.CreateVolumeRequest:
+		return r.GetParameters()[Profile]
+	case *csi.NodeStageVolumeRequest:
+		return r.GetVolumeContext()[ProfileLabel]
+	case *csi.NodePublishVolumeRequest:
+		return r.GetVolumeContext()[ProfileLabel]
+	}
+	return ""
+}
+
+// csiErrorCode returns the CSI message code of err, or the gRPC code when err was not built from a CSI message
+func csiErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if match := csiErrorCodeRegex.FindStringSubmatch(status.Convert(err).Message()); match != nil {
+		return match[1]
+	}
+	return status.Code(err).String()
+}
+
+// shutdownOnSIGTERM drains the server and removes the socket before exiting on SIGTERM
+func (s *nonBlockingGRPCServer) shutdownOnSIGTERM() {
 	// Reference: https://github.com/kubernetes-csi/node-driver-registrar/blob/master/cmd/csi-node-driver-registrar/node_register.go#L168
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc, syscall.SIGTERM)
 	<-sigc
-	err := os.Remove(endPoint)
-	if err != nil && !os.IsNotExist(err) {
-		glog.Errorf("failed to remove socket: %s with error: %+v", endPoint, err)
+	s.logger.Info("Received SIGTERM, shutting down GRPC server")
+	if err := s.Shutdown(); err != nil {
 		os.Exit(1)
 	}
 	os.Exit(0)
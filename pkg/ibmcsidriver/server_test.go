@@ -23,14 +23,22 @@ import (
 	"errors"
 	"flag"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"context"
 
 	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
 	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/ibmcsidriver/ibmcsidriverfakes"
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 func TestSetup(t *testing.T) {
@@ -93,8 +101,8 @@ func TestSetup(t *testing.T) {
 
 	{
         t.Logf("setup CSI sidecar with chown failure")
-        _ = os.Setenv("IS_NODE_SERVER", "true")
-        defer func() { _ = os.Unsetenv("IS_NODE_SERVER") }()
+        nonBlockingServer.SetNodeSidecars(true, 2121)
+        defer nonBlockingServer.SetNodeSidecars(false, 0)
 
         // Initialize your mock
         fakeFileOps := new(ibmcsidriverfakes.FakeSocketPermission)
@@ -114,18 +122,134 @@ func TestSetup(t *testing.T) {
 }
 
 func TestLogGRPC(t *testing.T) {
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+	s := NewNonBlockingGRPCServer(logger).(*nonBlockingGRPCServer)
+
 	ctx := context.Background()
 	info := &grpc.UnaryServerInfo{}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
-	_, err := logGRPC(ctx, nil, info, handler)
+	_, err := s.logGRPC(ctx, nil, info, handler)
 	assert.Nil(t, err)
 
 	//Return error
 	handler = func(ctx context.Context, req interface{}) (interface{}, error) {
 		return nil, errors.New("handler error")
 	}
-	_, err = logGRPC(ctx, nil, info, handler)
+	_, err = s.logGRPC(ctx, nil, info, handler)
 	assert.NotNil(t, err)
 	assert.Equal(t, err.Error(), "handler error")
 
 }
+
+func TestRecordMetrics(t *testing.T) {
+	ctx := context.Background()
+	info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Controller/CreateVolume"}
+	req := &csi.CreateVolumeRequest{Name: "pvc-1", Parameters: map[string]string{Profile: DP2Profile}}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	before := testutil.ToFloat64(driverMetrics.RPCRequests.WithLabelValues("CreateVolume", DP2Profile, "", "OK"))
+	_, err := recordMetrics(ctx, req, info, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(driverMetrics.RPCRequests.WithLabelValues("CreateVolume", DP2Profile, "", "OK")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(driverMetrics.RPCInFlight.WithLabelValues("CreateVolume")))
+
+	handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.InvalidArgument, "{RequestID: 123, Code: InvalidParameters, Description: Failed to extract parameters, Action: Please provide valid parameters}")
+	}
+	_, err = recordMetrics(ctx, req, info, handler)
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(driverMetrics.RPCRequests.WithLabelValues("CreateVolume", DP2Profile, "InvalidParameters", "InvalidArgument")))
+}
+
+func TestCSIErrorCode(t *testing.T) {
+	assert.Equal(t, "", csiErrorCode(nil))
+	assert.Equal(t, "InternalError", csiErrorCode(status.Error(codes.Internal, "{RequestID: 123 , BackendError: {Code:InternalError, Description:List Volumes Failed., RC:500}, Action: Please check 'BackendError' tag for more details}")))
+	assert.Equal(t, "Unavailable", csiErrorCode(status.Error(codes.Unavailable, "connection refused")))
+	assert.Equal(t, "Unknown", csiErrorCode(errors.New("handler error")))
+}
+
+// blockingIdentityServer holds GetPluginInfo until release is closed
+type blockingIdentityServer struct {
+	CSIIdentityServer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingIdentityServer) GetPluginInfo(ctx context.Context, req *csi.GetPluginInfoRequest) (*csi.GetPluginInfoResponse, error) {
+	close(b.started)
+	select {
+	case <-b.release:
+		return &csi.GetPluginInfoResponse{Name: "test"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	testCases := []struct {
+		name         string
+		drainTimeout time.Duration
+		releaseRPC   bool
+		expectRPCErr bool
+	}{
+		{
+			name:         "In-flight RPC drained",
+			drainTimeout: time.Minute,
+			releaseRPC:   true,
+		},
+		{
+			name:         "Drain timeout falls back to force stop",
+			drainTimeout: 100 * time.Millisecond,
+			expectRPCErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, teardown := cloudProvider.GetTestLogger(t)
+			defer teardown()
+
+			socket := filepath.Join(t.TempDir(), "csi.sock")
+			ids := &blockingIdentityServer{started: make(chan struct{}), release: make(chan struct{})}
+			s := NewNonBlockingGRPCServer(logger).(*nonBlockingGRPCServer)
+			hookCalled := false
+			s.SetGracefulShutdown(tc.drainTimeout, func() { hookCalled = true })
+
+			listener, err := s.Setup("unix:"+socket, ids, nil, nil)
+			assert.Nil(t, err)
+			go func() { _ = s.server.Serve(listener) }()
+
+			conn, err := grpc.NewClient("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			assert.Nil(t, err)
+			defer conn.Close()
+
+			rpcErr := make(chan error, 1)
+			go func() {
+				_, err := csi.NewIdentityClient(conn).GetPluginInfo(context.Background(), &csi.GetPluginInfoRequest{})
+				rpcErr <- err
+			}()
+			<-ids.started
+
+			shutdownErr := make(chan error, 1)
+			go func() { shutdownErr <- s.Shutdown() }()
+
+			if tc.releaseRPC {
+				// Shutdown must wait for the in-flight RPC before running the hooks
+				time.Sleep(50 * time.Millisecond)
+				select {
+				case <-shutdownErr:
+					t.Fatal("Shutdown returned before the in-flight RPC completed")
+				default:
+				}
+				close(ids.release)
+			}
+
+			assert.Nil(t, <-shutdownErr)
+			assert.Equal(t, tc.expectRPCErr, <-rpcErr != nil)
+			assert.True(t, hookCalled)
+			_, err = os.Stat(socket)
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	providerError "github.com/IBM/ibmcloud-volume-interface/lib/utils"
+	"github.com/IBM/secret-utils-lib/pkg/token"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSessionCacheTTL maximum age of a cached provider session. IAM tokens
+	// are valid for one hour, the session is refreshed well before that.
+	DefaultSessionCacheTTL = 45 * time.Minute
+
+	// sessionRefreshMargin how long before its IAM token expires a cached session is refreshed
+	sessionRefreshMargin = 5 * time.Minute
+)
+
+// SessionCache reuses one VPC provider session across CSI requests so that the
+// IAM token exchange and client setup are not repeated for every RPC. The
+// cached session is dropped shortly before its IAM token expires (bounded by
+// the TTL), when VPC rejects its token, or when the credentials in
+// storage-secret-store are rotated.
+type SessionCache struct {
+	mu        sync.Mutex
+	provider  cloudProvider.CloudProviderInterface
+	ttl       time.Duration
+	session   provider.Session
+	expiresAt time.Time
+	logger    *zap.Logger
+	now       func() time.Time
+
+	// authFailure last token rejection, cleared by the next successful call
+	authFailure error
+}
+
+// NewSessionCache returns nil when ttl is not positive, callers then open a new session for every request
+func NewSessionCache(provider cloudProvider.CloudProviderInterface, ttl time.Duration, logger *zap.Logger) *SessionCache {
+	if ttl <= 0 {
+		logger.Info("Provider session caching is disabled")
+		return nil
+	}
+	logger.Info("Provider session caching is enabled", zap.Duration("ttl", ttl))
+	return &SessionCache{
+		provider: provider,
+		ttl:      ttl,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Get returns the cached session or opens a new one. Concurrent callers wait
+// for a single refresh instead of authenticating in parallel. The session
+// outlives the request that opened it, so it is opened with a background
+// context and the driver logger rather than the caller's.
+func (sc *SessionCache) Get(ctx context.Context, ctxLogger *zap.Logger) (provider.Session, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.session != nil && sc.now().Before(sc.expiresAt) {
+		return sc.session, nil
+	}
+
+	ctxLogger.Info("Opening new provider session", zap.Bool("expired", sc.session != nil))
+	session, err := sc.provider.GetProviderSession(context.Background(), sc.logger)
+	if err != nil {
+		sc.session = nil
+		return nil, err
+	}
+	sc.session = session
+	sc.expiresAt = sc.sessionExpiry(session)
+	ctxLogger.Info("Opened provider session", zap.Time("refreshAt", sc.expiresAt))
+	return session, nil
+}
+
+// sessionExpiry returns when session must be refreshed, sessionRefreshMargin
+// before its IAM token expires and never later than the TTL
+func (sc *SessionCache) sessionExpiry(session provider.Session) time.Time {
+	now := sc.now()
+	expiresAt := now.Add(sc.ttl)
+	if lifetime, ok := tokenLifetime(session); ok {
+		if tokenExpiry := now.Add(lifetime - sessionRefreshMargin); tokenExpiry.Before(expiresAt) {
+			expiresAt = tokenExpiry
+		}
+	}
+	return expiresAt
+}
+
+// tokenLifetime returns how long the IAM access token of session remains valid
+func tokenLifetime(session provider.Session) (time.Duration, bool) {
+	credentials, ok := sessionCredentials(session)
+	if !ok || credentials.Credential == "" {
+		return 0, false
+	}
+	seconds, err := token.CheckTokenLifeTime(credentials.Credential)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// sessionCredentials returns the credentials of session. provider.Session does not expose
+// them, VPC sessions carry them in an exported ContextCredentials field.
+func sessionCredentials(session provider.Session) (provider.ContextCredentials, bool) {
+	if instrumented, ok := session.(*instrumentedSession); ok {
+		session = instrumented.Session
+	}
+	value := reflect.Indirect(reflect.ValueOf(session))
+	if value.Kind() != reflect.Struct {
+		return provider.ContextCredentials{}, false
+	}
+	field := value.FieldByName("ContextCredentials")
+	if !field.IsValid() || !field.CanInterface() {
+		return provider.ContextCredentials{}, false
+	}
+	credentials, ok := field.Interface().(provider.ContextCredentials)
+	return credentials, ok
+}
+
+// Invalidate drops the cached session, the next Get opens a new one. The old
+// session is not closed as in-flight requests may still be using it.
+func (sc *SessionCache) Invalidate(reason string) {
+	if sc == nil {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.session == nil {
+		return
+	}
+	sc.logger.Info("Invalidating cached provider session", zap.String("reason", reason))
+	sc.session = nil
+	sc.expiresAt = time.Time{}
+}
+
+// RecordResult tracks the outcome of a call made with a cached session. A
+// rejected token drops the session and is remembered until a call succeeds.
+func (sc *SessionCache) RecordResult(err error) {
+	if sc == nil {
+		return
+	}
+	if isAuthFailure(err) {
+		sc.mu.Lock()
+		sc.authFailure = err
+		sc.mu.Unlock()
+		sc.Invalidate("provider rejected session token")
+		return
+	}
+	if err == nil {
+		sc.mu.Lock()
+		sc.authFailure = nil
+		sc.mu.Unlock()
+	}
+}
+
+// AuthFailure returns the last token rejection, nil when a call succeeded since
+func (sc *SessionCache) AuthFailure() error {
+	if sc == nil {
+		return nil
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.authFailure
+}
+
+// isAuthFailure reports whether err means the session token was rejected
+func isAuthFailure(err error) bool {
+	msg, ok := err.(providerError.Message)
+	if !ok {
+		return false
+	}
+	return msg.RC == http.StatusUnauthorized || msg.Type == providerError.Unauthenticated || msg.Type == providerError.FailedAccessToken
+}
@@ -0,0 +1,247 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibmcloud-volume-interface/config"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider/fake"
+	providerError "github.com/IBM/ibmcloud-volume-interface/lib/utils"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// countingProvider opens a new fake session on every call
+type countingProvider struct {
+	mu         sync.Mutex
+	calls      int
+	err        error
+	profileErr error
+	sessions   []*fake.FakeSession
+}
+
+func (cp *countingProvider) GetProviderSession(_ context.Context, _ *zap.Logger) (provider.Session, error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.calls++
+	if cp.err != nil {
+		return nil, cp.err
+	}
+	session := &fake.FakeSession{}
+	session.ListVolumesReturns(&provider.VolumeList{}, nil)
+	session.GetVolumeProfileByNameReturns(&provider.Profile{Name: DP2Profile}, cp.profileErr)
+	cp.sessions = append(cp.sessions, session)
+	return session, nil
+}
+
+func (cp *countingProvider) GetConfig() *config.Config { return &config.Config{} }
+
+func (cp *countingProvider) GetClusterID() string { return "fake-cluster-id" }
+
+func (cp *countingProvider) callCount() int {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.calls
+}
+
+func TestSessionCacheDisabled(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	assert.Nil(t, NewSessionCache(&countingProvider{}, 0, logger))
+
+	// nil cache is safe to invalidate
+	var sc *SessionCache
+	sc.Invalidate("test")
+}
+
+func TestSessionCacheGet(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	cp := &countingProvider{}
+	sc := NewSessionCache(cp, time.Minute, logger)
+	now := time.Now()
+	sc.now = func() time.Time { return now }
+
+	first, err := sc.Get(context.Background(), logger)
+	assert.Nil(t, err)
+	second, err := sc.Get(context.Background(), logger)
+	assert.Nil(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, cp.callCount())
+
+	// refreshed once the ttl is over
+	now = now.Add(2 * time.Minute)
+	third, err := sc.Get(context.Background(), logger)
+	assert.Nil(t, err)
+	assert.NotSame(t, first, third)
+	assert.Equal(t, 2, cp.callCount())
+
+	// invalidation forces a new session
+	sc.Invalidate("credentials rotated")
+	_, err = sc.Get(context.Background(), logger)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, cp.callCount())
+
+	// failures are not cached
+	cp.err = errors.New("failed to get IAM token")
+	sc.Invalidate("credentials rotated")
+	_, err = sc.Get(context.Background(), logger)
+	assert.NotNil(t, err)
+	_, err = sc.Get(context.Background(), logger)
+	assert.NotNil(t, err)
+	assert.Equal(t, 5, cp.callCount())
+}
+
+// tokenSession mimics a VPC session which carries its IAM credentials
+type tokenSession struct {
+	*fake.FakeSession
+	ContextCredentials provider.ContextCredentials
+}
+
+// unsignedToken returns a JWT expiring at exp, the signature is never verified
+func unsignedToken(exp time.Time) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix()))) + ".c2lnbmF0dXJl"
+}
+
+func TestSessionCacheTokenExpiry(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	now := time.Now()
+	sc := NewSessionCache(&countingProvider{}, time.Hour, logger)
+	sc.now = func() time.Time { return now }
+
+	testCases := []struct {
+		name     string
+		session  provider.Session
+		expected time.Duration
+	}{
+		{
+			name:     "no credentials, ttl applies",
+			session:  &fake.FakeSession{},
+			expected: time.Hour,
+		},
+		{
+			name:     "token expires before ttl",
+			session:  &tokenSession{ContextCredentials: provider.ContextCredentials{Credential: unsignedToken(now.Add(30 * time.Minute))}},
+			expected: 30*time.Minute - sessionRefreshMargin,
+		},
+		{
+			name:     "token outlives ttl",
+			session:  &tokenSession{ContextCredentials: provider.ContextCredentials{Credential: unsignedToken(now.Add(2 * time.Hour))}},
+			expected: time.Hour,
+		},
+		{
+			name:     "credential is not a jwt",
+			session:  &tokenSession{ContextCredentials: provider.ContextCredentials{Credential: "api-key"}},
+			expected: time.Hour,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.WithinDuration(t, now.Add(tc.expected), sc.sessionExpiry(tc.session), 2*time.Second)
+		})
+	}
+}
+
+func TestSessionCacheConcurrentGet(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	cp := &countingProvider{}
+	sc := NewSessionCache(cp, time.Minute, logger)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sc.Get(context.Background(), logger)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, cp.callCount())
+}
+
+func TestSessionCacheInvalidatedOnAuthFailure(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	cp := &countingProvider{}
+	icDriver.cs.CSIProvider = cp
+	icDriver.cs.Sessions = NewSessionCache(cp, time.Hour, icDriver.logger)
+
+	_, err := icDriver.cs.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+	assert.Nil(t, err)
+	_, err = icDriver.cs.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cp.callCount())
+
+	cp.sessions[0].ListVolumesReturns(nil, providerError.Message{Code: "Unauthorized", Description: "Token expired", RC: 401, Type: providerError.Unauthenticated})
+	_, err = icDriver.cs.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+	assert.NotNil(t, err)
+
+	_, err = icDriver.cs.ListVolumes(context.Background(), &csi.ListVolumesRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, cp.callCount())
+}
+
+func TestSessionCacheRecordResult(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	cp := &countingProvider{}
+	sc := NewSessionCache(cp, time.Hour, logger)
+	unauthorized := providerError.Message{Code: "Unauthorized", RC: 401, Type: providerError.Unauthenticated}
+
+	_, err := sc.Get(context.Background(), logger)
+	assert.Nil(t, err)
+	sc.RecordResult(errors.New("connection refused"))
+	assert.Nil(t, sc.AuthFailure())
+
+	// kept until a call succeeds, the session is reopened
+	sc.RecordResult(unauthorized)
+	assert.Equal(t, unauthorized, sc.AuthFailure())
+	sc.RecordResult(errors.New("connection refused"))
+	assert.Equal(t, unauthorized, sc.AuthFailure())
+	_, err = sc.Get(context.Background(), logger)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, cp.callCount())
+	sc.RecordResult(nil)
+	assert.Nil(t, sc.AuthFailure())
+
+	var nilCache *SessionCache
+	nilCache.RecordResult(unauthorized)
+	assert.Nil(t, nilCache.AuthFailure())
+}
+
+func TestIsAuthFailure(t *testing.T) {
+	assert.False(t, isAuthFailure(nil))
+	assert.False(t, isAuthFailure(errors.New("connection refused")))
+	assert.False(t, isAuthFailure(providerError.Message{RC: 404, Type: providerError.RetrivalFailed}))
+	assert.True(t, isAuthFailure(providerError.Message{RC: 401}))
+	assert.True(t, isAuthFailure(providerError.Message{Type: providerError.FailedAccessToken}))
+}
@@ -0,0 +1,534 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	commonError "github.com/IBM/ibm-csi-common/pkg/messages"
+	mountManager "github.com/IBM/ibm-csi-common/pkg/mountmanager"
+	"github.com/IBM/ibm-csi-common/pkg/utils"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"go.uber.org/zap"
+	mount "k8s.io/mount-utils"
+)
+
+const (
+	// ParentVolumeHandle ... storage class parameter, volume handle (shareID#shareTargetID) of the existing share
+	// the volumes of the class are created in as sub directories
+	ParentVolumeHandle = "parentVolumeHandle"
+
+	// ParentShareName ... storage class parameter, name of the share the volumes of the class are created in as sub
+	// directories. The driver creates it with the share parameters of the class on the first CreateVolume.
+	ParentShareName = "parentShareName"
+
+	// ParentShareSize ... storage class parameter, size in GiB of the share created for parentShareName
+	ParentShareSize = "parentShareSize"
+
+	// SubDirectoryOnDelete ... storage class parameter, 'delete' (default) or 'archive' the sub directory on DeleteVolume
+	SubDirectoryOnDelete = "subDirectoryOnDelete"
+
+	// SubDirectoryLabel ...
+	SubDirectoryLabel = "subDirectory"
+
+	// subDirectoryDelete ...
+	subDirectoryDelete = "delete"
+
+	// subDirectoryArchive ...
+	subDirectoryArchive = "archive"
+
+	// archivedSubDirectoryPrefix prefix of the name a sub directory is renamed to on delete with subDirectoryOnDelete: archive
+	archivedSubDirectoryPrefix = "archived-"
+
+	// subDirectoryMode permissions of a new sub directory, pods with any user can write unless uid and gid are set
+	subDirectoryMode = 0777
+
+	// subDirectoryOwnerMode permissions of a new sub directory owned by the uid and gid of the storage class
+	subDirectoryOwnerMode = 0775
+)
+
+// subDirectoryNameRegex names of the sub directories, the PV names of the csi-provisioner match it
+var subDirectoryNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// errSubDirectoryIPsec the IPsec mount helper only runs on the worker nodes, the controller cannot mount those parent shares
+var errSubDirectoryIPsec = errors.New("sub directory volumes are not supported for parent shares with IPsec encryption in transit, the controller cannot mount them. Use the rfs profile, whose shares are mounted through stunnel")
+
+// errNoParentTunnels the controller has no stunnel sidecar to mount parent shares with stunnel encryption in transit
+var errNoParentTunnels = errors.New("the parent share is mounted through stunnel but the controller has no stunnel manager, run the controller with the stunnel sidecar of the node server")
+
+// subDirectoryVolume is a volume provisioned as a directory of a parent share. Its volume ID is
+// shareID#shareTargetID#directory of the parent share, with a #archive suffix when the directory is archived on delete.
+type subDirectoryVolume struct {
+	ShareID       string
+	AccessPointID string
+	Name          string
+	Archive       bool
+}
+
+// parseSubDirectoryVolumeID returns the sub directory volume of volumeID, false for the volume ID of a share
+func parseSubDirectoryVolumeID(volumeID string) (*subDirectoryVolume, bool) {
+	tokens := strings.Split(volumeID, VolumeIDSeperator)
+	if len(tokens) != 3 && (len(tokens) != 4 || tokens[3] != subDirectoryArchive) {
+		return nil, false
+	}
+	for _, token := range tokens[:3] {
+		if token == "" {
+			return nil, false
+		}
+	}
+	return &subDirectoryVolume{ShareID: tokens[0], AccessPointID: tokens[1], Name: tokens[2], Archive: len(tokens) == 4}, true
+}
+
+// VolumeID ...
+func (v *subDirectoryVolume) VolumeID() string {
+	volumeID := v.ShareID + VolumeIDSeperator + v.AccessPointID + VolumeIDSeperator + v.Name
+	if v.Archive {
+		volumeID += VolumeIDSeperator + subDirectoryArchive
+	}
+	return volumeID
+}
+
+// subDirectoryParameters are the storage class parameters of a sub directory volume
+type subDirectoryParameters struct {
+	volume subDirectoryVolume
+	owner  *provider.InitialOwner
+	// parentName and parentSize of the share created by the driver, empty with parentVolumeHandle
+	parentName string
+	parentSize int
+}
+
+// isSubDirectoryClass reports whether the volumes of a storage class with parameters are sub directories of a share
+func isSubDirectoryClass(parameters map[string]string) bool {
+	_, handle := parameters[ParentVolumeHandle]
+	_, name := parameters[ParentShareName]
+	return handle || name
+}
+
+// getSubDirectoryParameters parses the parameters of a CreateVolume request of a storage class with parentVolumeHandle
+// or parentShareName. The share parameters of the storage class are the ones of the share the driver creates for
+// parentShareName, with parentVolumeHandle the share exists and they are rejected.
+func getSubDirectoryParameters(req *csi.CreateVolumeRequest) (*subDirectoryParameters, error) {
+	params := &subDirectoryParameters{}
+	uid, gid := -1, -1
+	var shareParameters []string
+	var err error
+	for key, value := range req.GetParameters() {
+		switch key {
+		case ParentShareName:
+			params.parentName = strings.TrimSpace(value)
+			if params.parentName == "" {
+				return nil, fmt.Errorf("%s must not be empty", key)
+			}
+		case ParentShareSize:
+			if params.parentSize, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || params.parentSize <= 0 {
+				return nil, fmt.Errorf("%s:<%v> is not a valid size in GiB", key, value)
+			}
+		case ParentVolumeHandle:
+			tokens := strings.Split(strings.TrimSpace(value), VolumeIDSeperator)
+			if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+				return nil, fmt.Errorf("%s:<%v> is not in format shareID#shareTargetID", key, value)
+			}
+			params.volume.ShareID, params.volume.AccessPointID = tokens[0], tokens[1]
+		case SubDirectoryOnDelete:
+			switch value {
+			case "", subDirectoryDelete:
+			case subDirectoryArchive:
+				params.volume.Archive = true
+			default:
+				return nil, fmt.Errorf("%s:<%v> must be '%s' or '%s'", key, value, subDirectoryDelete, subDirectoryArchive)
+			}
+		case UID:
+			if uid, err = strconv.Atoi(value); err != nil || uid < 0 {
+				return nil, fmt.Errorf("%s:<%v> is not a valid user ID", key, value)
+			}
+		case GID:
+			if gid, err = strconv.Atoi(value); err != nil || gid < 0 {
+				return nil, fmt.Errorf("%s:<%v> is not a valid group ID", key, value)
+			}
+		case PVCNameKey, PVCNamespaceKey, PVNameKey:
+			// csi-provisioner metadata, only used to post events against the PVC
+		default:
+			shareParameters = append(shareParameters, key)
+		}
+	}
+	switch {
+	case params.volume.ShareID == "" && params.parentName == "":
+		return nil, fmt.Errorf("%s or %s is required for sub directory volumes", ParentVolumeHandle, ParentShareName)
+	case params.volume.ShareID != "" && params.parentName != "":
+		return nil, fmt.Errorf("%s and %s cannot be set together", ParentVolumeHandle, ParentShareName)
+	case params.volume.ShareID != "" && (len(shareParameters) != 0 || params.parentSize != 0):
+		if params.parentSize != 0 {
+			shareParameters = append(shareParameters, ParentShareSize)
+		}
+		sort.Strings(shareParameters)
+		return nil, fmt.Errorf("<%s> is not supported for sub directory volumes of %s, the share parameters are the ones of the parent share", strings.Join(shareParameters, ", "), ParentVolumeHandle)
+	case params.parentName != "" && params.parentSize == 0:
+		return nil, fmt.Errorf("%s is required with %s", ParentShareSize, ParentShareName)
+	case params.parentName != "" && strings.TrimSpace(req.GetParameters()[IsEITEnabled]) == TrueStr && req.GetParameters()[Profile] != RFSProfile:
+		return nil, errSubDirectoryIPsec
+	}
+	if (uid < 0) != (gid < 0) {
+		return nil, fmt.Errorf("%s and %s must be set together", UID, GID)
+	}
+	if uid >= 0 {
+		params.owner = &provider.InitialOwner{UserID: int64(uid), GroupID: int64(gid)}
+	}
+	if !subDirectoryNameRegex.MatchString(req.GetName()) {
+		return nil, fmt.Errorf("volume name <%s> cannot be used as directory name", req.GetName())
+	}
+	params.volume.Name = req.GetName()
+	return params, nil
+}
+
+// parentTunnels opens the stunnel tunnels of parent shares with stunnel encryption in transit, see rfseit.StunnelManager
+type parentTunnels interface {
+	EnsureTunnel(volumeID, nfsServer, requestID string) (int, error)
+	RemoveTunnel(volumeID, requestID string) error
+}
+
+// parentShare is the share the directories of sub directory volumes are created in
+type parentShare struct {
+	ID string
+	// Source NFS mount path of the share target
+	Source            string
+	Profile           string
+	TransitEncryption string
+}
+
+// SubDirectoryManager creates and deletes the directories of sub directory volumes through a temporary NFS
+// mount of the parent share. Many small volumes are carved out of one share this way, without the minimum share
+// size and the share quota of the account. The controller needs mount privileges, see --subdirectory-volumes.
+// Parent shares with stunnel encryption in transit are mounted through a tunnel of the stunnel sidecar, those with
+// IPsec are not supported. Snapshots are only taken of whole shares.
+type SubDirectoryManager struct {
+	mounter mountManager.Mounter
+	logger  *zap.Logger
+	// tunnels of the parent shares with stunnel encryption in transit, nil without stunnel sidecar
+	tunnels parentTunnels
+	// mountDir the parent shares are mounted below, the OS temp directory when empty
+	mountDir string
+	// mutex serializes the changes of one sub directory and the creation of one parent share
+	mutex utils.LockStore
+}
+
+// NewSubDirectoryManager ...
+func NewSubDirectoryManager(mounter mountManager.Mounter, logger *zap.Logger) *SubDirectoryManager {
+	return &SubDirectoryManager{mounter: mounter, logger: logger}
+}
+
+// Create creates the sub directory in the parent share, an existing directory is reused
+func (m *SubDirectoryManager) Create(ctxLogger *zap.Logger, requestID string, parent parentShare, name string, owner *provider.InitialOwner) error {
+	m.mutex.Lock(parent.Source + name)
+	defer m.mutex.Unlock(parent.Source + name)
+	return m.withParentMount(ctxLogger, requestID, parent, func(root string) error {
+		return createSubDirectory(ctxLogger, root, name, owner)
+	})
+}
+
+// Delete removes the sub directory from the parent share or, with archive, renames it to
+// archived-<name>-<timestamp>. A directory that does not exist is already deleted.
+func (m *SubDirectoryManager) Delete(ctxLogger *zap.Logger, requestID string, parent parentShare, name string, archive bool) error {
+	m.mutex.Lock(parent.Source + name)
+	defer m.mutex.Unlock(parent.Source + name)
+	return m.withParentMount(ctxLogger, requestID, parent, func(root string) error {
+		return deleteSubDirectory(ctxLogger, root, name, archive)
+	})
+}
+
+// withParentMount mounts the parent share at a temporary directory for the duration of fn, shares with stunnel
+// encryption in transit through the tunnel of the share like the node server does
+func (m *SubDirectoryManager) withParentMount(ctxLogger *zap.Logger, requestID string, parent parentShare, fn func(root string) error) error {
+	source, fsType := parent.Source, defaultFsType
+	isStunnel := parent.TransitEncryption == STUNNEL
+	options, err := effectiveMountOptions(parent.Profile, isStunnel, nil)
+	if err != nil {
+		return err
+	}
+	switch parent.TransitEncryption {
+	case IPSEC:
+		return errSubDirectoryIPsec
+	case STUNNEL:
+		if m.tunnels == nil {
+			return errNoParentTunnels
+		}
+		// one mount of the share at a time, so that no request removes the tunnel another one is about to mount through
+		tunnelKey := STUNNEL + "/" + parent.ID
+		m.mutex.Lock(tunnelKey)
+		defer m.mutex.Unlock(tunnelKey)
+		nfsSource, err := splitNFSSource(parent.Source)
+		if err != nil {
+			return err
+		}
+		port, err := m.tunnels.EnsureTunnel(parent.ID, nfsSource.Server, requestID)
+		if err != nil {
+			return err
+		}
+		// the tunnel is kept while other mounts of the share use it, deferred before the unmount so that it runs last
+		defer func() {
+			if err := m.tunnels.RemoveTunnel(parent.ID, requestID); err != nil {
+				ctxLogger.Warn("Failed to remove the stunnel tunnel of the parent share", zap.String("parentShare", parent.ID), zap.Error(err))
+			}
+		}()
+		source, fsType = fmt.Sprintf("%s:%s", stunnelLocalAddress, nfsSource.ExportPath), nfs4FsType
+		options = append(options, fmt.Sprintf("port=%d", port))
+	}
+
+	root, err := os.MkdirTemp(m.mountDir, "parent-share-")
+	if err != nil {
+		return err
+	}
+	ctxLogger.Info("Mounting parent share", zap.String("source", source), zap.String("mountPath", root), zap.Strings("options", options))
+	if err := m.mounter.Mount(source, root, fsType, options); err != nil {
+		_ = os.Remove(root)
+		return err
+	}
+	defer func() {
+		if err := mount.CleanupMountPoint(root, m.mounter, false /* bind mount */); err != nil {
+			ctxLogger.Warn("Failed to unmount parent share", zap.String("mountPath", root), zap.Error(err))
+		}
+	}()
+	return fn(root)
+}
+
+// createSubDirectory creates the directory name in the parent share mounted at root
+func createSubDirectory(ctxLogger *zap.Logger, root, name string, owner *provider.InitialOwner) error {
+	dir := filepath.Join(root, name)
+	if err := os.Mkdir(dir, subDirectoryMode); err != nil {
+		if os.IsExist(err) {
+			ctxLogger.Info("Sub directory already exists", zap.String("subDirectory", name))
+			return nil
+		}
+		return err
+	}
+	mode := os.FileMode(subDirectoryMode)
+	if owner != nil {
+		if err := os.Chown(dir, int(owner.UserID), int(owner.GroupID)); err != nil {
+			return err
+		}
+		mode = subDirectoryOwnerMode
+	}
+	// the mode of Mkdir is reduced by the umask of the driver
+	return os.Chmod(dir, mode)
+}
+
+// deleteSubDirectory removes or archives the directory name of the parent share mounted at root
+func deleteSubDirectory(ctxLogger *zap.Logger, root, name string, archive bool) error {
+	dir := filepath.Join(root, name)
+	if _, err := os.Lstat(dir); os.IsNotExist(err) {
+		ctxLogger.Info("Sub directory not found, already deleted", zap.String("subDirectory", name))
+		return nil
+	}
+	if archive {
+		archived := filepath.Join(root, fmt.Sprintf("%s%s-%s", archivedSubDirectoryPrefix, name, time.Now().UTC().Format("20060102150405")))
+		ctxLogger.Info("Archiving sub directory", zap.String("subDirectory", name), zap.String("archivedTo", filepath.Base(archived)))
+		return os.Rename(dir, archived)
+	}
+	ctxLogger.Info("Deleting sub directory", zap.String("subDirectory", name))
+	return os.RemoveAll(dir)
+}
+
+// subDirectorySource returns the NFS source of the sub directory in the parent share mounted from parentSource
+func subDirectorySource(parentSource, name string) string {
+	return strings.TrimRight(parentSource, "/") + "/" + name
+}
+
+// createSubDirectoryVolume provisions the volume of a storage class with parentVolumeHandle or parentShareName as
+// directory of the parent share. The capacity is not enforced, all volumes share the capacity of the parent share.
+func (csiCS *CSIControllerServer) createSubDirectoryVolume(ctx context.Context, ctxLogger *zap.Logger, requestID string, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
+	if csiCS.SubDirectories == nil {
+		return nil, commonError.GetCSIError(ctxLogger, SubDirectoryVolumesDisabled, requestID, nil)
+	}
+	if req.GetVolumeContentSource() != nil {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.UnsupportedVolumeContentSource, requestID, nil)
+	}
+	params, err := getSubDirectoryParameters(req)
+	if err != nil {
+		ctxLogger.Error("Unable to extract parameters", zap.Error(err))
+		return nil, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, err)
+	}
+
+	target, err := requestAccountTarget(ctx, ctxLogger, requestID, req.GetSecrets())
+	if err != nil {
+		return nil, err
+	}
+	session, err := csiCS.getAccountProviderSession(ctx, ctxLogger, requestID, commonError.InternalError, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.parentName != "" {
+		if err := csiCS.ensureParentShare(ctx, ctxLogger, requestID, session, req, params); err != nil {
+			return nil, err
+		}
+	}
+
+	parent, err := checkIfVolumeExists(session, provider.Volume{VolumeID: params.volume.ShareID}, ctxLogger)
+	if err != nil {
+		return nil, commonError.GetCSIBackendError(ctxLogger, requestID, err)
+	}
+	if parent == nil {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, fmt.Errorf("parent share '%s' of %s not found", params.volume.ShareID, ParentVolumeHandle))
+	}
+	auditRecordFromContext(ctx).setShareCRN(parent.CRN)
+	if parent.TransitEncryption == IPSEC {
+		return nil, commonError.GetCSIError(ctxLogger, commonError.InvalidParameters, requestID, errSubDirectoryIPsec)
+	}
+	accessPoint, err := session.GetVolumeAccessPoint(provider.VolumeAccessPointRequest{VolumeID: params.volume.ShareID, AccessPointID: params.volume.AccessPointID})
+	if err != nil {
+		return nil, commonError.GetCSIBackendError(ctxLogger, requestID, err)
+	}
+
+	share := newParentShare(parent, accessPoint)
+	ctxLogger.Info("Creating sub directory volume...", zap.String("parentShare", params.volume.ShareID), zap.String("subDirectory", params.volume.Name))
+	if err := csiCS.SubDirectories.Create(ctxLogger, requestID, share, params.volume.Name, params.owner); err != nil {
+		return nil, commonError.GetCSIError(ctxLogger, SubDirectoryFailed, requestID, err, "create", params.volume.Name, params.volume.ShareID)
+	}
+
+	capBytes := req.GetCapacityRange().GetRequiredBytes()
+	if capBytes == 0 && parent.Capacity != nil {
+		capBytes = int64(*parent.Capacity) * utils.GB
+	}
+	region := parent.Region
+	if region == "" {
+		region = csiCS.Driver.region
+	}
+	volumeContext := map[string]string{
+		VolumeIDLabel:          params.volume.VolumeID(),
+		ClusterIDLabel:         csiCS.CSIProvider.GetClusterID(),
+		FileShareIDLabel:       params.volume.ShareID,
+		FileShareTargetIDLabel: params.volume.AccessPointID,
+		VolumeCRNLabel:         parent.CRN,
+		NFSServerPath:          subDirectorySource(accessPoint.MountPath, params.volume.Name),
+		SubDirectoryLabel:      params.volume.Name,
+		utils.NodeRegionLabel:  region,
+	}
+	if share.Profile != "" {
+		volumeContext[ProfileLabel] = share.Profile
+	}
+	// the node server mounts the sub directory through the stunnel tunnel of the parent share
+	if share.TransitEncryption == STUNNEL {
+		volumeContext[IsEITEnabled] = TrueStr
+	}
+	if target.crossAccount() {
+		volumeContext[AccountIDLabel] = target.AccountID
+	}
+	return &csi.CreateVolumeResponse{
+		Volume: &csi.Volume{
+			CapacityBytes: capBytes,
+			VolumeId:      params.volume.VolumeID(),
+			VolumeContext: volumeContext,
+			AccessibleTopology: []*csi.Topology{{
+				Segments: map[string]string{utils.NodeRegionLabel: region},
+			}},
+		},
+	}, nil
+}
+
+// deleteSubDirectoryVolume removes or archives the directory of a sub directory volume, the parent share is kept
+func (csiCS *CSIControllerServer) deleteSubDirectoryVolume(ctx context.Context, ctxLogger *zap.Logger, requestID string, session provider.Session, volume *subDirectoryVolume) (*csi.DeleteVolumeResponse, error) {
+	if csiCS.SubDirectories == nil {
+		return nil, commonError.GetCSIError(ctxLogger, SubDirectoryVolumesDisabled, requestID, nil)
+	}
+	parent, err := checkIfVolumeExists(session, provider.Volume{VolumeID: volume.ShareID}, ctxLogger)
+	if err != nil {
+		return nil, commonError.GetCSIBackendError(ctxLogger, requestID, err)
+	}
+	if parent == nil {
+		ctxLogger.Info("Parent share not found, the sub directory is gone with it. Returning success without deletion...", zap.String("parentShare", volume.ShareID))
+		return &csi.DeleteVolumeResponse{}, nil
+	}
+	auditRecordFromContext(ctx).setShareCRN(parent.CRN)
+	accessPoint, err := session.GetVolumeAccessPoint(provider.VolumeAccessPointRequest{VolumeID: volume.ShareID, AccessPointID: volume.AccessPointID})
+	if err != nil {
+		return nil, commonError.GetCSIBackendError(ctxLogger, requestID, err)
+	}
+
+	action := "delete"
+	if volume.Archive {
+		action = "archive"
+	}
+	if err := csiCS.SubDirectories.Delete(ctxLogger, requestID, newParentShare(parent, accessPoint), volume.Name, volume.Archive); err != nil {
+		return nil, commonError.GetCSIError(ctxLogger, SubDirectoryFailed, requestID, err, action, volume.Name, volume.ShareID)
+	}
+	ctxLogger.Info("Sub directory volume deleted successfully", zap.String("action", action))
+	return &csi.DeleteVolumeResponse{}, nil
+}
+
+// newParentShare returns the parent share of volume mounted through accessPoint
+func newParentShare(volume *provider.Volume, accessPoint *provider.VolumeAccessPointResponse) parentShare {
+	share := parentShare{ID: volume.VolumeID, Source: accessPoint.MountPath, TransitEncryption: volume.TransitEncryption}
+	if volume.VPCVolume.Profile != nil {
+		share.Profile = volume.VPCVolume.Profile.Name
+	}
+	return share
+}
+
+// ensureParentShare sets the parent share of a storage class with parentShareName in params, the share is created
+// with the share parameters of the class when it does not exist. The driver never deletes it.
+func (csiCS *CSIControllerServer) ensureParentShare(ctx context.Context, ctxLogger *zap.Logger, requestID string, session provider.Session, req *csi.CreateVolumeRequest, params *subDirectoryParameters) error {
+	lockKey := ParentShareName + "/" + params.parentName
+	csiCS.SubDirectories.mutex.Lock(lockKey)
+	defer csiCS.SubDirectories.mutex.Unlock(lockKey)
+
+	existing, err := checkIfVolumeExists(session, provider.Volume{Name: &params.parentName}, ctxLogger)
+	if err != nil {
+		return commonError.GetCSIBackendError(ctxLogger, requestID, err)
+	}
+	if existing != nil && existing.VolumeAccessPoints != nil && len(*existing.VolumeAccessPoints) != 0 {
+		params.volume.ShareID, params.volume.AccessPointID = existing.VolumeID, (*existing.VolumeAccessPoints)[0].ID
+		return nil
+	}
+
+	parameters := map[string]string{}
+	for key, value := range req.GetParameters() {
+		switch key {
+		case ParentShareName, ParentShareSize, SubDirectoryOnDelete, UID, GID:
+		default:
+			parameters[key] = value
+		}
+	}
+	ctxLogger.Info("Creating parent share of sub directory volumes...", zap.String("parentShare", params.parentName), zap.Int("sizeGiB", params.parentSize))
+	response, err := csiCS.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name:               params.parentName,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: int64(params.parentSize) * utils.GB},
+		VolumeCapabilities: req.GetVolumeCapabilities(),
+		Parameters:         parameters,
+		Secrets:            req.GetSecrets(),
+	})
+	if err != nil {
+		return err
+	}
+	tokens := getTokens(response.GetVolume().GetVolumeId())
+	if len(tokens) != 2 {
+		return commonError.GetCSIError(ctxLogger, commonError.InternalError, requestID, fmt.Errorf("parent share '%s' has volume ID '%s'", params.parentName, response.GetVolume().GetVolumeId()))
+	}
+	params.volume.ShareID, params.volume.AccessPointID = tokens[0], tokens[1]
+	return nil
+}
@@ -0,0 +1,490 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/IBM/ibm-vpc-file-csi-driver/pkg/driverconfig"
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider"
+	"github.com/IBM/ibmcloud-volume-interface/lib/provider/fake"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	mount "k8s.io/mount-utils"
+)
+
+func TestParseSubDirectoryVolumeID(t *testing.T) {
+	testCases := []struct {
+		testCaseName string
+		volumeID     string
+		expVolume    *subDirectoryVolume
+	}{
+		{testCaseName: "share volume", volumeID: "share1#target1"},
+		{testCaseName: "deprecated share volume", volumeID: "share1:target1"},
+		{testCaseName: "sub directory", volumeID: "share1#target1#pvc-1", expVolume: &subDirectoryVolume{ShareID: "share1", AccessPointID: "target1", Name: "pvc-1"}},
+		{testCaseName: "archived sub directory", volumeID: "share1#target1#pvc-1#archive", expVolume: &subDirectoryVolume{ShareID: "share1", AccessPointID: "target1", Name: "pvc-1", Archive: true}},
+		{testCaseName: "unknown suffix", volumeID: "share1#target1#pvc-1#other"},
+		{testCaseName: "empty directory", volumeID: "share1#target1#"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			volume, ok := parseSubDirectoryVolumeID(tc.volumeID)
+			assert.Equal(t, tc.expVolume != nil, ok)
+			assert.Equal(t, tc.expVolume, volume)
+			if ok {
+				assert.Equal(t, tc.volumeID, volume.VolumeID())
+			}
+		})
+	}
+}
+
+func TestGetSubDirectoryParameters(t *testing.T) {
+	testCases := []struct {
+		testCaseName string
+		name         string
+		parameters   map[string]string
+		expParams    *subDirectoryParameters
+		expErr       string
+	}{
+		{
+			testCaseName: "parent share only",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1", PVCNameKey: "claim", PVCNamespaceKey: "default"},
+			expParams:    &subDirectoryParameters{volume: subDirectoryVolume{ShareID: "share1", AccessPointID: "target1", Name: "pvc-1"}},
+		},
+		{
+			testCaseName: "archive with owner",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1", SubDirectoryOnDelete: subDirectoryArchive, UID: "1000", GID: "2000"},
+			expParams: &subDirectoryParameters{
+				volume: subDirectoryVolume{ShareID: "share1", AccessPointID: "target1", Name: "pvc-1", Archive: true},
+				owner:  &provider.InitialOwner{UserID: 1000, GroupID: 2000},
+			},
+		},
+		{
+			testCaseName: "parent without share target",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1"},
+			expErr:       "is not in format shareID#shareTargetID",
+		},
+		{
+			testCaseName: "unknown on delete policy",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1", SubDirectoryOnDelete: "retain"},
+			expErr:       "must be 'delete' or 'archive'",
+		},
+		{
+			testCaseName: "share parameter",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1", Profile: DP2Profile},
+			expErr:       "is not supported for sub directory volumes",
+		},
+		{
+			testCaseName: "encryption in transit of an existing parent share",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1", IsEITEnabled: "true"},
+			expErr:       "<isEITEnabled> is not supported for sub directory volumes",
+		},
+		{
+			testCaseName: "parent share size of an existing parent share",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1", ParentShareSize: "100"},
+			expErr:       "<parentShareSize> is not supported for sub directory volumes",
+		},
+		{
+			testCaseName: "parent share created by the driver",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentShareName: "parent", ParentShareSize: "100", Profile: RFSProfile, IsEITEnabled: "true", UID: "1000", GID: "2000"},
+			expParams: &subDirectoryParameters{
+				volume:     subDirectoryVolume{Name: "pvc-1"},
+				owner:      &provider.InitialOwner{UserID: 1000, GroupID: 2000},
+				parentName: "parent",
+				parentSize: 100,
+			},
+		},
+		{
+			testCaseName: "parent share created by the driver without size",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentShareName: "parent"},
+			expErr:       "parentShareSize is required with parentShareName",
+		},
+		{
+			testCaseName: "parent share created by the driver with IPsec",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentShareName: "parent", ParentShareSize: "100", Profile: DP2Profile, IsEITEnabled: "true"},
+			expErr:       "IPsec encryption in transit",
+		},
+		{
+			testCaseName: "parent handle and name",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1", ParentShareName: "parent"},
+			expErr:       "cannot be set together",
+		},
+		{
+			testCaseName: "no parent share",
+			name:         "pvc-1",
+			parameters:   map[string]string{SubDirectoryOnDelete: subDirectoryArchive},
+			expErr:       "parentVolumeHandle or parentShareName is required",
+		},
+		{
+			testCaseName: "uid without gid",
+			name:         "pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1", UID: "1000"},
+			expErr:       "must be set together",
+		},
+		{
+			testCaseName: "name with path separator",
+			name:         "../pvc-1",
+			parameters:   map[string]string{ParentVolumeHandle: "share1#target1"},
+			expErr:       "cannot be used as directory name",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			params, err := getSubDirectoryParameters(&csi.CreateVolumeRequest{Name: tc.name, Parameters: tc.parameters})
+			if tc.expErr != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.expErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expParams, params)
+		})
+	}
+}
+
+func TestCreateDeleteSubDirectory(t *testing.T) {
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+	root := t.TempDir()
+
+	assert.Nil(t, createSubDirectory(logger, root, "pvc-1", nil))
+	info, err := os.Stat(filepath.Join(root, "pvc-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.ModeDir|subDirectoryMode, info.Mode())
+	// a retried CreateVolume reuses the directory
+	assert.Nil(t, createSubDirectory(logger, root, "pvc-1", nil))
+
+	if os.Geteuid() == 0 {
+		assert.Nil(t, createSubDirectory(logger, root, "pvc-2", &provider.InitialOwner{UserID: 1000, GroupID: 2000}))
+		info, err = os.Stat(filepath.Join(root, "pvc-2"))
+		assert.Nil(t, err)
+		assert.Equal(t, os.ModeDir|subDirectoryOwnerMode, info.Mode())
+		assert.Equal(t, uint32(1000), info.Sys().(*syscall.Stat_t).Uid)
+		assert.Equal(t, uint32(2000), info.Sys().(*syscall.Stat_t).Gid)
+	}
+
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "pvc-1", "data"), []byte("data"), 0600))
+	assert.Nil(t, deleteSubDirectory(logger, root, "pvc-1", true))
+	archived, err := filepath.Glob(filepath.Join(root, archivedSubDirectoryPrefix+"pvc-1-*", "data"))
+	assert.Nil(t, err)
+	assert.Len(t, archived, 1)
+
+	assert.Nil(t, createSubDirectory(logger, root, "pvc-3", nil))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "pvc-3", "data"), []byte("data"), 0600))
+	assert.Nil(t, deleteSubDirectory(logger, root, "pvc-3", false))
+	_, err = os.Stat(filepath.Join(root, "pvc-3"))
+	assert.True(t, os.IsNotExist(err))
+	// a retried DeleteVolume finds the directory gone
+	assert.Nil(t, deleteSubDirectory(logger, root, "pvc-3", false))
+}
+
+// fakeParentTunnels records the tunnels of parent shares
+type fakeParentTunnels struct {
+	ensured []string
+	removed []string
+}
+
+func (f *fakeParentTunnels) EnsureTunnel(volumeID, nfsServer, requestID string) (int, error) {
+	f.ensured = append(f.ensured, volumeID+" "+nfsServer)
+	return 20049, nil
+}
+
+func (f *fakeParentTunnels) RemoveTunnel(volumeID, requestID string) error {
+	f.removed = append(f.removed, volumeID)
+	return nil
+}
+
+func TestCreateSubDirectoryVolume(t *testing.T) {
+	parentCapacity := 100
+	stunnelParent := &provider.Volume{VolumeID: "share1", VPCVolume: provider.VPCVolume{Profile: &provider.Profile{Name: RFSProfile}, VPCFileVolume: provider.VPCFileVolume{TransitEncryption: STUNNEL}}}
+	testCases := []struct {
+		name          string
+		disabled      bool
+		tunnels       bool
+		parameters    map[string]string
+		capacity      int64
+		parent        *provider.Volume
+		expErrCode    codes.Code
+		expVolumeID   string
+		expCapacity   int64
+		expServerPath string
+		expMount      string
+		expEIT        string
+	}{
+		{
+			name:          "Sub directory of the parent share",
+			parameters:    map[string]string{ParentVolumeHandle: "share1#target1"},
+			capacity:      1 << 30,
+			parent:        &provider.Volume{VolumeID: "share1", VPCVolume: provider.VPCVolume{CRN: "crn:share1", Profile: &provider.Profile{Name: DP2Profile}}},
+			expVolumeID:   "share1#target1#pvc-1",
+			expCapacity:   1 << 30,
+			expServerPath: "10.0.0.1:/parent/pvc-1",
+			expMount:      "mount 10.0.0.1:/parent,unmount ",
+		},
+		{
+			name:          "Archived sub directory with the capacity of the parent share",
+			parameters:    map[string]string{ParentVolumeHandle: "share1#target1", SubDirectoryOnDelete: subDirectoryArchive},
+			parent:        &provider.Volume{VolumeID: "share1", Capacity: &parentCapacity},
+			expVolumeID:   "share1#target1#pvc-1#archive",
+			expCapacity:   100 << 30,
+			expServerPath: "10.0.0.1:/parent/pvc-1",
+			expMount:      "mount 10.0.0.1:/parent,unmount ",
+		},
+		{
+			name:       "Sub directory volumes not enabled",
+			disabled:   true,
+			parameters: map[string]string{ParentVolumeHandle: "share1#target1"},
+			parent:     &provider.Volume{VolumeID: "share1"},
+			expErrCode: codes.FailedPrecondition,
+		},
+		{
+			name:       "Parent share not found",
+			parameters: map[string]string{ParentVolumeHandle: "share1#target1"},
+			expErrCode: codes.InvalidArgument,
+		},
+		{
+			name:          "Parent share with stunnel encryption in transit",
+			tunnels:       true,
+			parameters:    map[string]string{ParentVolumeHandle: "share1#target1"},
+			parent:        stunnelParent,
+			expVolumeID:   "share1#target1#pvc-1",
+			expServerPath: "10.0.0.1:/parent/pvc-1",
+			expMount:      "mount 127.0.0.1:/parent,unmount ",
+			expEIT:        TrueStr,
+		},
+		{
+			name:       "Parent share with stunnel encryption in transit without stunnel sidecar",
+			parameters: map[string]string{ParentVolumeHandle: "share1#target1"},
+			parent:     stunnelParent,
+			expErrCode: codes.Internal,
+		},
+		{
+			name:       "Parent share with IPsec encryption in transit",
+			tunnels:    true,
+			parameters: map[string]string{ParentVolumeHandle: "share1#target1"},
+			parent:     &provider.Volume{VolumeID: "share1", VPCVolume: provider.VPCVolume{VPCFileVolume: provider.VPCFileVolume{TransitEncryption: IPSEC}}},
+			expErrCode: codes.InvalidArgument,
+		},
+	}
+
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			icDriver := initIBMCSIDriver(t)
+			tunnels := &fakeParentTunnels{}
+			if !tc.disabled {
+				icDriver.cs.SubDirectories = NewSubDirectoryManager(icDriver.ns.Mounter, logger)
+				icDriver.cs.SubDirectories.mountDir = t.TempDir()
+				if tc.tunnels {
+					icDriver.cs.SubDirectories.tunnels = tunnels
+				}
+			}
+			fakeSession, err := icDriver.cs.CSIProvider.GetProviderSession(context.Background(), logger)
+			assert.Nil(t, err)
+			fakeStructSession := fakeSession.(*fake.FakeSession)
+			fakeStructSession.GetVolumeReturns(tc.parent, nil)
+			fakeStructSession.GetVolumeAccessPointReturns(&provider.VolumeAccessPointResponse{VolumeID: "share1", AccessPointID: "target1", MountPath: "10.0.0.1:/parent"}, nil)
+
+			response, err := icDriver.cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               "pvc-1",
+				CapacityRange:      &csi.CapacityRange{RequiredBytes: tc.capacity},
+				VolumeCapabilities: []*csi.VolumeCapability{{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER}}},
+				Parameters:         tc.parameters,
+			})
+			assert.Equal(t, tc.expErrCode, status.Code(err))
+			assert.Equal(t, 0, fakeStructSession.CreateVolumeCallCount())
+			if tc.expErrCode != codes.OK {
+				return
+			}
+			assert.Equal(t, tc.expVolumeID, response.Volume.VolumeId)
+			assert.Equal(t, tc.expCapacity, response.Volume.CapacityBytes)
+			assert.Equal(t, tc.expServerPath, response.Volume.VolumeContext[NFSServerPath])
+			assert.Equal(t, "share1", response.Volume.VolumeContext[FileShareIDLabel])
+			assert.Equal(t, "pvc-1", response.Volume.VolumeContext[SubDirectoryLabel])
+			assert.Equal(t, tc.expEIT, response.Volume.VolumeContext[IsEITEnabled])
+
+			// the parent share was mounted for the directory and unmounted again
+			fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+			var actions []string
+			for _, action := range fakeMounter.GetLog() {
+				actions = append(actions, action.Action+" "+action.Source)
+			}
+			assert.Equal(t, tc.expMount, strings.Join(actions, ","))
+			if tc.expEIT == TrueStr {
+				// through the tunnel of the parent share, which is removed once it is unmounted
+				assert.Equal(t, []string{"share1 10.0.0.1"}, tunnels.ensured)
+				assert.Equal(t, []string{"share1"}, tunnels.removed)
+			}
+			created, err := filepath.Glob(filepath.Join(icDriver.cs.SubDirectories.mountDir, "parent-share-*", "pvc-1"))
+			assert.Nil(t, err)
+			assert.Len(t, created, 1)
+		})
+	}
+}
+
+func TestCreateSubDirectoryVolumeParentShareName(t *testing.T) {
+	parentName := "parent"
+	parentCapacity := 100
+	parentShare := &provider.Volume{
+		VolumeID: "share1",
+		Name:     &parentName,
+		Capacity: &parentCapacity,
+		VPCVolume: provider.VPCVolume{
+			Profile:       &provider.Profile{Name: DP2Profile},
+			VPCFileVolume: provider.VPCFileVolume{VolumeAccessPoints: &[]provider.VolumeAccessPoint{{ID: "target1"}}},
+		},
+	}
+	parameters := map[string]string{ParentShareName: parentName, ParentShareSize: "100", UID: "1000", GID: "2000"}
+	for k, v := range stdENIParams {
+		parameters[k] = v
+	}
+	testCases := []struct {
+		name        string
+		existing    *provider.Volume
+		expCreated  bool
+		expVolumeID string
+	}{
+		{name: "Parent share created on the first volume", expCreated: true, expVolumeID: "share1#target1#pvc-1"},
+		{name: "Existing parent share", existing: parentShare, expVolumeID: "share1#target1#pvc-1"},
+	}
+
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			icDriver := initIBMCSIDriver(t)
+			assert.Nil(t, icDriver.config.ApplyClusterSettings(driverconfig.ClusterSettings{VPCID: "vpc-cluster", VPCSubnetIDs: "subnet-cluster"}))
+			icDriver.cs.SubDirectories = NewSubDirectoryManager(icDriver.ns.Mounter, logger)
+			icDriver.cs.SubDirectories.mountDir = t.TempDir()
+			fakeSession, err := icDriver.cs.CSIProvider.GetProviderSession(context.Background(), logger)
+			assert.Nil(t, err)
+			fakeStructSession := fakeSession.(*fake.FakeSession)
+			fakeStructSession.GetVolumeByNameReturns(tc.existing, nil)
+			fakeStructSession.GetSubnetForVolumeAccessPointReturns("subnet-id", nil)
+			fakeStructSession.GetSecurityGroupForVolumeAccessPointReturns("sg-id", nil)
+			fakeStructSession.CreateVolumeReturns(parentShare, nil)
+			fakeStructSession.WaitForCreateVolumeAccessPointReturns(&provider.VolumeAccessPointResponse{VolumeID: "share1", AccessPointID: "target1", MountPath: "10.0.0.1:/parent"}, nil)
+			fakeStructSession.GetVolumeReturns(parentShare, nil)
+			fakeStructSession.GetVolumeAccessPointReturns(&provider.VolumeAccessPointResponse{VolumeID: "share1", AccessPointID: "target1", MountPath: "10.0.0.1:/parent"}, nil)
+
+			response, err := icDriver.cs.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               "pvc-1",
+				CapacityRange:      &csi.CapacityRange{RequiredBytes: 1 << 30},
+				VolumeCapabilities: []*csi.VolumeCapability{{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER}}},
+				Parameters:         parameters,
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expVolumeID, response.Volume.VolumeId)
+			assert.Equal(t, "10.0.0.1:/parent/pvc-1", response.Volume.VolumeContext[NFSServerPath])
+			if !tc.expCreated {
+				assert.Equal(t, 0, fakeStructSession.CreateVolumeCallCount())
+				return
+			}
+			// the share parameters of the class apply to the parent share, the owner to the sub directory
+			assert.Equal(t, 1, fakeStructSession.CreateVolumeCallCount())
+			created := fakeStructSession.CreateVolumeArgsForCall(0)
+			assert.Equal(t, parentName, *created.Name)
+			assert.Equal(t, parentCapacity, *created.Capacity)
+			assert.Equal(t, DP2Profile, created.Profile.Name)
+			assert.Nil(t, created.InitialOwner)
+		})
+	}
+}
+
+func TestDeleteSubDirectoryVolume(t *testing.T) {
+	testCases := []struct {
+		name       string
+		volumeID   string
+		disabled   bool
+		parent     *provider.Volume
+		expErrCode codes.Code
+		expMounted bool
+	}{
+		{name: "Delete sub directory", volumeID: "share1#target1#pvc-1", parent: &provider.Volume{VolumeID: "share1"}, expMounted: true},
+		{name: "Archive sub directory", volumeID: "share1#target1#pvc-1#archive", parent: &provider.Volume{VolumeID: "share1"}, expMounted: true},
+		{name: "Parent share already deleted", volumeID: "share1#target1#pvc-1"},
+		{name: "Sub directory volumes not enabled", volumeID: "share1#target1#pvc-1", disabled: true, parent: &provider.Volume{VolumeID: "share1"}, expErrCode: codes.FailedPrecondition},
+	}
+
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			icDriver := initIBMCSIDriver(t)
+			if !tc.disabled {
+				icDriver.cs.SubDirectories = NewSubDirectoryManager(icDriver.ns.Mounter, logger)
+				icDriver.cs.SubDirectories.mountDir = t.TempDir()
+			}
+			fakeSession, err := icDriver.cs.CSIProvider.GetProviderSession(context.Background(), logger)
+			assert.Nil(t, err)
+			fakeStructSession := fakeSession.(*fake.FakeSession)
+			fakeStructSession.GetVolumeReturns(tc.parent, nil)
+			fakeStructSession.GetVolumeAccessPointReturns(&provider.VolumeAccessPointResponse{VolumeID: "share1", AccessPointID: "target1", MountPath: "10.0.0.1:/parent"}, nil)
+
+			_, err = icDriver.cs.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: tc.volumeID})
+			assert.Equal(t, tc.expErrCode, status.Code(err))
+			// the parent share is never deleted with its sub directories
+			assert.Equal(t, 0, fakeStructSession.DeleteVolumeCallCount())
+			assert.Equal(t, 0, fakeStructSession.DeleteVolumeAccessPointCallCount())
+			fakeMounter := icDriver.ns.Mounter.GetSafeFormatAndMount().Interface.(*mount.FakeMounter)
+			assert.Equal(t, tc.expMounted, len(fakeMounter.GetLog()) != 0)
+		})
+	}
+}
+
+func TestControllerExpandSubDirectoryVolume(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	response, err := icDriver.cs.ControllerExpandVolume(context.Background(), &csi.ControllerExpandVolumeRequest{
+		VolumeId:      "share1#target1#pvc-1",
+		CapacityRange: &csi.CapacityRange{RequiredBytes: 20 << 30},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &csi.ControllerExpandVolumeResponse{CapacityBytes: 20 << 30}, response)
+}
+
+func TestCreateSnapshotOfSubDirectoryVolume(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	_, err := icDriver.cs.CreateSnapshot(context.Background(), &csi.CreateSnapshotRequest{
+		Name:           "snapshot-1",
+		SourceVolumeId: "share1#target1#pvc-1",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "Snapshots of sub directory volumes are not supported")
+}
@@ -0,0 +1,175 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TLSConfig ... TLS settings of a tcp CSI endpoint, unix sockets are always served without TLS
+type TLSConfig struct {
+	// CertFile and KeyFile hold the PEM encoded server certificate and key, TLS is enabled when set
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the PEM encoded CAs that client certificates are verified against
+	ClientCAFile string
+	// RequireClientCert rejects clients that do not present a certificate signed by ClientCAFile
+	RequireClientCert bool
+}
+
+// Enabled ...
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// Validate ...
+func (c TLSConfig) Validate() error {
+	if !c.Enabled() {
+		if c.ClientCAFile != "" || c.RequireClientCert {
+			return errors.New("client certificate verification requires a server certificate and key")
+		}
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return errors.New("both the server certificate and key are required for TLS")
+	}
+	if c.RequireClientCert && c.ClientCAFile == "" {
+		return errors.New("a client CA is required to verify client certificates")
+	}
+	return nil
+}
+
+// certReloader serves the certificates of a TLSConfig and reloads them when the files change,
+// so rotated certificates are picked up on the next handshake without a restart
+type certReloader struct {
+	config TLSConfig
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	modTime   time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// newCertReloader ...
+func newCertReloader(config TLSConfig, logger *zap.Logger) (*certReloader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	r := &certReloader{config: config, logger: logger}
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// serverConfig ...
+func (r *certReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: r.getConfigForClient,
+	}
+}
+
+// getConfigForClient returns the TLS config of a handshake built from the latest certificates
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if modTime, err := r.latestModTime(); err != nil {
+		r.logger.Warn("Failed to check TLS certificates for changes, serving the loaded ones", zap.Error(err))
+	} else if modTime.After(r.modTime) {
+		if err := r.load(modTime); err != nil {
+			r.logger.Warn("Failed to reload TLS certificates, serving the loaded ones", zap.Error(err))
+		} else {
+			r.logger.Info("Reloaded TLS certificates", zap.String("certFile", r.config.CertFile))
+		}
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*r.cert},
+		ClientAuth:   tls.NoClientCert,
+	}
+	if r.clientCAs != nil {
+		config.ClientCAs = r.clientCAs
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.config.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return config, nil
+}
+
+// load reads the certificate, key and client CA, must be called with mu held unless r is not shared yet
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA %s", r.config.ClientCAFile)
+		}
+	}
+
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTime = modTime
+	return nil
+}
+
+// latestModTime returns the newest modification time of the configured files. Stat follows
+// symlinks, so the atomic symlink swap of a mounted Kubernetes secret is detected too
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,249 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cloudProvider "github.com/IBM/ibmcloud-volume-file-vpc/pkg/ibmcloudprovider"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// testCert is a PEM encoded certificate and key signed by the CA that is passed to newTestCert
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, commonName string, ca *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parent, parentKey := template, key
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, parentKey = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeTestFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	assert.Nil(t, os.WriteFile(path, data, 0600))
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	testcases := []struct {
+		testCaseName  string
+		config        TLSConfig
+		expectEnabled bool
+		expectErr     bool
+	}{
+		{
+			testCaseName: "TLS disabled",
+			config:       TLSConfig{},
+		},
+		{
+			testCaseName:  "Server TLS",
+			config:        TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key"},
+			expectEnabled: true,
+		},
+		{
+			testCaseName:  "Mutual TLS",
+			config:        TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt", RequireClientCert: true},
+			expectEnabled: true,
+		},
+		{
+			testCaseName:  "Missing key",
+			config:        TLSConfig{CertFile: "tls.crt"},
+			expectEnabled: true,
+			expectErr:     true,
+		},
+		{
+			testCaseName:  "Client certificates required without CA",
+			config:        TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", RequireClientCert: true},
+			expectEnabled: true,
+			expectErr:     true,
+		},
+		{
+			testCaseName: "Client CA without server certificate",
+			config:       TLSConfig{ClientCAFile: "ca.crt"},
+			expectErr:    true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			assert.Equal(t, testcase.expectEnabled, testcase.config.Enabled())
+			assert.Equal(t, testcase.expectErr, testcase.config.Validate() != nil)
+		})
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+
+	dir := t.TempDir()
+	config := TLSConfig{
+		CertFile:          filepath.Join(dir, "tls.crt"),
+		KeyFile:           filepath.Join(dir, "tls.key"),
+		ClientCAFile:      filepath.Join(dir, "ca.crt"),
+		RequireClientCert: true,
+	}
+	ca := newTestCert(t, "ca", nil)
+	first := newTestCert(t, "first", ca)
+	modTime := time.Now().Add(-time.Minute)
+	writeTestFile(t, config.ClientCAFile, ca.certPEM, modTime)
+	writeTestFile(t, config.CertFile, first.certPEM, modTime)
+	writeTestFile(t, config.KeyFile, first.keyPEM, modTime)
+
+	reloader, err := newCertReloader(config, logger)
+	assert.Nil(t, err)
+	tlsConfig, err := reloader.getConfigForClient(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+	assert.Equal(t, first.cert.Raw, tlsConfig.Certificates[0].Certificate[0])
+
+	// A half written rotation keeps serving the loaded certificate
+	second := newTestCert(t, "second", ca)
+	writeTestFile(t, config.CertFile, second.certPEM, time.Now())
+	tlsConfig, err = reloader.getConfigForClient(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, first.cert.Raw, tlsConfig.Certificates[0].Certificate[0])
+
+	// The rotated certificate is served once the key matches
+	writeTestFile(t, config.KeyFile, second.keyPEM, time.Now())
+	tlsConfig, err = reloader.getConfigForClient(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, second.cert.Raw, tlsConfig.Certificates[0].Certificate[0])
+
+	// Missing files fail at startup
+	config.ClientCAFile = filepath.Join(dir, "missing.crt")
+	_, err = newCertReloader(config, logger)
+	assert.NotNil(t, err)
+}
+
+func TestSetupTLS(t *testing.T) {
+	logger, teardown := cloudProvider.GetTestLogger(t)
+	defer teardown()
+
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", ca)
+	client := newTestCert(t, "client", ca)
+	config := TLSConfig{
+		CertFile:          filepath.Join(dir, "tls.crt"),
+		KeyFile:           filepath.Join(dir, "tls.key"),
+		ClientCAFile:      filepath.Join(dir, "ca.crt"),
+		RequireClientCert: true,
+	}
+	writeTestFile(t, config.ClientCAFile, ca.certPEM, time.Now())
+	writeTestFile(t, config.CertFile, server.certPEM, time.Now())
+	writeTestFile(t, config.KeyFile, server.keyPEM, time.Now())
+
+	s := NewNonBlockingGRPCServer(logger).(*nonBlockingGRPCServer)
+	s.SetTLSConfig(config)
+	listener, err := s.Setup("tcp://127.0.0.1:0", &CSIIdentityServer{Driver: &IBMCSIDriver{name: "test", vendorVersion: "1.0", logger: logger}}, nil, nil)
+	assert.Nil(t, err)
+	go func() { _ = s.server.Serve(listener) }()
+	defer s.ForceStop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert, err := tls.X509KeyPair(client.certPEM, client.keyPEM)
+	assert.Nil(t, err)
+
+	testcases := []struct {
+		testCaseName string
+		certificates []tls.Certificate
+		expectErr    bool
+	}{
+		{
+			testCaseName: "Client with certificate",
+			certificates: []tls.Certificate{clientCert},
+		},
+		{
+			testCaseName: "Client without certificate",
+			expectErr:    true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			creds := credentials.NewTLS(&tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: testcase.certificates, MinVersion: tls.VersionTLS12})
+			conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(creds))
+			assert.Nil(t, err)
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			resp, err := csi.NewIdentityClient(conn).GetPluginInfo(ctx, &csi.GetPluginInfoRequest{})
+			assert.Equal(t, testcase.expectErr, err != nil)
+			if !testcase.expectErr {
+				assert.Equal(t, "test", resp.GetName())
+			}
+		})
+	}
+
+	// Invalid certificates fail the setup
+	s = NewNonBlockingGRPCServer(logger).(*nonBlockingGRPCServer)
+	s.SetTLSConfig(TLSConfig{CertFile: config.ClientCAFile, KeyFile: config.KeyFile})
+	_, err = s.Setup("tcp://127.0.0.1:0", nil, nil, nil)
+	assert.NotNil(t, err)
+}
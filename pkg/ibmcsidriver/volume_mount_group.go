@@ -0,0 +1,90 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+// volumeMountGroupMode group permissions set on the share root for the volume mount group. The setgid bit
+// makes new files and directories inherit the group.
+const volumeMountGroupMode = os.ModeSetgid | 0070
+
+// noVolumeMountGroup ...
+const noVolumeMountGroup = -1
+
+// parseVolumeMountGroup returns the GID of the volume_mount_group (the pod fsGroup) of the capability,
+// noVolumeMountGroup when it is not set
+func parseVolumeMountGroup(volumeCapability *csi.VolumeCapability) (int, error) {
+	group := volumeCapability.GetMount().GetVolumeMountGroup()
+	if group == "" {
+		return noVolumeMountGroup, nil
+	}
+	gid, err := strconv.Atoi(group)
+	if err != nil || gid < 0 {
+		return noVolumeMountGroup, fmt.Errorf("invalid group ID %q", group)
+	}
+	return gid, nil
+}
+
+// volumeMountGroupConflict the share root was already given to another volume mount group
+type volumeMountGroupConflict struct {
+	gid int
+}
+
+func (e *volumeMountGroupConflict) Error() string {
+	return fmt.Sprintf("the share root is owned by group %d", e.gid)
+}
+
+// applyVolumeMountGroup gives the root of the share mounted at target to the group gid: group owned, group
+// writable and setgid. NFS has no mount option for the group of a share, and a recursive change over NFS is
+// slow, so only the share root is changed; new entries inherit the group through the setgid bit. The same for
+// plain, tunnelled and bind mounted shares. Only a root that is still owned by the root group is claimed, a
+// root that already belongs to gid is left as it is, and a root of another group, e.g. of another pod fsGroup
+// or of the gid storage class parameter, is never taken over and returns a volumeMountGroupConflict.
+func applyVolumeMountGroup(target string, gid int) error {
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unable to read the owner of %s", target)
+	}
+	mode := info.Mode()
+	owner := int(stat.Gid)
+	switch {
+	case owner == gid && mode&volumeMountGroupMode == volumeMountGroupMode:
+		return nil
+	case owner == gid:
+	case owner == 0 && mode&os.ModeSetgid == 0:
+		if err := os.Chown(target, -1, gid); err != nil {
+			return err
+		}
+	default:
+		return &volumeMountGroupConflict{gid: owner}
+	}
+	return os.Chmod(target, mode&(os.ModePerm|os.ModeSetuid|os.ModeSticky)|volumeMountGroupMode)
+}
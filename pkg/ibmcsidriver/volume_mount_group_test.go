@@ -0,0 +1,111 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVolumeMountGroup(t *testing.T) {
+	testCases := []struct {
+		testCaseName string
+		group        string
+		expGID       int
+		expErr       bool
+	}{
+		{testCaseName: "not set", group: "", expGID: noVolumeMountGroup},
+		{testCaseName: "numeric group", group: "2000", expGID: 2000},
+		{testCaseName: "root group", group: "0", expGID: 0},
+		{testCaseName: "group name", group: "users", expGID: noVolumeMountGroup, expErr: true},
+		{testCaseName: "negative group", group: "-5", expGID: noVolumeMountGroup, expErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			volumeCapability := &csi.VolumeCapability{
+				AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{VolumeMountGroup: tc.group}},
+			}
+			gid, err := parseVolumeMountGroup(volumeCapability)
+			assert.Equal(t, tc.expGID, gid)
+			assert.Equal(t, tc.expErr, err != nil)
+		})
+	}
+}
+
+func TestApplyVolumeMountGroup(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the group of a directory needs root")
+	}
+	target := t.TempDir()
+	assert.Nil(t, os.Chmod(target, 0755))
+
+	assert.Nil(t, applyVolumeMountGroup(target, 2000))
+	info, err := os.Stat(target)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2000), info.Sys().(*syscall.Stat_t).Gid)
+	assert.Equal(t, os.ModeDir|os.ModeSetgid|0775, info.Mode())
+
+	// a second publish leaves the share root as it is
+	assert.Nil(t, applyVolumeMountGroup(target, 2000))
+	info, err = os.Stat(target)
+	assert.Nil(t, err)
+	assert.Equal(t, os.ModeDir|os.ModeSetgid|0775, info.Mode())
+
+	assert.NotNil(t, applyVolumeMountGroup(filepath.Join(target, "missing"), 2000))
+}
+
+func TestApplyVolumeMountGroupConflict(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the group of a directory needs root")
+	}
+	testCases := []struct {
+		testCaseName string
+		rootGID      int
+		rootMode     os.FileMode
+	}{
+		{testCaseName: "root of the gid storage class parameter", rootGID: 3000, rootMode: 0775},
+		{testCaseName: "root claimed by another mount group", rootGID: 3000, rootMode: os.ModeSetgid | 0775},
+		{testCaseName: "root claimed by mount group 0", rootGID: 0, rootMode: os.ModeSetgid | 0775},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			target := t.TempDir()
+			assert.Nil(t, os.Chown(target, -1, tc.rootGID))
+			assert.Nil(t, os.Chmod(target, tc.rootMode))
+
+			err := applyVolumeMountGroup(target, 2000)
+			var conflict *volumeMountGroupConflict
+			if assert.True(t, errors.As(err, &conflict)) {
+				assert.Equal(t, tc.rootGID, conflict.gid)
+			}
+			// the share root is left as it is
+			info, err := os.Stat(target)
+			assert.Nil(t, err)
+			assert.Equal(t, uint32(tc.rootGID), info.Sys().(*syscall.Stat_t).Gid)
+			assert.Equal(t, os.ModeDir|tc.rootMode, info.Mode())
+		})
+	}
+}
@@ -0,0 +1,217 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultVolumeStatsCacheTTL time the usage of a share is reused, twice the interval kubelet asks for the stats of a
+	// pod volume at, so that every other call of a path and the calls of the other paths of the share reuse it
+	DefaultVolumeStatsCacheTTL = 2 * time.Minute
+
+	// DefaultVolumeStatsTimeout time a statfs may take before NodeGetVolumeStats reports the mount unresponsive
+	DefaultVolumeStatsTimeout = 10 * time.Second
+
+	// volumeStatsEntryMaxAge shares whose stats were not fetched for this long are dropped from the cache
+	volumeStatsEntryMaxAge = 15 * time.Minute
+)
+
+// VolumeStatsConfig ...
+type VolumeStatsConfig struct {
+	// CacheTTL time the usage of a share is reused, 0 runs a statfs for every call
+	CacheTTL time.Duration
+
+	// Timeout time a statfs may take before the mount is reported unresponsive
+	Timeout time.Duration
+}
+
+// volumeStats file system stats of a share, in bytes and inodes
+type volumeStats struct {
+	available, capacity, used      int64
+	inodes, inodesFree, inodesUsed int64
+}
+
+// statsFetch statfs of one volume path, stats and err are set before done is closed
+type statsFetch struct {
+	done  chan struct{}
+	stats volumeStats
+	err   error
+}
+
+type volumeStatsEntry struct {
+	stats   volumeStats
+	fetched time.Time
+}
+
+// VolumeStatsCache runs the statfs of NodeGetVolumeStats. All paths of a share report the same usage, so the
+// usage is cached per share and fetched by the statfs of whichever path asks once it is older than the TTL.
+// The health of a mount is checked per path on every call: a path served from the cached usage is probed by
+// the mount health checker, a path whose statfs fetched the usage is healthy by that. The statfs runs in a
+// separate goroutine that concurrent calls of the same path join. A statfs or probe that fails with a broken
+// mount error or does not return within its timeout reports the volume condition abnormal, with the last
+// known usage of the share so that a slow or stale NFS server does not block the kubelet stats loop or drop
+// the usage graphs to zero.
+type VolumeStatsCache struct {
+	config VolumeStatsConfig
+	health *MountHealthChecker
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu sync.Mutex
+	// fetches running statfs by path
+	fetches map[string]*statsFetch
+	// shares last known stats by share
+	shares map[string]*volumeStatsEntry
+}
+
+// NewVolumeStatsCache ...
+func NewVolumeStatsCache(config VolumeStatsConfig, health *MountHealthChecker, logger *zap.Logger) *VolumeStatsCache {
+	return &VolumeStatsCache{
+		config:  config,
+		health:  health,
+		logger:  logger,
+		now:     time.Now,
+		fetches: map[string]*statsFetch{},
+		shares:  map[string]*volumeStatsEntry{},
+	}
+}
+
+// volumeStatsKey the stats are kept per share, the volume ID has the format shareID#targetID
+func volumeStatsKey(volumeID string) string {
+	if tokens := getTokens(volumeID); len(tokens) > 0 && tokens[0] != "" {
+		return tokens[0]
+	}
+	return volumeID
+}
+
+// Fresh returns the stats of the share when they were fetched within the TTL
+func (vc *VolumeStatsCache) Fresh(share string) (volumeStats, bool) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	entry, ok := vc.shares[share]
+	if !ok || vc.now().Sub(entry.fetched) >= vc.config.CacheTTL {
+		return volumeStats{}, false
+	}
+	return entry.stats, true
+}
+
+// Last returns the last known stats of the share, however old they are
+func (vc *VolumeStatsCache) Last(share string) (volumeStats, bool) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	entry, ok := vc.shares[share]
+	if !ok {
+		return volumeStats{}, false
+	}
+	return entry.stats, true
+}
+
+// Get returns the stats of the share and the condition of its mount at path. The mount is probed when the
+// stats of the share are fresh, statfs of path runs otherwise. Errors that do not mean a broken mount, e.g. a
+// path that does not exist, are returned.
+func (vc *VolumeStatsCache) Get(ctx context.Context, ctxLogger *zap.Logger, share, path string, statfs func() (volumeStats, error)) (volumeStats, *csi.VolumeCondition, error) {
+	var reason string
+	var err error
+	timeout := vc.config.Timeout
+	if stats, ok := vc.Fresh(share); ok {
+		timeout = vc.health.timeout
+		if reason, err = vc.health.probeReason(ctx, path); reason == "" {
+			if err != nil {
+				return volumeStats{}, nil, err
+			}
+			return stats, healthyVolumeCondition(), nil
+		}
+	} else {
+		if stats, err := vc.fetch(ctx, share, path, statfs); err == nil {
+			return stats, healthyVolumeCondition(), nil
+		} else if reason = mountConditionReason(err); reason == "" {
+			return volumeStats{}, nil, err
+		}
+	}
+
+	driverMetrics.VolumeConditionAbnormal.WithLabelValues(reason).Inc()
+	ctxLogger.Warn("Mount is not healthy", zap.String("path", path), zap.String("reason", reason), zap.Error(err))
+	stats, _ := vc.Last(share)
+	return stats, mountCondition(path, reason, timeout, err), nil
+}
+
+// fetch waits for the statfs of path up to the timeout
+func (vc *VolumeStatsCache) fetch(ctx context.Context, share, path string, statfs func() (volumeStats, error)) (volumeStats, error) {
+	fetch := vc.start(share, path, statfs)
+	timeout := time.NewTimer(vc.config.Timeout)
+	defer timeout.Stop()
+	select {
+	case <-fetch.done:
+		return fetch.stats, fetch.err
+	case <-timeout.C:
+	case <-ctx.Done():
+	}
+	return volumeStats{}, context.DeadlineExceeded
+}
+
+// start returns the statfs of the volume path, starting one unless it is still running
+func (vc *VolumeStatsCache) start(share, path string, statfs func() (volumeStats, error)) *statsFetch {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if fetch, ok := vc.fetches[path]; ok {
+		return fetch
+	}
+	vc.prune(vc.now())
+
+	fetch := &statsFetch{done: make(chan struct{})}
+	vc.fetches[path] = fetch
+	go func() {
+		// counted once the call is slow, a statfs on a hung mount may never return
+		slow := time.AfterFunc(vc.config.Timeout, func() {
+			driverMetrics.SlowStatfs.Inc()
+			vc.logger.Warn("Volume statfs is slow", zap.String("path", path), zap.Duration("timeout", vc.config.Timeout))
+		})
+		start := time.Now()
+		fetch.stats, fetch.err = statfs()
+		slow.Stop()
+		driverMetrics.StatfsDuration.Observe(time.Since(start).Seconds())
+
+		vc.mu.Lock()
+		if fetch.err == nil {
+			vc.shares[share] = &volumeStatsEntry{stats: fetch.stats, fetched: vc.now()}
+		}
+		delete(vc.fetches, path)
+		vc.mu.Unlock()
+		close(fetch.done)
+	}()
+	return fetch
+}
+
+// prune drops the shares whose stats were not fetched for a while, e.g. after they were unpublished
+func (vc *VolumeStatsCache) prune(now time.Time) {
+	for share, entry := range vc.shares {
+		if now.Sub(entry.fetched) > volumeStatsEntryMaxAge {
+			delete(vc.shares, share)
+		}
+	}
+}
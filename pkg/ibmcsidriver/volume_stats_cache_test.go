@@ -0,0 +1,277 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ibmcsidriver ...
+package ibmcsidriver
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	csi "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestVolumeStatsCache(t *testing.T, config VolumeStatsConfig, probe func(path string) error) *VolumeStatsCache {
+	logger, teardown := GetTestLogger(t)
+	t.Cleanup(teardown)
+	health := NewMountHealthChecker(time.Second, logger)
+	health.probe = probe
+	return NewVolumeStatsCache(config, health, logger)
+}
+
+// countingProbe counts the mount health probes and fails them with err
+type countingProbe struct {
+	calls int32
+	err   error
+}
+
+func (cp *countingProbe) probe(string) error {
+	atomic.AddInt32(&cp.calls, 1)
+	return cp.err
+}
+
+func TestVolumeStatsKey(t *testing.T) {
+	assert.Equal(t, "share-1", volumeStatsKey("share-1#target-1"))
+	assert.Equal(t, "share-1", volumeStatsKey("share-1:target-1"))
+	assert.Equal(t, "share-1", volumeStatsKey("share-1"))
+}
+
+func TestVolumeStatsCacheTTL(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	probe := &countingProbe{}
+	cache := newTestVolumeStatsCache(t, VolumeStatsConfig{CacheTTL: time.Minute, Timeout: time.Second}, probe.probe)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	var calls int32
+	statfs := func() (volumeStats, error) {
+		return volumeStats{used: int64(atomic.AddInt32(&calls, 1))}, nil
+	}
+
+	stats, condition, err := cache.Get(context.Background(), logger, "share-1", "/target-1", statfs)
+	assert.Nil(t, err)
+	assert.False(t, condition.Abnormal)
+	assert.Equal(t, int64(1), stats.used)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&probe.calls))
+
+	// the same path gets the cached usage of the share after a probe of its mount
+	stats, condition, err = cache.Get(context.Background(), logger, "share-1", "/target-1", statfs)
+	assert.Nil(t, err)
+	assert.False(t, condition.Abnormal)
+	assert.Equal(t, int64(1), stats.used)
+
+	// and so do the other targets of the share
+	stats, _, err = cache.Get(context.Background(), logger, "share-1", "/target-2", statfs)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), stats.used)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&probe.calls))
+
+	// other shares have their own usage
+	stats, _, err = cache.Get(context.Background(), logger, "share-2", "/target-3", statfs)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), stats.used)
+
+	now = now.Add(time.Minute)
+	stats, _, err = cache.Get(context.Background(), logger, "share-1", "/target-2", statfs)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), stats.used)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&probe.calls))
+}
+
+func TestVolumeStatsCacheDisabled(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	cache := newTestVolumeStatsCache(t, VolumeStatsConfig{CacheTTL: 0, Timeout: time.Second}, nil)
+
+	var calls int32
+	statfs := func() (volumeStats, error) {
+		atomic.AddInt32(&calls, 1)
+		return volumeStats{}, nil
+	}
+	for i := 0; i < 3; i++ {
+		_, _, err := cache.Get(context.Background(), logger, "share-1", "/target-1", statfs)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestVolumeStatsCacheError(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	cache := newTestVolumeStatsCache(t, VolumeStatsConfig{CacheTTL: time.Minute, Timeout: time.Second}, nil)
+
+	_, condition, err := cache.Get(context.Background(), logger, "share-1", "/target-1", func() (volumeStats, error) {
+		return volumeStats{}, unix.EACCES
+	})
+	assert.True(t, errors.Is(err, unix.EACCES))
+	assert.Nil(t, condition)
+	// failures are not cached
+	_, ok := cache.Last("share-1")
+	assert.False(t, ok)
+	_, ok = cache.Fresh("share-1")
+	assert.False(t, ok)
+}
+
+func TestVolumeStatsCacheBrokenMount(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	probe := &countingProbe{}
+	cache := newTestVolumeStatsCache(t, VolumeStatsConfig{CacheTTL: time.Minute, Timeout: time.Second}, probe.probe)
+
+	_, _, err := cache.Get(context.Background(), logger, "share-1", "/target-1", func() (volumeStats, error) {
+		return volumeStats{used: 1}, nil
+	})
+	assert.Nil(t, err)
+
+	// a stale path of the share is abnormal although the share has fresh stats from another path
+	probe.err = unix.ESTALE
+	stats, condition, err := cache.Get(context.Background(), logger, "share-1", "/target-2", func() (volumeStats, error) {
+		return volumeStats{used: 2}, nil
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, condition) {
+		assert.True(t, condition.Abnormal)
+		assert.Contains(t, condition.Message, mountStale)
+	}
+	assert.Equal(t, int64(1), stats.used)
+
+	// a probed path that does not exist is an error, not an abnormal mount
+	probe.err = unix.ENOENT
+	_, condition, err = cache.Get(context.Background(), logger, "share-1", "/target-2", nil)
+	assert.True(t, errors.Is(err, unix.ENOENT))
+	assert.Nil(t, condition)
+
+	// a statfs that fails with a broken mount error is abnormal as well
+	_, condition, err = cache.Get(context.Background(), logger, "share-2", "/target-3", func() (volumeStats, error) {
+		return volumeStats{}, unix.EIO
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, condition) {
+		assert.True(t, condition.Abnormal)
+		assert.Contains(t, condition.Message, mountIOError)
+	}
+}
+
+func TestVolumeStatsCacheTimeout(t *testing.T) {
+	logger, teardown := GetTestLogger(t)
+	defer teardown()
+	cache := newTestVolumeStatsCache(t, VolumeStatsConfig{CacheTTL: time.Minute, Timeout: 20 * time.Millisecond}, nil)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	release := make(chan struct{})
+	var calls int32
+	hung := func() (volumeStats, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return volumeStats{used: 2}, nil
+	}
+
+	// no stats known yet
+	stats, condition, err := cache.Get(context.Background(), logger, "share-1", "/target-1", hung)
+	assert.Nil(t, err)
+	if assert.NotNil(t, condition) {
+		assert.True(t, condition.Abnormal)
+		assert.Contains(t, condition.Message, mountUnresponsive)
+	}
+	assert.Equal(t, volumeStats{}, stats)
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, ok := cache.Last("share-1")
+		return ok
+	}, time.Second, 5*time.Millisecond)
+
+	// the late result of the hung call was cached, the next slow call reports it with an abnormal condition
+	now = now.Add(time.Minute)
+	block := make(chan struct{})
+	defer close(block)
+	slow := func() (volumeStats, error) {
+		atomic.AddInt32(&calls, 1)
+		<-block
+		return volumeStats{used: 3}, nil
+	}
+	for i := 0; i < 2; i++ {
+		stats, condition, err := cache.Get(context.Background(), logger, "share-1", "/target-1", slow)
+		assert.Nil(t, err)
+		assert.True(t, condition.Abnormal)
+		assert.Equal(t, int64(2), stats.used)
+	}
+	// the second call joined the running statfs
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+// countingStatsUtils counts the FSInfo calls
+type countingStatsUtils struct {
+	MockStatUtils
+	calls int32
+}
+
+func (su *countingStatsUtils) FSInfo(path string) (int64, int64, int64, int64, int64, int64, error) {
+	atomic.AddInt32(&su.calls, 1)
+	return su.MockStatUtils.FSInfo(path)
+}
+
+func TestNodeGetVolumeStatsCached(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	stats := &countingStatsUtils{}
+	icDriver.ns.Stats = stats
+	probe := &countingProbe{}
+	icDriver.ns.MountHealth.probe = probe.probe
+
+	requests := []*csi.NodeGetVolumeStatsRequest{
+		{VolumeId: "share-1#target-1", VolumePath: "/pods/pod-1/mount"},
+		{VolumeId: "share-1#target-1", VolumePath: "/pods/pod-1/mount"},
+		{VolumeId: "share-1#target-1", VolumePath: "/pods/pod-2/mount"},
+	}
+	for _, req := range requests {
+		resp, err := icDriver.ns.NodeGetVolumeStats(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), resp.Usage[0].Total)
+		assert.False(t, resp.VolumeCondition.Abnormal)
+	}
+	// the usage of the share is fetched once per TTL, the mounts of the other calls are probed
+	assert.Equal(t, int32(1), atomic.LoadInt32(&stats.calls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&probe.calls))
+
+	// an abnormal mount keeps reporting the last known usage
+	icDriver.ns.VolumeStats.now = func() time.Time { return time.Now().Add(DefaultVolumeStatsCacheTTL) }
+	icDriver.ns.Stats = &failingStatsUtils{err: unix.ESTALE}
+	resp, err := icDriver.ns.NodeGetVolumeStats(context.Background(), requests[0])
+	assert.Nil(t, err)
+	assert.True(t, resp.VolumeCondition.Abnormal)
+	assert.Equal(t, int64(1), resp.Usage[0].Total)
+}
+
+func TestNodeGetVolumeStatsPathNotExist(t *testing.T) {
+	icDriver := initIBMCSIDriver(t)
+	icDriver.ns.Stats = &failingStatsUtils{err: unix.ENOENT}
+
+	_, err := icDriver.ns.NodeGetVolumeStats(context.Background(), &csi.NodeGetVolumeStatsRequest{
+		VolumeId:   defaultVolumeID,
+		VolumePath: notBlockDevice,
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
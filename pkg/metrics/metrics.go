@@ -0,0 +1,186 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package metrics contains the prometheus metrics exported by the IBM VPC File CSI driver.
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Namespace of all driver metrics
+const Namespace = "ibm_vpc_file_csi"
+
+var (
+	// RPCRequests counts CSI RPCs by method, volume profile, CSI error code and gRPC status
+	RPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "rpc_requests_total",
+		Help:      "Number of CSI RPCs handled, by method, volume profile, CSI error code and gRPC status.",
+	}, []string{"method", "profile", "error_code", "grpc_status"})
+
+	// RPCInFlight number of CSI RPCs currently being handled
+	RPCInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "rpc_in_flight",
+		Help:      "Number of CSI RPCs currently being handled, by method.",
+	}, []string{"method"})
+
+	// ProviderCallDuration latency of the VPC provider session calls
+	ProviderCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "provider_call_duration_seconds",
+		Help:      "Latency of VPC provider session calls, by operation and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 13), // 50ms to ~200s, Wait* calls poll for minutes
+	}, []string{"operation", "result"})
+
+	// MountFailures counts failed NodeStageVolume and NodePublishVolume mounts by reason
+	MountFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "mount_failures_total",
+		Help:      "Number of failed mounts, by failure reason.",
+	}, []string{"reason"})
+
+	// VolumeConditionAbnormal counts NodeGetVolumeStats calls that found a broken mount, by reason
+	VolumeConditionAbnormal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "volume_condition_abnormal_total",
+		Help:      "Number of volume stats requests that reported an abnormal mount, by reason.",
+	}, []string{"reason"})
+
+	// StatfsDuration latency of the statfs calls of NodeGetVolumeStats
+	StatfsDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "statfs_duration_seconds",
+		Help:      "Latency of volume statfs calls.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10), // 1ms to ~4min
+	})
+
+	// SlowStatfs counts volume statfs calls that did not return within the volume stats timeout
+	SlowStatfs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "statfs_slow_total",
+		Help:      "Number of volume statfs calls that took longer than the volume stats timeout.",
+	})
+
+	// StaleMountRemounts counts remount attempts of volumes with stale NFS mounts, by result
+	StaleMountRemounts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "stale_mount_remounts_total",
+		Help:      "Number of remount attempts of volumes with stale NFS mounts, by result.",
+	}, []string{"result"})
+
+	// AllocatedStunnelPorts number of stunnel ports allocated to RFS EIT volumes on this node
+	AllocatedStunnelPorts = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "stunnel_allocated_ports",
+		Help:      "Number of local stunnel ports allocated to RFS EIT volumes.",
+	})
+
+	// ActiveTunnels number of allocated tunnels with at least one NFS mount on this node
+	ActiveTunnels = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "stunnel_active_tunnels",
+		Help:      "Number of stunnel tunnels with at least one active NFS mount.",
+	}, activeTunnels)
+
+	// CircuitBreakerState state of the VPC provider circuit breaker
+	CircuitBreakerState = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "circuit_breaker_state",
+		Help:      "State of the VPC provider circuit breaker (0=closed, 1=open, 2=half-open).",
+	})
+
+	// CircuitBreakerRejected counts requests rejected while the circuit breaker was open
+	CircuitBreakerRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "circuit_breaker_rejected_total",
+		Help:      "Number of controller requests rejected while the VPC provider circuit breaker was open.",
+	})
+
+	// ClusterConfigApplied resource version of the last applied cluster provider data ConfigMap, the value is always 1
+	ClusterConfigApplied = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "cluster_config_applied_info",
+		Help:      "Resource version of the last applied ibm-cloud-provider-data ConfigMap.",
+	}, []string{"resource_version"})
+
+	// ClusterConfigUpdates counts the applied and rejected cluster provider data ConfigMap events
+	ClusterConfigUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "cluster_config_updates_total",
+		Help:      "Number of ibm-cloud-provider-data ConfigMap events, by event and result.",
+	}, []string{"event", "result"})
+)
+
+var (
+	activeTunnelsMu   sync.RWMutex
+	activeTunnelsFunc func() int
+)
+
+// SetActiveTunnelsFunc sets the source of the active tunnels gauge, evaluated on every scrape
+func SetActiveTunnelsFunc(f func() int) {
+	activeTunnelsMu.Lock()
+	defer activeTunnelsMu.Unlock()
+	activeTunnelsFunc = f
+}
+
+func activeTunnels() float64 {
+	activeTunnelsMu.RLock()
+	defer activeTunnelsMu.RUnlock()
+	if activeTunnelsFunc == nil {
+		return 0
+	}
+	return float64(activeTunnelsFunc())
+}
+
+// SetClusterConfigApplied records the resource version of the applied cluster provider data ConfigMap
+func SetClusterConfigApplied(resourceVersion string) {
+	ClusterConfigApplied.Reset()
+	ClusterConfigApplied.WithLabelValues(resourceVersion).Set(1)
+}
+
+// Result returns the result label for an operation outcome
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// RegisterAll registers the driver metrics with the default prometheus registry
+func RegisterAll() {
+	prometheus.MustRegister(
+		RPCRequests,
+		RPCInFlight,
+		ProviderCallDuration,
+		MountFailures,
+		VolumeConditionAbnormal,
+		StatfsDuration,
+		SlowStatfs,
+		StaleMountRemounts,
+		AllocatedStunnelPorts,
+		ActiveTunnels,
+		CircuitBreakerState,
+		CircuitBreakerRejected,
+		ClusterConfigApplied,
+		ClusterConfigUpdates,
+	)
+}
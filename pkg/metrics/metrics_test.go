@@ -0,0 +1,48 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package metrics ...
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterAll(t *testing.T) {
+	assert.NotPanics(t, RegisterAll)
+	// registering twice must fail loudly
+	assert.Panics(t, RegisterAll)
+}
+
+func TestActiveTunnels(t *testing.T) {
+	SetActiveTunnelsFunc(nil)
+	assert.Equal(t, float64(0), testutil.ToFloat64(ActiveTunnels))
+
+	SetActiveTunnelsFunc(func() int { return 3 })
+	defer SetActiveTunnelsFunc(nil)
+	assert.Equal(t, float64(3), testutil.ToFloat64(ActiveTunnels))
+}
+
+func TestResult(t *testing.T) {
+	assert.Equal(t, "success", Result(nil))
+	assert.Equal(t, "error", Result(errors.New("failed")))
+}
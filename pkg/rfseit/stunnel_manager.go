@@ -24,6 +24,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -35,6 +36,7 @@ import (
 	"syscall"
 	"time"
 
+	driverMetrics "github.com/IBM/ibm-vpc-file-csi-driver/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -109,12 +111,18 @@ type StunnelManager struct {
 // NewStunnelManager creates a new StunnelManager with defaults derived from
 // the OS_TYPE and CLUSTER_ENV environment variables.
 func NewStunnelManager(logger *zap.Logger) (*StunnelManager, error) {
+	return NewStunnelManagerWithSettings(os.Getenv("OS_TYPE"), os.Getenv("CLUSTER_ENV"), logger)
+}
+
+// NewStunnelManagerWithSettings creates a new StunnelManager for the given worker OS
+// (selects the CA bundle) and cluster environment (selects the checkHost).
+func NewStunnelManagerWithSettings(osType, clusterEnv string, logger *zap.Logger) (*StunnelManager, error) {
 	if logger == nil {
 		return nil, fmt.Errorf("logger is required")
 	}
 
-	// Auto-detect CA bundle based on OS_TYPE environment variable
-	caFile, err := detectCABundle(logger)
+	// Auto-detect CA bundle based on the OS type
+	caFile, err := caBundleForOSType(osType, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect CA bundle: %w", err)
 	}
@@ -122,8 +130,8 @@ func NewStunnelManager(logger *zap.Logger) (*StunnelManager, error) {
 		return nil, fmt.Errorf("failed to detect CA bundle: empty CA bundle path")
 	}
 
-	// Determine checkHost based on CLUSTER_ENV environment variable
-	checkHost, err := getClusterEnv(logger)
+	// Determine checkHost based on the cluster environment
+	checkHost, err := checkHostForClusterEnv(clusterEnv, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine checkHost: %w", err)
 	}
@@ -165,8 +173,11 @@ func NewStunnelManager(logger *zap.Logger) (*StunnelManager, error) {
 // detectCABundle determines the system CA bundle path based on OS_TYPE environment variable.
 // Returns error if OS_TYPE is not set or is unknown.
 func detectCABundle(logger *zap.Logger) (string, error) {
-	// OS_TYPE environment variable is mandatory
-	osType := os.Getenv("OS_TYPE")
+	return caBundleForOSType(os.Getenv("OS_TYPE"), logger)
+}
+
+// caBundleForOSType returns the system CA bundle path of the worker OS, the OS type is mandatory.
+func caBundleForOSType(osType string, logger *zap.Logger) (string, error) {
 	if osType == "" {
 		return "", fmt.Errorf("OS_TYPE environment variable is required but not set")
 	}
@@ -197,7 +208,11 @@ func detectCABundle(logger *zap.Logger) (string, error) {
 // getClusterEnv determines the hostname for TLS certificate verification based on CLUSTER_ENV.
 // Defaults to production when CLUSTER_ENV is not set or is unknown.
 func getClusterEnv(logger *zap.Logger) (string, error) {
-	clusterEnv := os.Getenv("CLUSTER_ENV")
+	return checkHostForClusterEnv(os.Getenv("CLUSTER_ENV"), logger)
+}
+
+// checkHostForClusterEnv returns the checkHost of the cluster environment, production for empty or unknown values.
+func checkHostForClusterEnv(clusterEnv string, logger *zap.Logger) (string, error) {
 	if clusterEnv == "" {
 		clusterEnv = "prod"
 		logger.Warn("CLUSTER_ENV not set, defaulting to production for TLS verification",
@@ -222,6 +237,22 @@ func getClusterEnv(logger *zap.Logger) (string, error) {
 	return checkHost, nil
 }
 
+// SetClusterEnv switches the host TLS certificates are verified against. Tunnels created from now on
+// use the new checkHost, existing tunnel configs keep theirs until the volume is mounted again.
+func (sm *StunnelManager) SetClusterEnv(clusterEnv string) error {
+	checkHost, err := checkHostForClusterEnv(clusterEnv, sm.logger)
+	if err != nil {
+		return err
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.checkHost != checkHost {
+		sm.logger.Info("Updated checkHost for new tunnels", zap.String("oldCheckHost", sm.checkHost), zap.String("checkHost", checkHost))
+		sm.checkHost = checkHost
+	}
+	return nil
+}
+
 // recoverExistingTunnels scans the services directory and rebuilds the port allocation map.
 // Called once during construction to restore state after a CSI node pod restart.
 func (sm *StunnelManager) recoverExistingTunnels() error {
@@ -259,6 +290,7 @@ func (sm *StunnelManager) recoverExistingTunnels() error {
 		}
 	}
 
+	sm.updatePortMetrics()
 	sm.logger.Info("Recovery complete",
 		zap.Int("tunnelCount", len(sm.allocatedPorts)))
 
@@ -399,6 +431,7 @@ func (sm *StunnelManager) EnsureTunnel(volumeID, nfsServer, requestID string) (i
 	// Commit port allocation (file write succeeded)
 	sm.allocatedPorts[volumeID] = port
 	sm.portToVolume[port] = volumeID
+	sm.updatePortMetrics()
 
 	sm.logger.Info("Created tunnel config",
 		zap.String("RequestID", requestID),
@@ -423,6 +456,7 @@ func (sm *StunnelManager) EnsureTunnel(volumeID, nfsServer, requestID string) (i
 			// Rollback: remove port allocation and config
 			delete(sm.allocatedPorts, volumeID)
 			delete(sm.portToVolume, port)
+			sm.updatePortMetrics()
 			_ = os.Remove(configPath) // #nosec G104: Best effort remove, error not actionable
 			return 0, fmt.Errorf("stunnel not running after %v wait, retry on mount failure", StunnelStartupWaitTime)
 		}
@@ -499,6 +533,28 @@ func (sm *StunnelManager) scheduleDebouncedSIGHUP(requestID string) {
 		zap.Duration("debounceWindow", sm.debounceWindow))
 }
 
+// FlushPendingSIGHUP sends a debounced SIGHUP right away instead of waiting for the
+// debounce window, so stunnel loads the latest configs before the node server exits.
+// It is a no-op when no SIGHUP is pending.
+func (sm *StunnelManager) FlushPendingSIGHUP(requestID string) error {
+	sm.debounceMu.Lock()
+	defer sm.debounceMu.Unlock()
+
+	if sm.debounceTimer != nil {
+		sm.debounceTimer.Stop()
+	}
+	if !sm.pendingSIGHUP {
+		return nil
+	}
+	sm.pendingSIGHUP = false
+
+	sm.logger.Info("Flushing pending SIGHUP to stunnel", zap.String("RequestID", requestID))
+	if err := sm.reloadStunnel(requestID); err != nil {
+		return fmt.Errorf("failed to flush pending SIGHUP: %w", err)
+	}
+	return nil
+}
+
 // isStunnelRunning checks if the stunnel process is currently running.
 func (sm *StunnelManager) isStunnelRunning() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), PgrepTimeout)
@@ -619,6 +675,7 @@ func (sm *StunnelManager) RemoveTunnel(volumeID, requestID string) error {
 	// double os.Remove. Failures below roll back both maps.
 	delete(sm.allocatedPorts, volumeID)
 	delete(sm.portToVolume, tunnelPort)
+	sm.updatePortMetrics()
 
 	sm.mu.Unlock()
 	// sm.mu is NOT held from here — both helpers are free to acquire debounceMu.
@@ -628,6 +685,7 @@ func (sm *StunnelManager) RemoveTunnel(volumeID, requestID string) error {
 			sm.mu.Lock()
 			sm.allocatedPorts[volumeID] = tunnelPort
 			sm.portToVolume[tunnelPort] = volumeID
+			sm.updatePortMetrics()
 			sm.mu.Unlock()
 			return err
 		}
@@ -637,6 +695,7 @@ func (sm *StunnelManager) RemoveTunnel(volumeID, requestID string) error {
 		sm.mu.Lock()
 		sm.allocatedPorts[volumeID] = tunnelPort
 		sm.portToVolume[tunnelPort] = volumeID
+		sm.updatePortMetrics()
 		sm.mu.Unlock()
 		sm.logger.Error("Failed to remove config file, rolled back port release",
 			zap.String("RequestID", requestID),
@@ -738,6 +797,94 @@ func (sm *StunnelManager) isTunnelPortInUse(port int) bool {
 	return false
 }
 
+// updatePortMetrics publishes the number of allocated ports. Must be called with sm.mu held.
+func (sm *StunnelManager) updatePortMetrics() {
+	driverMetrics.AllocatedStunnelPorts.Set(float64(len(sm.allocatedPorts)))
+}
+
+// ActiveTunnelCount returns the number of allocated tunnels whose port is used by
+// at least one NFS mount, based on a single read of /proc/mounts.
+func (sm *StunnelManager) ActiveTunnelCount() int {
+	data, err := os.ReadFile("/proc/mounts")
+	if err != nil {
+		sm.logger.Debug("Failed to read /proc/mounts for active tunnel count", zap.Error(err))
+		return 0
+	}
+
+	// Mount entries look like: 127.0.0.1:/EXPORT /mountpoint nfs4 rw,...,port=20000,... 0 0
+	mountedPorts := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] != "nfs4" || !strings.HasPrefix(fields[0], "127.0.0.1:") {
+			continue
+		}
+		for _, option := range strings.Split(fields[3], ",") {
+			if strings.HasPrefix(option, "port=") {
+				mountedPorts[strings.TrimPrefix(option, "port=")] = true
+			}
+		}
+	}
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	active := 0
+	for port := range sm.portToVolume {
+		if mountedPorts[strconv.Itoa(port)] {
+			active++
+		}
+	}
+	return active
+}
+
+// HealthCheck verifies that the stunnel process is running while tunnels are
+// allocated and that the tunnel configs on disk match the port allocation map.
+// It returns nil when no tunnel is allocated, stunnel is not needed then.
+func (sm *StunnelManager) HealthCheck() error {
+	sm.mu.RLock()
+	allocated := make(map[string]int, len(sm.allocatedPorts))
+	for volumeID, port := range sm.allocatedPorts {
+		allocated[volumeID] = port
+	}
+	sm.mu.RUnlock()
+
+	if len(allocated) == 0 {
+		return nil
+	}
+
+	var errs []error
+	if !sm.isStunnelRunning() {
+		errs = append(errs, fmt.Errorf("stunnel process is not running with %d tunnel(s) configured, check the stunnel sidecar container", len(allocated)))
+	}
+
+	for volumeID, port := range allocated {
+		configPath := filepath.Join(sm.servicesDir, volumeID+".conf")
+		configPort, err := sm.extractPortFromConfigFile(configPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tunnel config of volume %s: %w", volumeID, err))
+			continue
+		}
+		if configPort != port {
+			errs = append(errs, fmt.Errorf("tunnel config of volume %s accepts on port %d, expected port %d", volumeID, configPort, port))
+		}
+	}
+
+	files, err := os.ReadDir(sm.servicesDir)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to read services directory: %w", err))
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".conf" {
+			continue
+		}
+		if _, ok := allocated[strings.TrimSuffix(file.Name(), ".conf")]; !ok {
+			errs = append(errs, fmt.Errorf("tunnel config %s has no port allocated", file.Name()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // GetTunnelPort returns the port allocated to a volume, or (0, false) if none.
 func (sm *StunnelManager) GetTunnelPort(volumeID string) (int, bool) {
 	if volumeID == "" {
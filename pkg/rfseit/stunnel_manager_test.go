@@ -193,6 +193,37 @@ func TestDetectCABundle(t *testing.T) {
 	}
 }
 
+// TestSetClusterEnv tests switching the checkHost of new tunnels
+func TestSetClusterEnv(t *testing.T) {
+	sm := &StunnelManager{
+		checkHost: ProductionCheckHost,
+		logger:    zaptest.NewLogger(t),
+	}
+
+	tests := []struct {
+		clusterEnv string
+		want       string
+	}{
+		{clusterEnv: "stage", want: StagingCheckHost},
+		{clusterEnv: "unknown", want: ProductionCheckHost},
+		{clusterEnv: "stage", want: StagingCheckHost},
+		{clusterEnv: "prod", want: ProductionCheckHost},
+	}
+
+	for _, tt := range tests {
+		if err := sm.SetClusterEnv(tt.clusterEnv); err != nil {
+			t.Fatalf("SetClusterEnv(%q) unexpected error: %v", tt.clusterEnv, err)
+		}
+		if sm.checkHost != tt.want {
+			t.Errorf("SetClusterEnv(%q) checkHost = %v, want %v", tt.clusterEnv, sm.checkHost, tt.want)
+		}
+		config := sm.buildTunnelConfig("vol-1", "10.0.0.1", InitialPort)
+		if !strings.Contains(config, "checkHost = "+tt.want) {
+			t.Errorf("SetClusterEnv(%q) tunnel config does not use checkHost %v:\n%s", tt.clusterEnv, tt.want, config)
+		}
+	}
+}
+
 // TestGetCheckHost tests checkHost determination
 func TestGetCheckHost(t *testing.T) {
 	logger := zaptest.NewLogger(t)
@@ -820,6 +851,71 @@ func TestIsTunnelPortInUse(t *testing.T) {
 	}
 }
 
+// TestActiveTunnelCount verifies allocated ports without mounts are not counted as active
+func TestActiveTunnelCount(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	sm := &StunnelManager{
+		allocatedPorts: map[string]int{"vol1": 11301},
+		portToVolume:   map[int]string{11301: "vol1"},
+		logger:         logger,
+	}
+
+	// No NFS mount on the test host uses the tunnel port
+	if got := sm.ActiveTunnelCount(); got != 0 {
+		t.Errorf("ActiveTunnelCount() = %d, want 0", got)
+	}
+}
+
+// TestHealthCheck tests stunnel process and tunnel config consistency checks
+func TestHealthCheck(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	servicesDir := t.TempDir()
+	sm := &StunnelManager{
+		servicesDir:    servicesDir,
+		initialPort:    InitialPort,
+		portRange:      PortRange,
+		allocatedPorts: map[string]int{},
+		portToVolume:   map[int]string{},
+		logger:         logger,
+	}
+
+	// Nothing allocated, stunnel is not required
+	if err := sm.HealthCheck(); err != nil {
+		t.Errorf("HealthCheck() with no tunnels = %v, want nil", err)
+	}
+
+	sm.allocatedPorts = map[string]int{"vol1": 11301, "vol2": 11302}
+	sm.portToVolume = map[int]string{11301: "vol1", 11302: "vol2"}
+	if err := os.WriteFile(filepath.Join(servicesDir, "vol1.conf"), []byte(sm.buildTunnelConfig("vol1", "server1", 11301)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(servicesDir, "vol2.conf"), []byte(sm.buildTunnelConfig("vol2", "server2", 11399)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(servicesDir, "orphan.conf"), []byte(sm.buildTunnelConfig("orphan", "server3", 11303)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := sm.HealthCheck()
+	if err == nil {
+		t.Fatal("HealthCheck() expected error for inconsistent tunnel configs")
+	}
+	for _, want := range []string{
+		"volume vol2 accepts on port 11399, expected port 11302",
+		"tunnel config orphan.conf has no port allocated",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("HealthCheck() error = %q, want it to contain %q", err.Error(), want)
+		}
+	}
+	if strings.Contains(err.Error(), "vol1") {
+		t.Errorf("HealthCheck() error = %q, consistent tunnel vol1 should not be reported", err.Error())
+	}
+	if !sm.isStunnelRunning() && !strings.Contains(err.Error(), "stunnel process is not running with 2 tunnel(s) configured") {
+		t.Errorf("HealthCheck() error = %q, want stunnel process failure", err.Error())
+	}
+}
+
 // TestGetTunnelPort tests port retrieval
 func TestGetTunnelPort(t *testing.T) {
 	logger := zaptest.NewLogger(t)
@@ -1596,3 +1692,33 @@ func TestRemoveTunnel_AdditionalCases(t *testing.T) {
 		})
 	}
 }
+
+// TestFlushPendingSIGHUP tests that a pending debounced SIGHUP is sent without waiting for the window
+func TestFlushPendingSIGHUP(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	sm := &StunnelManager{
+		logger:         logger,
+		debounceWindow: time.Hour,
+		stunnelStarted: true,
+	}
+
+	// Nothing pending
+	if err := sm.FlushPendingSIGHUP("flush-request"); err != nil {
+		t.Errorf("FlushPendingSIGHUP() with nothing pending error = %v, want nil", err)
+	}
+
+	sm.scheduleDebouncedSIGHUP("request-1")
+	// stunnel is not running in the test environment, so the flush reports the reload failure
+	if err := sm.FlushPendingSIGHUP("flush-request"); err == nil {
+		t.Error("FlushPendingSIGHUP() expected error when stunnel is not running, got nil")
+	}
+
+	sm.debounceMu.Lock()
+	defer sm.debounceMu.Unlock()
+	if sm.pendingSIGHUP {
+		t.Error("pendingSIGHUP should be false after flush")
+	}
+	if sm.debounceTimer.Stop() {
+		t.Error("debounceTimer should already be stopped after flush")
+	}
+}
@@ -0,0 +1,153 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tracing sets up optional OpenTelemetry tracing for the IBM VPC File CSI driver.
+// When no OTLP endpoint is configured the global no-op tracer provider is kept and
+// spans cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TracerName instrumentation scope of the driver spans
+const TracerName = "github.com/IBM/ibm-vpc-file-csi-driver"
+
+// ShutdownTimeout bounds how long pending spans are flushed on shutdown
+const ShutdownTimeout = 5 * time.Second
+
+// RequestIDKey span attribute carrying the driver requestID, the same one that is logged
+const RequestIDKey = attribute.Key("csi.request_id")
+
+// Config ...
+type Config struct {
+	// Endpoint OTLP gRPC collector address (host:port), tracing is disabled when empty
+	Endpoint string
+
+	// Insecure disables TLS towards the collector
+	Insecure bool
+
+	// SampleRatio fraction of traces sampled, between 0 and 1
+	SampleRatio float64
+
+	// ServiceName reported as the service.name resource attribute
+	ServiceName string
+
+	// ServiceVersion reported as the service.version resource attribute
+	ServiceVersion string
+}
+
+// Setup installs the global tracer provider. The returned func flushes and
+// stops the exporter and must be called on shutdown.
+func Setup(ctx context.Context, config Config, logger *zap.Logger) (func(context.Context) error, error) {
+	if config.Endpoint == "" {
+		logger.Info("OpenTelemetry tracing is disabled")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", config.ServiceName),
+		attribute.String("service.version", config.ServiceVersion),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	logger.Info("OpenTelemetry tracing is enabled",
+		zap.String("endpoint", config.Endpoint),
+		zap.Bool("insecure", config.Insecure),
+		zap.Float64("sampleRatio", config.SampleRatio))
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the driver tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// StartSpan starts a child span of the span in ctx, tagged with the requestID when known
+func StartSpan(ctx context.Context, name string, requestID string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if requestID = strings.TrimSpace(requestID); requestID != "" {
+		attrs = append(attrs, RequestIDKey.String(requestID))
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// SetRequestID tags the current span with the requestID
+func SetRequestID(ctx context.Context, requestID string) {
+	if requestID = strings.TrimSpace(requestID); requestID != "" {
+		trace.SpanFromContext(ctx).SetAttributes(RequestIDKey.String(requestID))
+	}
+}
+
+// EndSpan records err on the span, if any, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// UnaryServerInterceptor starts one server span per CSI gRPC method
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := Tracer().Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", info.FullMethod),
+		))
+	defer span.End()
+
+	resp, err := handler(ctx, req)
+	code := status.Code(err)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()))
+	if code != grpcCodes.OK {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, status.Convert(err).Message())
+	}
+	return resp, err
+}
@@ -0,0 +1,126 @@
+/**
+ *
+ * Copyright 2026 IBM Inc. All rights reserved
+ * SPDX-License-Identifier: Apache2.0
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpcCodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = tp.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{}, zap.NewNop())
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+}
+
+func TestStartSpan(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	_, span := StartSpan(context.Background(), "processMount", "req-1")
+	EndSpan(span, nil)
+	_, span = StartSpan(context.Background(), "EnsureTunnel", " ")
+	EndSpan(span, errors.New("no free port"))
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "processMount", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, RequestIDKey.String("req-1"))
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	assert.Equal(t, "EnsureTunnel", spans[1].Name)
+	assert.Empty(t, spans[1].Attributes)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "no free port", spans[1].Status.Description)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	testcases := []struct {
+		testCaseName   string
+		handlerErr     error
+		expectedStatus codes.Code
+		expectedCode   string
+	}{
+		{
+			testCaseName:   "Success",
+			handlerErr:     nil,
+			expectedStatus: codes.Unset,
+			expectedCode:   "OK",
+		},
+		{
+			testCaseName:   "Failure",
+			handlerErr:     status.Error(grpcCodes.NotFound, "volume not found"),
+			expectedStatus: codes.Error,
+			expectedCode:   "NotFound",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.testCaseName, func(t *testing.T) {
+			exporter := newTestExporter(t)
+			info := &grpc.UnaryServerInfo{FullMethod: "/csi.v1.Controller/CreateVolume"}
+
+			var parent trace.SpanContext
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				SetRequestID(ctx, "req-1")
+				_, child := StartSpan(ctx, "provider.CreateVolume", "req-1")
+				EndSpan(child, nil)
+				parent = trace.SpanContextFromContext(ctx)
+				return nil, testcase.handlerErr
+			}
+			_, err := UnaryServerInterceptor(context.Background(), nil, info, handler)
+			assert.Equal(t, testcase.handlerErr, err)
+
+			spans := exporter.GetSpans()
+			assert.Len(t, spans, 2)
+			child, server := spans[0], spans[1]
+			assert.Equal(t, info.FullMethod, server.Name)
+			assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+			assert.Equal(t, testcase.expectedStatus, server.Status.Code)
+			assert.Contains(t, server.Attributes, RequestIDKey.String("req-1"))
+			assert.Contains(t, server.Attributes, attribute.String("rpc.grpc.status_code", testcase.expectedCode))
+			assert.Equal(t, parent.SpanID(), child.Parent.SpanID())
+			assert.Equal(t, server.SpanContext.TraceID(), child.SpanContext.TraceID())
+		})
+	}
+}
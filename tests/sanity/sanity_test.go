@@ -152,10 +152,11 @@ func initCSIDriverForSanity(t *testing.T) *csiDriver.IBMCSIDriver {
 	logger, teardown := cloudProvider.GetTestLogger(t)
 	defer teardown()
 	csiSanityDriver := csiDriver.GetIBMCSIDriver()
+	csiSanityDriver.SetMode(csiDriver.ModeAll)
 
 	// Create fake provider and mounter
 	provider, _ := NewFakeSanityCloudProvider("", logger)
-	mounter := mountManager.NewFakeNodeMounter()
+	mounter := &sanityMounter{mountManager.NewFakeNodeMounter()}
 
 	statsUtil := &MockStatSanity{}
 
@@ -200,6 +201,17 @@ type FakeSanityCloudProvider struct {
 
 var _ cloudProvider.CloudProviderInterface = &FakeSanityCloudProvider{}
 
+// sanityMounter creates the directories like the node mounter, so that the fake mount table forgets
+// unpublished target paths and the staging path can be unstaged
+type sanityMounter struct {
+	mountManager.Mounter
+}
+
+// MakeDir ...
+func (m *sanityMounter) MakeDir(pathname string) error {
+	return os.MkdirAll(pathname, 0750)
+}
+
 // NewFakeSanityCloudProvider ...
 func NewFakeSanityCloudProvider(_ string, _ *zap.Logger) (*FakeSanityCloudProvider, error) {
 	return &FakeSanityCloudProvider{ProviderName: "FakeSanityCloudProvider",